@@ -4,14 +4,20 @@ import (
 	"GoAssembly/pkg/assembly"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type CommandLineOptions struct {
 	inputFile *string
 	molFile *bool
+	multigraph *bool
+	directed *bool
 	logFile *string
 	numWorkers *int
 	bufferSize *int
@@ -20,6 +26,30 @@ type CommandLineOptions struct {
 	verbose *bool
 	log *bool
 	pathway *bool
+	mode *string
+	strings *bool
+	fasta *bool
+	sequenceType *string
+	coarse *bool
+	joint *bool
+	space *bool
+	require *string
+	requireAtoms *string
+	keepBonds *string
+	forbid *string
+	minFragment *int
+	maxFragment *int
+	contract *string
+	colouring *string
+	hydrogens *string
+	rings *bool
+	rxn *bool
+	inFormat *string
+	vertexAttr *string
+	edgeAttr *string
+	dot *string
+	graphML *string
+	svg *string
 	tail []string
 	}
 
@@ -29,12 +59,477 @@ func check(e error) {
 	}
 }
 
+// readGraph reads a single graph from a mol file or graph file, depending on the command line options
+func readGraph(inFile string, CLArgs CommandLineOptions) assembly.Graph {
+	if *CLArgs.molFile {
+		g, err := assembly.MolGraphFromFileColouring(inFile, molColouring(*CLArgs.colouring, *CLArgs.hydrogens))
+		check(err)
+		return g
+	}
+	switch *CLArgs.inFormat {
+	case assembly.FormatGraphML, assembly.FormatGML, assembly.FormatDOT:
+		options := assembly.GraphFormatOptions{
+			VertexColour: *CLArgs.vertexAttr,
+			EdgeColour:   *CLArgs.edgeAttr,
+			Multigraph:   *CLArgs.multigraph,
+			Directed:     *CLArgs.directed,
+		}
+		g, _, err := assembly.NewGraphFromFormatFile(inFile, *CLArgs.inFormat, options)
+		check(err)
+		return g
+	}
+	g, _, err := assembly.NewGraphFromFileOptions(inFile, *CLArgs.multigraph, *CLArgs.directed)
+	check(err)
+	return g
+}
+
+// molColouring parses the -colouring option, which chooses the colours of mol file graphs, and the -hydrogens option,
+// which chooses whether they have hydrogen atoms
+func molColouring(option string, hydrogensOption string) assembly.Colouring {
+	colouring, err := assembly.ParseColouring(option)
+	check(err)
+	colouring.Hydrogens, err = assembly.ParseHydrogens(hydrogensOption)
+	check(err)
+	return colouring
+}
+
+// molNumbering returns the mol file numbers of the atoms and bonds of the graph read from a mol file, with the hydrogen
+// atoms chosen by the -hydrogens option
+func molNumbering(inFile string, CLArgs CommandLineOptions) assembly.MolNumbering {
+	numbering, err := assembly.MolNumberingHydrogens(inFile, molColouring(*CLArgs.colouring, *CLArgs.hydrogens).Hydrogens)
+	check(err)
+	return numbering
+}
+
+// depict returns how to draw the graph read from inFile: from the mol file coordinates for a mol file, with the hydrogen
+// atoms chosen by the -hydrogens option, otherwise laid out
+func depict(inFile string, g *assembly.Graph, CLArgs CommandLineOptions) assembly.Depiction {
+	if !*CLArgs.molFile {
+		return assembly.GraphDepiction(g)
+	}
+	depiction, err := assembly.MolDepictionFromFile(inFile, molColouring(*CLArgs.colouring, *CLArgs.hydrogens).Hydrogens)
+	check(err)
+	return depiction
+}
+
+// requiredDuplicates parses the -require fragments and -requireatoms atom numbers into the duplicates the pathway must use
+func requiredDuplicates(inFile string, CLArgs CommandLineOptions) []assembly.RequiredDuplicate {
+	var required []assembly.RequiredDuplicate
+	if *CLArgs.require != "" {
+		for _, fragmentString := range strings.Split(*CLArgs.require, ",") {
+			fragment, err := assembly.ParseFragment(strings.TrimSpace(fragmentString))
+			check(err)
+			required = append(required, assembly.RequiredFragment(fragment))
+		}
+	}
+
+	if *CLArgs.requireAtoms != "" {
+		if !*CLArgs.molFile {
+			check(fmt.Errorf("-requireatoms needs a mol file"))
+		}
+		numbering := molNumbering(inFile, CLArgs)
+		for _, pairString := range strings.Split(*CLArgs.requireAtoms, ";") {
+			sides := strings.Split(pairString, ":")
+			if len(sides) != 2 {
+				check(fmt.Errorf("bad -requireatoms %v, each fragment must be two atom lists separated by :", pairString))
+			}
+			var atoms [2][]int
+			for side := range sides {
+				for _, atomString := range strings.Split(sides[side], ",") {
+					atom, err := strconv.Atoi(strings.TrimSpace(atomString))
+					check(err)
+					atoms[side] = append(atoms[side], atom)
+				}
+			}
+			r, err := assembly.RequiredAtoms(numbering, atoms[0], atoms[1])
+			check(err)
+			required = append(required, r)
+		}
+	}
+	return required
+}
+
+// searchConstraints parses the -keepbonds, -forbid, -minfragment and -maxfragment options into the search constraints,
+// or returns nil if none are given
+func searchConstraints(inFile string, g *assembly.Graph, CLArgs CommandLineOptions) *assembly.Constraints {
+	if *CLArgs.keepBonds == "" && *CLArgs.forbid == "" && *CLArgs.minFragment == 0 && *CLArgs.maxFragment == 0 {
+		return nil
+	}
+
+	var keep [][2]int
+	if *CLArgs.keepBonds != "" {
+		var bonds []int
+		for _, bondString := range strings.Split(*CLArgs.keepBonds, ",") {
+			bond, err := strconv.Atoi(strings.TrimSpace(bondString))
+			check(err)
+			bonds = append(bonds, bond)
+		}
+		if *CLArgs.molFile {
+			var err error
+			keep, err = assembly.KeepMolBonds(g, molNumbering(inFile, CLArgs), bonds)
+			check(err)
+		} else {
+			for _, bond := range bonds {
+				if bond < 1 || bond > len(g.Edges) {
+					check(fmt.Errorf("bond %v is not in the graph, which has %v edges", bond, len(g.Edges)))
+				}
+				keep = append(keep, g.Edges[bond-1])
+			}
+		}
+	}
+
+	var forbidden []assembly.Graph
+	if *CLArgs.forbid != "" {
+		for _, fragmentString := range strings.Split(*CLArgs.forbid, ",") {
+			fragment, err := assembly.ParseFragment(strings.TrimSpace(fragmentString))
+			check(err)
+			forbidden = append(forbidden, fragment)
+		}
+	}
+
+	constraints, err := assembly.NewConstraints(g, keep, forbidden, *CLArgs.minFragment, *CLArgs.maxFragment)
+	check(err)
+	return constraints
+}
+
+// jointCommand finds the joint assembly index of the graphs in all the input files
+func jointCommand(inFiles []string, CLArgs CommandLineOptions) {
+	var graphs []assembly.Graph
+	for _, inFile := range inFiles {
+		graphs = append(graphs, readGraph(inFile, CLArgs))
+	}
+
+	start := time.Now()
+	combined, targets, pathways := assembly.JointAssembly(graphs, *CLArgs.numWorkers, *CLArgs.bufferSize, *CLArgs.variant)
+	elapsed := time.Now().Sub(start)
+
+	if *CLArgs.verbose {
+		for i, inFile := range inFiles {
+			fmt.Println("Target", i, inFile)
+		}
+		fmt.Println(assembly.JointAssemblyString(pathways, &combined, targets))
+		fmt.Println("Time: ", elapsed.Seconds())
+	} else {
+		fmt.Println(assembly.JointAssemblyIndex(&pathways[0], &combined))
+	}
+}
+
+// reactionsCommand assembles the reactants and products of every reaction in the RXN or RD input files, and outputs
+// their assembly indices, the difference and the joint assembly index of the reactants, one reaction per line.
+// Reactions without a name are named by their file and position in it
+func reactionsCommand(inFiles []string, CLArgs CommandLineOptions) {
+	for _, inFile := range inFiles {
+		reactions, err := assembly.ParseReactionsFile(inFile, molColouring(*CLArgs.colouring, *CLArgs.hydrogens))
+		check(err)
+		for i := range reactions {
+			name := reactions[i].Name
+			if name == "" {
+				name = fmt.Sprintf("%v:%v", inFile, i+1)
+			}
+			indices := assembly.ReactionAssembly(&reactions[i], *CLArgs.numWorkers, *CLArgs.bufferSize, *CLArgs.variant)
+			fmt.Println(assembly.ReactionIndicesString(name, indices))
+		}
+	}
+}
+
+// contractCommand coarse grains the input graph with the rules in the -contract rule file, and assembles the coarse graph.
+// Both the assembly index of the coarse graph and the upper bound on the assembly index of the input graph are output
+func contractCommand(inFile string, CLArgs CommandLineOptions) {
+	rules, err := assembly.ParseCoarseRulesFile(*CLArgs.contract)
+	check(err)
+	g := readGraph(inFile, CLArgs)
+	var numbering *assembly.MolNumbering
+	if *CLArgs.molFile {
+		fileNumbering := molNumbering(inFile, CLArgs)
+		numbering = &fileNumbering
+	}
+
+	start := time.Now()
+	coarse, pathways, expanded, err := assembly.CoarseAssembly(g, rules, numbering, *CLArgs.numWorkers, *CLArgs.bufferSize, *CLArgs.variant)
+	check(err)
+	elapsed := time.Now().Sub(start)
+
+	coarseIndex := assembly.AssemblyIndex(&pathways[0], &coarse.Graph)
+	atomIndex := assembly.AssemblyIndex(&expanded, &g)
+	if *CLArgs.verbose {
+		fmt.Println("Running on file: ", inFile)
+		assemblyString, err := assembly.CoarseAssemblyString(&coarse, pathways, &expanded, numbering)
+		check(err)
+		fmt.Println(assemblyString)
+		fmt.Println("Coarse Assembly Index: ", coarseIndex)
+		fmt.Println("Expanded Assembly Index (upper bound): ", atomIndex)
+		fmt.Println("Time: ", elapsed.Seconds())
+	} else {
+		fmt.Println(coarseIndex, atomIndex)
+	}
+}
+
+// stringsCommand calculates the assembly index of each line in a file of strings
+func stringsCommand(inFile string, verbose bool) {
+	data, err := ioutil.ReadFile(inFile)
+	check(err)
+	for _, s := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if s == "" {
+			continue
+		}
+		if verbose {
+			pathways := assembly.StringAssembly(s)
+			g := assembly.StringGraph(s)
+			fmt.Println(assembly.AssemblyString(pathways, &g))
+			fmt.Println(s, assembly.AssemblyIndex(&pathways[0], &g))
+		} else {
+			fmt.Println(s, assembly.StringAssemblyIndex(s))
+		}
+	}
+}
+
+// fastaCommand assembles each sequence in a FASTA file, outputting the sequence name and assembly index on each line
+func fastaCommand(inFile string, CLArgs CommandLineOptions, edgeMode bool) {
+	records, err := assembly.ParseFastaFile(inFile)
+	check(err)
+	for _, record := range records {
+		g, err := assembly.SequenceGraph(record.Sequence, *CLArgs.sequenceType, !*CLArgs.coarse)
+		check(err)
+		pathways := assembly.AssemblyMode(g, *CLArgs.numWorkers, *CLArgs.bufferSize, *CLArgs.variant, edgeMode)
+		assemblyIndex := assembly.AssemblyIndexMode(&pathways[0], &g, edgeMode)
+		if *CLArgs.verbose {
+			fmt.Println(assembly.AssemblyString(pathways, &g))
+		}
+		fmt.Println(record.Name, assemblyIndex)
+	}
+}
+
+// validateCommand checks input files and prints any issues with the graphs in them, without running assembly
+// Returns the exit code, which is 1 if any graph is invalid
+func validateCommand(args []string) int {
+	validateFlags := flag.NewFlagSet("validate", flag.ExitOnError)
+	molFile := validateFlags.Bool("molfile", true, "true if molfile, false if general graph file")
+	multigraph := validateFlags.Bool("multigraph", false, "general graph file may contain parallel edges and self-loops")
+	directed := validateFlags.Bool("directed", false, "general graph file edges are directed")
+	pathway := validateFlags.Bool("pathway", false, "the input file contains multiple graphs, e.g. an sdf file")
+	colouring := validateFlags.String("colouring", "element", "the colours of mol file graphs, see the main -colouring flag")
+	hydrogens := validateFlags.String("hydrogens", "strip", "the hydrogen atoms of mol file graphs, see the main -hydrogens flag")
+	check(validateFlags.Parse(args))
+
+	exitCode := 0
+	for _, inFile := range validateFlags.Args() {
+		var errs []error
+		if *pathway {
+			fileBytes, err := ioutil.ReadFile(inFile)
+			check(err)
+			_, errs = assembly.ValidateMultiMolString(string(fileBytes))
+		} else if *molFile {
+			_, err := assembly.MolGraphFromFileColouring(inFile, molColouring(*colouring, *hydrogens))
+			errs = []error{err}
+		} else if *multigraph || *directed {
+			_, _, err := assembly.NewGraphFromFileOptions(inFile, *multigraph, *directed)
+			errs = []error{err}
+		} else {
+			_, _, err := assembly.NewGraphFromFile(inFile)
+			errs = []error{err}
+		}
+
+		fileValid := true
+		for i, err := range errs {
+			if err != nil {
+				fmt.Printf("%v (graph %v): %v\n", inFile, i, err)
+				fileValid = false
+				exitCode = 1
+			}
+		}
+		if fileValid {
+			fmt.Printf("%v: valid\n", inFile)
+		}
+	}
+	return exitCode
+}
+
+// verifyCommand checks a pathway, in the form of the -verbose output, against the original graph it is for, and prints
+// any issues with it. Returns the exit code, which is 1 if the pathway is invalid
+func verifyCommand(args []string) int {
+	verifyFlags := flag.NewFlagSet("verify", flag.ExitOnError)
+	molFile := verifyFlags.Bool("molfile", true, "true if molfile, false if general graph file")
+	multigraph := verifyFlags.Bool("multigraph", false, "general graph file may contain parallel edges and self-loops")
+	directed := verifyFlags.Bool("directed", false, "general graph file edges are directed")
+	index := verifyFlags.Int("index", -1, "the claimed assembly index, if not given in the pathway file")
+	colouring := verifyFlags.String("colouring", "element", "the colours of mol file graphs, which must be those the pathway was found with")
+	hydrogens := verifyFlags.String("hydrogens", "strip", "the hydrogen atoms of mol file graphs, which must be those the pathway was found with")
+	check(verifyFlags.Parse(args))
+	if verifyFlags.NArg() != 2 {
+		check(fmt.Errorf("verify needs the original graph file and the pathway file"))
+	}
+	inFile, pathwayFile := verifyFlags.Arg(0), verifyFlags.Arg(1)
+
+	var original assembly.Graph
+	var err error
+	if *molFile {
+		original, err = assembly.MolGraphFromFileColouring(inFile, molColouring(*colouring, *hydrogens))
+	} else {
+		original, _, err = assembly.NewGraphFromFileOptions(inFile, *multigraph, *directed)
+	}
+	check(err)
+
+	pathwayBytes, err := ioutil.ReadFile(pathwayFile)
+	check(err)
+	pathway, claimedIndex, err := assembly.ParsePathwayString(string(pathwayBytes))
+	check(err)
+	if *index != -1 {
+		claimedIndex = *index
+	}
+
+	if claimedIndex == -1 {
+		err = assembly.VerifyPathway(&original, &pathway)
+	} else {
+		err = assembly.VerifyPathwayIndex(&original, &pathway, claimedIndex)
+	}
+	if err != nil {
+		fmt.Printf("%v: %v\n", pathwayFile, err)
+		return 1
+	}
+	fmt.Printf("%v: valid, assembly index %v\n", pathwayFile, assembly.AssemblyIndex(&pathway, &original))
+	return 0
+}
+
+// serveCommand runs an HTTP service that assembles the mol blocks posted to it: POST a mol block to /assembly for its
+// assembly index, or to /svg for an SVG drawing of the molecule with each duplicated fragment of the pathway highlighted,
+// with the assembly index in the Assembly-Index header. Returns the exit code when the service stops
+func serveCommand(args []string) int {
+	serveFlags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := serveFlags.String("addr", "localhost:8080", "the address to listen on")
+	numWorkers := serveFlags.Int("workers", 100, "the number of workers in the worker pool of each request")
+	bufferSize := serveFlags.Int("buffer", 100, "the buffer size of the jobs queue of each request")
+	colouring := serveFlags.String("colouring", "element", "the colours of mol file graphs, see the main -colouring flag")
+	hydrogens := serveFlags.String("hydrogens", "strip", "the hydrogen atoms of mol file graphs, see the main -hydrogens flag")
+	concurrent := serveFlags.Int("concurrent", 2, "the most assemblies to run at once, other requests wait for one to finish")
+	maxBonds := serveFlags.Int("maxbonds", 100, "the most bonds a molecule may have, larger ones are refused")
+	timeout := serveFlags.Duration("timeout", time.Minute, "how long a request waits for its assembly")
+	check(serveFlags.Parse(args))
+	molColours := molColouring(*colouring, *hydrogens)
+	if *concurrent < 1 {
+		check(fmt.Errorf("-concurrent must be at least 1, got %v", *concurrent))
+	}
+	limits := serveLimits{make(chan struct{}, *concurrent), *maxBonds, *timeout}
+
+	http.HandleFunc("/assembly", func(w http.ResponseWriter, r *http.Request) {
+		serveMolBlock(w, r, molColours, *numWorkers, *bufferSize, limits, false)
+	})
+	http.HandleFunc("/svg", func(w http.ResponseWriter, r *http.Request) {
+		serveMolBlock(w, r, molColours, *numWorkers, *bufferSize, limits, true)
+	})
+	fmt.Printf("listening on %v\n", *addr)
+	fmt.Println(http.ListenAndServe(*addr, nil))
+	return 1
+}
+
+// serveLimits bound the work of the serve command. slots holds a value for each assembly running, maxBonds is the most
+// bonds a molecule may have, and timeout is how long a request waits for a slot and its assembly
+type serveLimits struct {
+	slots chan struct{}
+	maxBonds int
+	timeout time.Duration
+}
+
+// serveMolBlock handles a request to the serve command, whose body is a mol block of at most 1 MB. It responds with the
+// assembly index as text, or if svg is true with the SVG drawing of the molecule. Mol blocks that can't be read or are
+// invalid are a bad request, and molecules with more than limits.maxBonds bonds are unprocessable. If there is no slot
+// free or the assembly doesn't finish within limits.timeout, the service is unavailable. An assembly can't be stopped,
+// so it keeps its slot until it finishes, even after its request has timed out
+func serveMolBlock(w http.ResponseWriter, r *http.Request, colouring assembly.Colouring, numWorkers int, bufferSize int, limits serveLimits, svg bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "POST a mol block", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	molBlock := string(body)
+	g, err := assembly.MolGraphFromStringColouring(molBlock, colouring)
+	if err == nil && len(g.Vertices) == 0 {
+		err = fmt.Errorf("no atoms in the mol block")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if len(g.Edges) > limits.maxBonds {
+		http.Error(w, fmt.Sprintf("the molecule has %v bonds, the most is %v", len(g.Edges), limits.maxBonds),
+			http.StatusUnprocessableEntity)
+		return
+	}
+
+	index := 0
+	var highlight assembly.Highlight
+	if len(g.Edges) > 0 {
+		deadline := time.After(limits.timeout)
+		select {
+		case limits.slots <- struct{}{}:
+		case <-deadline:
+			http.Error(w, fmt.Sprintf("the server was busy for %v, try again later", limits.timeout),
+				http.StatusServiceUnavailable)
+			return
+		case <-r.Context().Done():
+			return
+		}
+		done := make(chan []assembly.Pathway, 1)
+		go func() {
+			defer func() { <-limits.slots }()
+			done <- assembly.Assembly(g, numWorkers, bufferSize, "shortest")
+		}()
+
+		var pathways []assembly.Pathway
+		select {
+		case pathways = <-done:
+		case <-deadline:
+			http.Error(w, fmt.Sprintf("the assembly took longer than %v", limits.timeout), http.StatusServiceUnavailable)
+			return
+		case <-r.Context().Done():
+			return
+		}
+		index = assembly.AssemblyIndex(&pathways[0], &g)
+		if highlight, err = assembly.NewHighlight(&pathways[0], &g); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if !svg {
+		fmt.Fprintln(w, index)
+		return
+	}
+
+	depiction, err := assembly.MolDepictionFromString(molBlock, colouring.Hydrogens)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Assembly-Index", strconv.Itoa(index))
+	fmt.Fprint(w, assembly.SVGString(&g, &depiction, &highlight))
+}
+
 // main executable will output assembly index and pathway to stdout and log file if selected in command line arguments
+// Use "validate" as the first argument to only check the input files, e.g. ./assembly validate -molfile=false graph.txt
+// Use "verify" as the first argument to check a pathway, e.g. ./assembly verify my_mol.mol my_mol_pathway.txt
+// Use "serve" as the first argument to run an HTTP service, e.g. ./assembly serve -addr localhost:8080
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(validateCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(verifyCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		os.Exit(serveCommand(os.Args[2:]))
+	}
+
 	// command line arguments
 	inputFile := flag.String("file", "", "the name of the input file")
 	molFile := flag.Bool("molfile", true, "true if molfile, false if general graph file")
+	multigraph := flag.Bool("multigraph", false, "general graph file may contain parallel edges and self-loops")
+	directed := flag.Bool("directed", false, "general graph file edges are directed, from the first vertex to the second")
 	logFile := flag.String("logfile", "log.txt", "the path to the log file")
 	numWorkers := flag.Int("workers", 100, "the number of workers in the worker pool")
 	bufferSize := flag.Int("buffer", 100, "the buffer size of the jobs queue")
@@ -43,11 +538,37 @@ func main() {
 	verbose := flag.Bool("verbose", false, "stdout pathway information - if false, only assembly index output")
 	log := flag.Bool("log", false, "log to file")
 	pathway := flag.Bool("pathway", false, "the input file contains multiple graphs in the form of a starting pathway, e.g. an sdf file")
+	mode := flag.String("mode", "edge", "edge for edge (bond) based assembly, vertex for vertex (atom) based assembly")
+	stringInput := flag.Bool("strings", false, "the input file contains strings, one per line, e.g. DNA or peptide sequences")
+	fasta := flag.Bool("fasta", false, "the input file is a FASTA file of peptide or nucleic acid sequences, each of which is assembled")
+	sequenceType := flag.String("sequence", "", "the FASTA sequence type, protein, dna or rna - guessed from each sequence if not given")
+	coarse := flag.Bool("coarse", false, "FASTA sequences are coarse grained graphs of residues, rather than atom level graphs")
+	joint := flag.Bool("joint", false, "jointly assemble all the input files, giving the joint assembly index of the set")
+	space := flag.Bool("space", false, "output the assembly space of the pathway, i.e. every join that builds the graph, after checking it")
+	require := flag.String("require", "", "comma separated SMILES-like fragments, two copies of each of which the pathway must use, e.g. C(=O)O")
+	requireAtoms := flag.String("requireatoms", "", "mol file atom numbers of two copies of a fragment the pathway must use, e.g. 1,2,3:7,8,9, with ; between fragments")
+	keepBonds := flag.String("keepbonds", "", "comma separated bond numbers (counting from 1, in the order of the input file) that duplicates must not cut")
+	forbid := flag.String("forbid", "", "comma separated SMILES-like fragments that must not be used as duplicates, e.g. C(=O)O")
+	minFragment := flag.Int("minfragment", 0, "the minimum number of edges of a duplicated fragment, 0 for no limit")
+	maxFragment := flag.Int("maxfragment", 0, "the maximum number of edges of a duplicated fragment, 0 for no limit")
+	contract := flag.String("contract", "", "rule file of substructures to contract before assembly, outputs the coarse and expanded assembly indices")
+	colouring := flag.String("colouring", "element", "the colours of mol file graphs: element, or any of charge, isotope, radical, aromaticity, hybridisation, nobondorder, stereo and aromatise or kekulise, comma separated")
+	hydrogens := flag.String("hydrogens", "strip", "the hydrogen atoms of mol file graphs: strip them, keep those in the file, or add the implicit ones from valence rules")
+	inFormat := flag.String("informat", "", "the input file format: mol, graph (the general graph file format), graphml, gml or dot, which overrides -molfile")
+	vertexAttr := flag.String("vertexattr", assembly.DefaultGraphFormatOptions.VertexColour, "the attribute of graphml, gml and dot vertices their colours are read from")
+	edgeAttr := flag.String("edgeattr", assembly.DefaultGraphFormatOptions.EdgeColour, "the attribute of graphml, gml and dot edges their colours are read from")
+	dot := flag.String("dot", "", "write the graph to this Graphviz DOT file, with each duplicated fragment of the pathway highlighted")
+	graphML := flag.String("graphml", "", "write the graph to this GraphML file, with each duplicated fragment of the pathway highlighted")
+	svg := flag.String("svg", "", "write an SVG drawing of the graph to this file, with each duplicated fragment of the pathway highlighted")
+	rxn := flag.Bool("rxn", false, "the input files are RXN or RD files of reactions, and the assembly indices of the reactants and products of each are output")
+	rings := flag.Bool("rings", false, "also output ring statistics of the graph: cycle rank, SSSR ring sizes, relevant cycles and ring systems")
 
 	flag.Parse()
 	CLArgs := CommandLineOptions{
 		inputFile,
 		molFile,
+		multigraph,
+		directed,
 		logFile,
 		numWorkers,
 		bufferSize,
@@ -56,9 +577,45 @@ func main() {
 		verbose,
 		log,
 		pathway,
+		mode,
+		stringInput,
+		fasta,
+		sequenceType,
+		coarse,
+		joint,
+		space,
+		require,
+		requireAtoms,
+		keepBonds,
+		forbid,
+		minFragment,
+		maxFragment,
+		contract,
+		colouring,
+		hydrogens,
+		rings,
+		rxn,
+		inFormat,
+		vertexAttr,
+		edgeAttr,
+		dot,
+		graphML,
+		svg,
 		flag.Args(),
 	}
 
+	// the input format chooses whether the input is a mol file
+	switch *CLArgs.inFormat {
+	case "":
+	case "mol":
+		*CLArgs.molFile = true
+	case "graph", assembly.FormatGraphML, assembly.FormatGML, assembly.FormatDOT:
+		*CLArgs.molFile = false
+	default:
+		check(fmt.Errorf("unknown input format %v, must be mol, graph, %v, %v or %v", *CLArgs.inFormat,
+			assembly.FormatGraphML, assembly.FormatGML, assembly.FormatDOT))
+	}
+
 	var logf *os.File
 	var err error
 
@@ -77,37 +634,151 @@ func main() {
 		inFile = *CLArgs.inputFile
 	}
 
+	var edgeMode bool
+	switch *CLArgs.mode {
+	case "edge":
+		edgeMode = true
+	case "vertex":
+		edgeMode = false
+	default:
+		check(fmt.Errorf("unknown mode %v, must be edge or vertex", *CLArgs.mode))
+	}
+
+	// strings are assembled with their own search, outputting one assembly index per line
+	if *CLArgs.strings {
+		stringsCommand(inFile, *CLArgs.verbose)
+		return
+	}
+
+	// each sequence in a FASTA file is assembled separately, outputting one assembly index per sequence
+	if *CLArgs.fasta {
+		fastaCommand(inFile, CLArgs, edgeMode)
+		return
+	}
+
+	// each reaction in the input files is output on its own line
+	if *CLArgs.rxn {
+		inFiles := CLArgs.tail
+		if *CLArgs.inputFile != "" {
+			inFiles = append([]string{*CLArgs.inputFile}, inFiles...)
+		}
+		reactionsCommand(inFiles, CLArgs)
+		return
+	}
+
+	// all the input files are assembled together
+	if *CLArgs.joint {
+		inFiles := CLArgs.tail
+		if *CLArgs.inputFile != "" {
+			inFiles = append([]string{*CLArgs.inputFile}, inFiles...)
+		}
+		jointCommand(inFiles, CLArgs)
+		return
+	}
+
+	// substructures are contracted before assembly, and the pathway expanded afterwards
+	if *CLArgs.contract != "" {
+		if !edgeMode {
+			check(fmt.Errorf("coarse graining can only be used in edge mode"))
+		}
+		contractCommand(inFile, CLArgs)
+		return
+	}
+
 	// Generate slice of Graphs. This will just contain the graph of the initial structure, unless a starting pathway is provided, in which
 	// case it will contain the graphs in the pathway
 	var fileGraph []assembly.Graph
 	if *CLArgs.pathway{
-		fileGraph = assembly.ParseSDFile(inFile, true)
+		fileGraph = assembly.ParseSDFileColouring(inFile, true, molColouring(*CLArgs.colouring, *CLArgs.hydrogens))
 	} else {
-		if *CLArgs.molFile {
-			fileGraph = append(fileGraph, assembly.MolColourGraph(inFile))
-		} else {
-			fileGraph = append(fileGraph, assembly.NewGraphOnlyFromFile(inFile))
-		}
+		fileGraph = append(fileGraph, readGraph(inFile, CLArgs))
+	}
+
+	// fragments are coloured by element, so would not match graphs with more detail in their colours
+	if (*CLArgs.require != "" || *CLArgs.forbid != "") && molColouring(*CLArgs.colouring, "strip") != (assembly.Colouring{}) {
+		check(fmt.Errorf("-require and -forbid fragments can only be used with the element colouring"))
 	}
 
 	var pathways []assembly.Pathway
+	constraints := searchConstraints(inFile, &fileGraph[0], CLArgs)
+	if constraints != nil && (!edgeMode || *CLArgs.pathway || *CLArgs.require != "" || *CLArgs.requireAtoms != "") {
+		check(fmt.Errorf("search constraints can only be used in edge mode, without a starting pathway or required duplicates"))
+	}
 	start := time.Now()
 
 	// Generate the output pathways. At present, the only variant implemented will return a single shortest pathway
 	if *CLArgs.pathway{
-		originalGraph, starterPathway := assembly.MolListToPathway(fileGraph, []assembly.Duplicates{})
-		pathways = assembly.AssemblyPathway(originalGraph, starterPathway, *CLArgs.numWorkers, *CLArgs.bufferSize,*CLArgs.variant)
+		if !edgeMode {
+			check(fmt.Errorf("starting pathways can only be used in edge mode"))
+		}
+		originalGraph, starterPathways, err := assembly.MolListStartingPathways(fileGraph)
+		check(err)
+		pathways = assembly.AssemblyStartingPathways(originalGraph, starterPathways, *CLArgs.numWorkers, *CLArgs.bufferSize,*CLArgs.variant)
+	} else if *CLArgs.require != "" || *CLArgs.requireAtoms != "" {
+		if !edgeMode {
+			check(fmt.Errorf("required duplicates can only be used in edge mode"))
+		}
+		pathways, err = assembly.AssemblyRequired(fileGraph[0], requiredDuplicates(inFile, CLArgs), *CLArgs.numWorkers, *CLArgs.bufferSize, *CLArgs.variant)
+		check(err)
+	} else if constraints != nil {
+		pathways = assembly.AssemblyConstrained(fileGraph[0], constraints, *CLArgs.numWorkers, *CLArgs.bufferSize, *CLArgs.variant)
 	} else {
-		pathways = assembly.Assembly(fileGraph[0], *CLArgs.numWorkers, *CLArgs.bufferSize, *CLArgs.variant)
+		pathways = assembly.AssemblyMode(fileGraph[0], *CLArgs.numWorkers, *CLArgs.bufferSize, *CLArgs.variant, edgeMode)
 	}
 
 
 	elapsed := time.Now().Sub(start)
 
 	// calculate the assembly index from the pathways, and a string containing pathway details
-	assemblyIndex := assembly.AssemblyIndex(&pathways[0], &fileGraph[0])
+	assemblyIndex := assembly.AssemblyIndexMode(&pathways[0], &fileGraph[0], edgeMode)
 	assemblyString := assembly.AssemblyString(pathways, &fileGraph[0])
 
+	// give where each duplicated fragment is in the mol file, as the pathway uses relabelled vertices
+	if *CLArgs.molFile && !*CLArgs.pathway {
+		numbering := molNumbering(inFile, CLArgs)
+		occurrences, remnant, err := assembly.PathwayOccurrences(&pathways[0], &fileGraph[0], &numbering)
+		check(err)
+		assemblyString += assembly.OccurrencesString(occurrences, remnant)
+	}
+
+	// the assembly space replays the pathway as joins, so also checks that the assembly index can be achieved
+	if *CLArgs.space {
+		if !edgeMode {
+			check(fmt.Errorf("the assembly space can only be found for edge mode"))
+		}
+		space, err := assembly.PathwayAssemblySpace(&pathways[0], &fileGraph[0])
+		check(err)
+		check(assembly.ValidateAssemblySpace(&space, &pathways[0], &fileGraph[0]))
+		assemblyString += assembly.AssemblySpaceString(&space)
+	}
+
+	// the graph is exported or drawn with the duplicated fragments highlighted, to show the pathway
+	if *CLArgs.dot != "" || *CLArgs.graphML != "" || *CLArgs.svg != "" {
+		if *CLArgs.pathway {
+			check(fmt.Errorf("-dot, -graphml and -svg can't be used with a starting pathway"))
+		}
+		highlight, err := assembly.NewHighlight(&pathways[0], &fileGraph[0])
+		check(err)
+		options := assembly.GraphFormatOptions{VertexColour: *CLArgs.vertexAttr, EdgeColour: *CLArgs.edgeAttr}
+		if *CLArgs.dot != "" {
+			check(ioutil.WriteFile(*CLArgs.dot, []byte(assembly.DOTString(&fileGraph[0], inFile, &highlight, options)), 0644))
+		}
+		if *CLArgs.graphML != "" {
+			check(ioutil.WriteFile(*CLArgs.graphML, []byte(assembly.GraphMLString(&fileGraph[0], inFile, &highlight, options)), 0644))
+		}
+		if *CLArgs.svg != "" {
+			depiction := depict(inFile, &fileGraph[0], CLArgs)
+			check(ioutil.WriteFile(*CLArgs.svg, []byte(assembly.SVGString(&fileGraph[0], &depiction, &highlight)), 0644))
+		}
+	}
+
+	// ring statistics are output alongside the assembly index, and don't depend on the pathway
+	var ringString string
+	if *CLArgs.rings {
+		ringString = assembly.RingStatisticsString(assembly.RingStats(&fileGraph[0]))
+		assemblyString += ringString
+	}
+
 	// output assembly index and details to stdout
 	if *CLArgs.verbose {
 		fmt.Println("Running on file: ", inFile)
@@ -117,6 +788,7 @@ func main() {
 		fmt.Println("Time: ", elapsed.Seconds())
 	} else {
 		fmt.Println(assemblyIndex)
+		fmt.Print(ringString)
 	}
 
 	// output assembly index and details to log file (if specified in command line arguments)
@@ -0,0 +1,292 @@
+package assembly
+
+import (
+	"errors"
+	"sort"
+)
+
+// Code for normalising the bonds of molecules, so the same molecule gets the same bond colours whatever tool wrote the
+// mol file: some write aromatic rings with aromatic bonds (mol file bond type 4) and some with alternating single and
+// double bonds (a Kekulé structure). Aromatic bonds are first kekulised, then rings are found with SSSR and checked for
+// aromaticity with a Hückel model, and then either marked aromatic (AromatiseBonds) or given a new Kekulé structure
+// (KekuliseBonds)
+
+// AromatiseBonds returns the bond types (mol file numbers) of a molecule with the bonds of aromatic rings as aromatic
+// (4), and all other bonds single, double or triple. A ring, or two rings sharing a bond, is aromatic if every atom in
+// it gives electrons to its pi system (see piElectrons) and there are 4n+2 of them. An error is returned if the
+// aromatic bonds in the input can't be kekulised. bonds are pairs of positions in atoms, which may or may not include
+// hydrogen atoms
+func AromatiseBonds(atoms []MolAtom, bonds [][2]int, bondTypes []int) ([]int, error) {
+	kekule, err := kekuliseAromatic(atoms, bonds, bondTypes)
+	if err != nil {
+		return nil, err
+	}
+	aromatic := aromaticBonds(atoms, bonds, kekule)
+	for i := range kekule {
+		if aromatic[i] {
+			kekule[i] = 4
+		}
+	}
+	return kekule, nil
+}
+
+// KekuliseBonds returns the bond types (mol file numbers) of a molecule with no aromatic bonds: the aromatic rings (see
+// AromatiseBonds) are given alternating single and double bonds. The double bonds are placed in the same way for
+// aromatic and Kekulé input, but depend on the order of the atoms and bonds, so AromatiseBonds is the more consistent
+// normal form. An error is returned if the aromatic bonds can't be kekulised
+func KekuliseBonds(atoms []MolAtom, bonds [][2]int, bondTypes []int) ([]int, error) {
+	aromatic, err := AromatiseBonds(atoms, bonds, bondTypes)
+	if err != nil {
+		return nil, err
+	}
+	return kekuliseAromatic(atoms, bonds, aromatic)
+}
+
+// kekuliseAromatic returns the bond types with each aromatic bond (4) replaced by a single or double bond, such that
+// every atom with aromatic bonds that needs a double bond to fill its valence has exactly one. Pyrrole type nitrogens
+// (with no hydrogen given) and negatively charged atoms may have one or not. An error is returned if there is no way to
+// do this
+func kekuliseAromatic(atoms []MolAtom, bonds [][2]int, bondTypes []int) ([]int, error) {
+	kekule := append([]int{}, bondTypes...)
+
+	// the valence each atom has left for double bonds, counting each aromatic bond as single
+	free := make([]int, len(atoms))
+	aromaticAt := make([][]int, len(atoms)) // the aromatic bonds at each atom
+	for i, atom := range atoms {
+		free[i] = defaultValence(atom)
+	}
+	for i, b := range bonds {
+		order := bondTypes[i]
+		if order == 4 {
+			order = 1
+			aromaticAt[b[0]] = append(aromaticAt[b[0]], i)
+			aromaticAt[b[1]] = append(aromaticAt[b[1]], i)
+		}
+		free[b[0]] -= order
+		free[b[1]] -= order
+	}
+
+	// the atoms that must have a double bond, and those that may
+	need := make(map[int]bool)
+	may := make(map[int]bool)
+	var needed []int
+	for i, atom := range atoms {
+		if len(aromaticAt[i]) == 0 || free[i] < 1 {
+			continue
+		}
+		pyrroleType := free[i] == 1 && atom.Charge == 0 && len(aromaticAt[i]) == 2 &&
+			(atom.Element == "N" || atom.Element == "P" || atom.Element == "As")
+		if pyrroleType || atom.Charge < 0 {
+			may[i] = true
+		} else {
+			need[i] = true
+			needed = append(needed, i)
+		}
+	}
+
+	matched := make(map[int]int) // the double bond at each matched atom
+	other := func(bond int, v int) int {
+		if bonds[bond][0] == v {
+			return bonds[bond][1]
+		}
+		return bonds[bond][0]
+	}
+	options := func(v int) []int {
+		var bondOptions []int
+		for _, bond := range aromaticAt[v] {
+			u := other(bond, v)
+			if _, taken := matched[u]; !taken && (need[u] || may[u]) {
+				bondOptions = append(bondOptions, bond)
+			}
+		}
+		return bondOptions
+	}
+
+	// match the atoms that need a double bond, taking the one with the fewest options first and backtracking
+	var match func() bool
+	match = func() bool {
+		next, nextOptions := -1, []int(nil)
+		for _, v := range needed {
+			if _, taken := matched[v]; taken {
+				continue
+			}
+			if o := options(v); next == -1 || len(o) < len(nextOptions) {
+				next, nextOptions = v, o
+			}
+		}
+		if next == -1 {
+			return true
+		}
+		for _, bond := range nextOptions {
+			u := other(bond, next)
+			matched[next], matched[u] = bond, bond
+			if match() {
+				return true
+			}
+			delete(matched, next)
+			delete(matched, u)
+		}
+		return false
+	}
+	if !match() {
+		return nil, errors.New("aromatic bonds can't be kekulised: there is no way to give each atom that needs one a double bond")
+	}
+
+	for i := range kekule {
+		if kekule[i] == 4 {
+			kekule[i] = 1
+		}
+	}
+	for _, bond := range matched {
+		kekule[bond] = 2
+	}
+	return kekule, nil
+}
+
+// aromaticBonds returns which bonds of a molecule with no aromatic bonds are in aromatic rings. The rings checked are
+// the SSSR rings, and the pairs of them that share one bond, e.g. for azulene
+func aromaticBonds(atoms []MolAtom, bonds [][2]int, bondTypes []int) []bool {
+	indices := make([]int, len(atoms))
+	for i := range indices {
+		indices[i] = i
+	}
+	g := NewColourGraphFromIDs(indices, bonds, []int{}, []int{})
+	rings := SSSR(&g)
+	inRing := make(map[int]bool)
+	for _, ring := range rings {
+		for _, v := range ringVertices(&g, ring) {
+			inRing[v] = true
+		}
+	}
+
+	candidates := append([][]int{}, rings...)
+	for i := range rings {
+		for j := i + 1; j < len(rings); j++ {
+			if shared := sharedEdges(rings[i], rings[j]); shared == 1 {
+				candidates = append(candidates, append(append([]int{}, rings[i]...), rings[j]...))
+			}
+		}
+	}
+
+	aromatic := make([]bool, len(bonds))
+	for _, ring := range candidates {
+		vertices := make(map[int]bool)
+		for _, e := range ring {
+			vertices[bonds[e][0]] = true
+			vertices[bonds[e][1]] = true
+		}
+		electrons := 0
+		for v := range vertices {
+			n, ok := piElectrons(atoms, bonds, bondTypes, v, vertices, inRing)
+			if !ok {
+				electrons = -1
+				break
+			}
+			electrons += n
+		}
+		if electrons >= 2 && electrons%4 == 2 {
+			for _, e := range ring {
+				aromatic[e] = true
+			}
+		}
+	}
+	return aromatic
+}
+
+// sharedEdges returns the number of edges two rings have in common
+func sharedEdges(ring1 []int, ring2 []int) int {
+	sorted := append([]int{}, ring2...)
+	sort.Ints(sorted)
+	shared := 0
+	for _, e := range ring1 {
+		if i := sort.SearchInts(sorted, e); i < len(sorted) && sorted[i] == e {
+			shared++
+		}
+	}
+	return shared
+}
+
+// piElectrons returns the number of electrons atom v gives to the pi system of a ring with vertices ring: 1 for a
+// double bond in the ring or to another ring atom, 0 for a double bond out of the rings (e.g. C=O), 2 for a lone pair
+// (pyrrole type N, O and S, or a carbanion) and 0 for a carbocation or boron. It is not ok if the atom has a triple
+// bond, more than one double bond, or no p orbital (e.g. an sp3 carbon), so the ring can't be aromatic
+func piElectrons(atoms []MolAtom, bonds [][2]int, bondTypes []int, v int, ring map[int]bool, inRing map[int]bool) (int, bool) {
+	atom := atoms[v]
+	var doubles []int
+	valence := 0
+	for i, b := range bonds {
+		if b[0] != v && b[1] != v {
+			continue
+		}
+		valence += bondTypes[i]
+		switch bondTypes[i] {
+		case 2:
+			doubles = append(doubles, i)
+		case 3:
+			return 0, false
+		}
+	}
+
+	switch len(doubles) {
+	case 0:
+	case 1:
+		u := bonds[doubles[0]][0]
+		if u == v {
+			u = bonds[doubles[0]][1]
+		}
+		if ring[u] || inRing[u] {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+
+	switch atom.Element {
+	case "C":
+		switch atom.Charge {
+		case -1:
+			return 2, true
+		case 1:
+			return 0, true
+		}
+	case "N", "P", "As":
+		if atom.Charge == -1 || (atom.Charge == 0 && valence <= 3) {
+			return 2, true
+		}
+	case "O", "S", "Se", "Te":
+		if atom.Charge == 0 {
+			return 2, true
+		}
+	case "B":
+		if atom.Charge == 0 {
+			return 0, true
+		}
+	}
+	return 0, false
+}
+
+// defaultValence returns the usual number of bonds of an atom, including implicit hydrogens, or 0 if it isn't known.
+// A positive charge adds a bond to elements with lone pairs (e.g. N+ has 4) and takes one from carbon, and a negative
+// charge the other way round
+func defaultValence(atom MolAtom) int {
+	switch atom.Element {
+	case "C", "Si":
+		return 4 - abs(atom.Charge)
+	case "N", "P", "As":
+		return 3 + atom.Charge
+	case "O", "S", "Se", "Te":
+		return 2 + atom.Charge
+	case "B":
+		return 3 - atom.Charge
+	}
+	return 0
+}
+
+// abs returns the absolute value of an int
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
@@ -0,0 +1,95 @@
+package assembly
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAromatiseBonds(t *testing.T) {
+	ring6 := [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 0}}
+	ring5 := [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 0}}
+	quinone := append(append([][2]int{}, ring6...), [2]int{0, 6}, [2]int{3, 7})
+	azulene := [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 0}, {6, 7}, {7, 8}, {8, 9}, {9, 0}}
+	tests := []struct {
+		name      string
+		elements  string
+		bonds     [][2]int
+		bondTypes []int
+		expected  []int // nil for an error
+	}{
+		{"benzene kekule", "C C C C C C", ring6, []int{2, 1, 2, 1, 2, 1}, []int{4, 4, 4, 4, 4, 4}},
+		{"benzene aromatic", "C C C C C C", ring6, []int{4, 4, 4, 4, 4, 4}, []int{4, 4, 4, 4, 4, 4}},
+		{"pyridine aromatic", "N C C C C C", ring6, []int{4, 4, 4, 4, 4, 4}, []int{4, 4, 4, 4, 4, 4}},
+		{"pyrrole kekule", "N C C C C", ring5, []int{1, 2, 1, 2, 1}, []int{4, 4, 4, 4, 4}},
+		{"pyrrole aromatic", "N C C C C", ring5, []int{4, 4, 4, 4, 4}, []int{4, 4, 4, 4, 4}},
+		{"furan aromatic", "C C O C C", ring5, []int{4, 4, 4, 4, 4}, []int{4, 4, 4, 4, 4}},
+		{"cyclohexene", "C C C C C C", ring6, []int{2, 1, 1, 1, 1, 1}, []int{2, 1, 1, 1, 1, 1}},
+		{"cyclobutadiene", "C C C C", [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 0}}, []int{2, 1, 2, 1}, []int{2, 1, 2, 1}},
+		{"benzoquinone", "C C C C C C O O", quinone, []int{1, 2, 1, 1, 2, 1, 2, 2}, []int{1, 2, 1, 1, 2, 1, 2, 2}},
+		{"azulene", "C C C C C C C C C C", azulene, []int{2, 1, 2, 1, 2, 1, 1, 2, 1, 2, 1},
+			[]int{4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4}},
+		{"cyclopentadiene aromatic", "C C C C C", ring5, []int{4, 4, 4, 4, 4}, nil},
+	}
+
+	for _, tt := range tests {
+		atoms := molAtoms(strings.Fields(tt.elements))
+		bondTypes, err := AromatiseBonds(atoms, tt.bonds, tt.bondTypes)
+		if tt.expected == nil {
+			if err == nil {
+				t.Errorf("AromatiseBonds %v should give an error", tt.name)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(bondTypes, tt.expected) {
+			t.Errorf("AromatiseBonds %v expected %v, got %v %v", tt.name, tt.expected, bondTypes, err)
+		}
+	}
+}
+
+func TestKekuliseBonds(t *testing.T) {
+	ring6 := [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 0}}
+	atoms := molAtoms([]string{"C", "C", "C", "C", "C", "C"})
+	fromAromatic, err := KekuliseBonds(atoms, ring6, []int{4, 4, 4, 4, 4, 4})
+	check(err)
+	fromKekule, err := KekuliseBonds(atoms, ring6, []int{1, 2, 1, 2, 1, 2})
+	check(err)
+	if !reflect.DeepEqual(fromAromatic, fromKekule) {
+		t.Errorf("KekuliseBonds should give the same bonds for aromatic and Kekule benzene, got %v and %v", fromAromatic,
+			fromKekule)
+	}
+	doubles := 0
+	for _, bondType := range fromAromatic {
+		if bondType == 2 {
+			doubles++
+		}
+	}
+	if doubles != 3 {
+		t.Errorf("KekuliseBonds benzene should have 3 double bonds, got %v", fromAromatic)
+	}
+}
+
+func TestMolGraphNormalisedBonds(t *testing.T) {
+	for _, colouring := range []Colouring{{}, {Aromatise: true}, {Kekulise: true}} {
+		aromatic, err := MolGraphFromFileColouring("testdata/toluene_aromatic.mol", colouring)
+		check(err)
+		kekule, err := MolGraphFromFileColouring("testdata/toluene_kekule.mol", colouring)
+		check(err)
+		normalised := colouring.Aromatise || colouring.Kekulise
+		if GraphsIsomorphic(&aromatic, &kekule) != normalised {
+			t.Errorf("GraphsIsomorphic aromatic and Kekule toluene coloured by %v should be %v", colouring, normalised)
+		}
+	}
+
+	aspirin, err := MolGraphFromFileColouring("testdata/aspirin.mol", Colouring{Aromatise: true})
+	check(err)
+	aromaticBonds := 0
+	for _, colour := range aspirin.EdgeColourNames() {
+		if colour == "aromatic" {
+			aromaticBonds++
+		}
+	}
+	if aromaticBonds != 6 {
+		t.Errorf("aspirin aromatised should have 6 aromatic bonds, got %v", aspirin.EdgeColourNames())
+	}
+}
@@ -12,7 +12,9 @@ import (
 )
 
 // Pathway contains all information representing an assembly pathway. Pathway.pathway is a list of graphs that represent duplicated structures
-// within the original graph object, and Pathway.duplicates is the related edge indices of those duplicated graphs with respect to the original graph (TODO: check this)
+// within the original graph object, and Pathway.duplicates gives the edges of both occurrences of each of those graphs. These are in the
+// vertex labels of the remnant at the step the duplicate was found, and relabelled vertices are recorded in Pathway.atomEquivalents,
+// use PathwayOccurrences to map them back to the original graph (or mol file).
 // Pathway.remnant is the remaining structure once duplicates have been removed / separated out. Pathway does not contain the original graph, which is also
 // required for meaningful calculations, such as the assembly index.
 type Pathway struct {
@@ -57,7 +59,7 @@ func check(e error) {
 // PathwayStepsSaved checks the total steps saved on a pathway by looking at the size of the duplicates
 // each duplicate can save the number of edges/nodes in it (depending on edgeMode) minus 1. This is because all those edges/nodes
 // would otherwise need to be added individually. The -1 is because you would still need one step to join the duplicate structure.
-// Currently edgemode should always be true, unless and until vertex based assembly is implemented
+// edgeMode is true for the usual edge (bond) based assembly, and false for vertex based assembly (see AssemblyMode)
 func PathwayStepsSaved(pathway *Pathway, edgeMode bool) int {
 	stepsSaved := 0
 
@@ -212,9 +214,18 @@ func AssemblyString(pathways []Pathway, originalGraph *Graph) string {
 // AssemblyIndex returns the integer assembly index of the pathway, which is the max assembly index for originalGraph (number of edges - 1)
 // minus the total steps saved through all the duplicates
 func AssemblyIndex(pathway *Pathway, originalGraph *Graph) int {
+	return AssemblyIndexMode(pathway, originalGraph, true)
+}
+
+// AssemblyIndexMode returns the assembly index of the pathway in edge or vertex mode. In vertex mode the max assembly
+// index is the number of vertices - 1, as vertices are the building units
+func AssemblyIndexMode(pathway *Pathway, originalGraph *Graph, edgeMode bool) int {
 
 	index := len(originalGraph.Edges) - 1
-	index -= PathwayStepsSaved(pathway, true)
+	if !edgeMode {
+		index = len(originalGraph.Vertices) - 1
+	}
+	index -= PathwayStepsSaved(pathway, edgeMode)
 	return index
 
 }
@@ -247,7 +258,12 @@ func nonForbiddenNeighbour(sub []int, edgeAdjacencies map[int][]int, forbidden m
 // BestAssemblyIndex returns the best possible assembly index of a pathway, based on the maximum possible
 // steps saved on the remnant graph. This is used to bound the assembly process
 func BestAssemblyIndex(g *Graph, pathway *Pathway) int {
-	return AssemblyIndex(pathway, g) - MaxStepsSaved(pathway)
+	return BestAssemblyIndexMode(g, pathway, true)
+}
+
+// BestAssemblyIndexMode returns the best possible assembly index of a pathway in edge or vertex mode
+func BestAssemblyIndexMode(g *Graph, pathway *Pathway, edgeMode bool) int {
+	return AssemblyIndexMode(pathway, g, edgeMode) - MaxStepsSavedMode(pathway, edgeMode)
 }
 
 // MaxStepsSaved returns the maximum possible additional steps that could be saved within the remnant portion of a
@@ -255,13 +271,24 @@ func BestAssemblyIndex(g *Graph, pathway *Pathway) int {
 // shortest possible way, which is bounded by the log base 2 of the number of edges (i.e. repeatedly duplicating an structure,
 // from 1 edge, to 2, to 4, to 8 etc.
 func MaxStepsSaved(pathway *Pathway) int {
-	connectedComponentEdges := ConnectedComponentEdges(&pathway.remnant)
+	return MaxStepsSavedMode(pathway, true)
+}
+
+// MaxStepsSavedMode returns the maximum possible additional steps saved in edge or vertex mode. In vertex mode the
+// connected components are counted by their vertices rather than edges
+func MaxStepsSavedMode(pathway *Pathway, edgeMode bool) int {
+	var components [][]int
+	if edgeMode {
+		components = ConnectedComponentEdges(&pathway.remnant)
+	} else {
+		components = ConnectedComponentVertices(&pathway.remnant)
+	}
 	maxStepsSaved := 0
 
-	for _, c := range connectedComponentEdges {
-		numEdges := len(c)
-		naiveMA := numEdges - 1
-		bestMA := int(math.Floor(math.Log2(float64(numEdges))))
+	for _, c := range components {
+		numUnits := len(c)
+		naiveMA := numUnits - 1
+		bestMA := int(math.Floor(math.Log2(float64(numUnits))))
 		bestStepsSaved := naiveMA - bestMA
 		maxStepsSaved += bestStepsSaved
 	}
@@ -285,3 +312,24 @@ func UpdateAtomEquivalents(pathway *Pathway, vertexMap map[int]int){
 	}
 }
 
+// RecombineRemnant recombines the remnant and a duplicate broken off from it (see RecombineGraphs) to give the remnant of
+// the pathway, updating the atom equivalents. Relabelled vertices never reuse a label already in the atom equivalents or
+// the fragments broken off, even if it is no longer in the remnant, so each label always refers to the same vertex of
+// the original graph
+func RecombineRemnant(pathway *Pathway, remnant *Graph, duplicate *Graph) {
+	nextVertex := helpers.MaxIntSlice([]int{helpers.MaxIntSlice(remnant.Vertices), helpers.MaxIntSlice(duplicate.Vertices)}) + 1
+	for _, equivalents := range pathway.atomEquivalents {
+		if next := helpers.MaxIntSlice(equivalents) + 1; next > nextVertex {
+			nextVertex = next
+		}
+	}
+	for _, fragment := range pathway.pathway {
+		if next := helpers.MaxIntSlice(fragment.Vertices) + 1; next > nextVertex {
+			nextVertex = next
+		}
+	}
+
+	newGraph, vertexMap := recombineGraphsFrom(remnant, duplicate, nextVertex)
+	pathway.remnant = CopyGraph(&newGraph)
+	UpdateAtomEquivalents(pathway, vertexMap)
+}
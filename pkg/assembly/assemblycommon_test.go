@@ -384,3 +384,30 @@ func TestUpdateAtomEquivalents(t *testing.T) {
 		}
 	}
 }
+
+func TestRecombineRemnant(t *testing.T) {
+	// vertex 13 was a relabelled 7, but is no longer in the remnant, so 2 must not be relabelled to 13
+	pathway := NewPathway([]Graph{}, NewGraph([]int{}, [][2]int{}), []Duplicates{}, [][]int{{7, 13}})
+	remnant := NewGraph([]int{2, 3, 12}, [][2]int{{2, 3}, {3, 12}})
+	duplicate := NewGraph([]int{1, 2}, [][2]int{{1, 2}})
+
+	RecombineRemnant(&pathway, &remnant, &duplicate)
+	expectedEquivalents := [][]int{{7, 13}, {2, 14}}
+	if !reflect.DeepEqual(pathway.atomEquivalents, expectedEquivalents) {
+		t.Errorf("RecombineRemnant atom equivalents expected %v, got %v", expectedEquivalents, pathway.atomEquivalents)
+	}
+	expectedEdges := [][2]int{{2, 3}, {3, 12}, {1, 14}}
+	if !reflect.DeepEqual(pathway.remnant.Edges, expectedEdges) {
+		t.Errorf("RecombineRemnant remnant edges expected %v, got %v", expectedEdges, pathway.remnant.Edges)
+	}
+
+	// 5 was broken off in a fragment, so 3 must not be relabelled to 5
+	pathway = NewPathway([]Graph{NewGraph([]int{4, 5}, [][2]int{{4, 5}})}, NewGraph([]int{}, [][2]int{}), []Duplicates{}, [][]int{})
+	remnant = NewGraph([]int{0, 3}, [][2]int{{0, 3}})
+	duplicate = NewGraph([]int{3, 4}, [][2]int{{3, 4}})
+	RecombineRemnant(&pathway, &remnant, &duplicate)
+	expectedEquivalents = [][]int{{3, 6}}
+	if !reflect.DeepEqual(pathway.atomEquivalents, expectedEquivalents) {
+		t.Errorf("RecombineRemnant atom equivalents expected %v, got %v", expectedEquivalents, pathway.atomEquivalents)
+	}
+}
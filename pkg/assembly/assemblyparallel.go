@@ -15,7 +15,10 @@ import (
 // This file contains functions specific to the main parallel implementation of the assembly algorithm, the main one being Assembly
 
 // Worker takes pathways from the jobs queue and extends them, placing the results back in the jobs queue
-func Worker(jobs chan Pathway, graph *Graph, bestPathways *[]Pathway, activeWorkers *WorkerCounter, variant string, done chan bool) {
+// edgeMode selects edge based (ExtendPathway) or vertex based (ExtendPathwayVertex) assembly, and constraints are only
+// used in edge mode
+func Worker(jobs chan Pathway, graph *Graph, bestPathways *[]Pathway, activeWorkers *WorkerCounter, variant string, done chan bool, edgeMode bool,
+	constraints *Constraints) {
 
 	// Initially wait for a job (a Pathway)
 	currentPathway := <-jobs
@@ -23,7 +26,11 @@ func Worker(jobs chan Pathway, graph *Graph, bestPathways *[]Pathway, activeWork
 	for {
 
 		// Extend the pathway, putting any results back in the jobs queue for other workers to pick up
-		ExtendPathway(&currentPathway, bestPathways, graph, variant, jobs, activeWorkers)
+		if edgeMode {
+			ExtendPathway(&currentPathway, bestPathways, graph, variant, jobs, activeWorkers, constraints)
+		} else {
+			ExtendPathwayVertex(&currentPathway, bestPathways, graph, variant, jobs, activeWorkers)
+		}
 
 		// TODO: rename, since activeWorkers is now really active jobs
 		if activeWorkers.NumWorkers() == 0{
@@ -66,20 +73,16 @@ func (workerCounter *WorkerCounter) NumWorkers() int64 {
 	return numWorkers
 }
 
-// AssemblyFromMultiMolString take a set of graphs and use as starting pathway. TODO: include duplicates also
-// the original graph is the first one, then a pathway with the final residue at the end
+// AssemblyFromMultiMolString take a set of graphs and use as starting pathway.
+// the original graph is the first one, then a pathway with the final residue at the end. The occurrences of the
+// duplicated graphs are found in the original graph (see MolListStartingPathways), and invalid pathways are fatal
 func AssemblyFromMultiMolString(mols string, numWorkers int, chanBufferSize int, variant string) []Pathway {
 	graphs := ParseMultiMolString(mols, true)
 
-	originalGraph := graphs[0]
-	startingPathway := Pathway{
-		graphs[1:len(graphs)-1],
-		graphs[len(graphs)-1],
-		[]Duplicates{},
-		[][]int{},
-	}
+	originalGraph, startingPathways, err := MolListStartingPathways(graphs)
+	check(err)
 
-	outputPathway := AssemblyPathway(originalGraph, startingPathway, numWorkers, chanBufferSize, variant)
+	outputPathway := AssemblyStartingPathways(originalGraph, startingPathways, numWorkers, chanBufferSize, variant)
 	return outputPathway
 }
 
@@ -105,14 +108,16 @@ func AssemblyToString(molBlock string, numWorkers int, chanBufferSize int, varia
 // AssemblySDFBlock is similar to AssemblyToString, but takes an sdf block as input rather than a
 // single mol block. The SDF block is interpreted as the first mol being the original molecule,
 // the last being the remnant, and the intermediates being the duplicates. The main assembly algorithm
-// works on the remnant, and then extends the duplicates. There's no check that the input pathway is sensible,
-// e.g. there is no check that the remnant or duplicates exist within the target molecule.
+// works on the remnant, and then extends the duplicates. The occurrences of the duplicates are found in the target
+// molecule, and it is fatal if they can't be found or the remnant is not what is left (see MolListStartingPathways).
+// The output pathway can be checked with VerifyPathway.
 func AssemblySDFBlock(sdfBlock string, numWorkers int, chanBufferSize int, variant string) string {
 	graphs := ParseMultiMolString(sdfBlock, true)
-	originalGraph, starterPathway := MolListToPathway(graphs, []Duplicates{})
+	originalGraph, starterPathways, err := MolListStartingPathways(graphs)
+	check(err)
 
 	start := time.Now()
-	pathways := AssemblyPathway(originalGraph, starterPathway, numWorkers, chanBufferSize, variant)
+	pathways := AssemblyStartingPathways(originalGraph, starterPathways, numWorkers, chanBufferSize, variant)
 	elapsed := time.Now().Sub(start)
 
 	assemblyIndex := AssemblyIndex(&pathways[0], &originalGraph)
@@ -134,14 +139,44 @@ func AssemblySDFBlock(sdfBlock string, numWorkers int, chanBufferSize int, varia
 // is buffered. If full, the goroutine will process the job in a depth first manner, until there is space in the queue.
 // numWorkers is the number of worker threads, and chanBufferSize is the buffer size of the queue.
 func Assembly(graph Graph, numWorkers int, chanBufferSize int, variant string) []Pathway{
+	return AssemblyMode(graph, numWorkers, chanBufferSize, variant, true)
+}
+
+// AssemblyMode is the same as Assembly, but edgeMode can be set to false for vertex based assembly. In vertex mode the
+// building units are vertices, fragments are connected sets of vertices with all the edges between them, and the assembly
+// index should be calculated with AssemblyIndexMode
+func AssemblyMode(graph Graph, numWorkers int, chanBufferSize int, variant string, edgeMode bool) []Pathway{
 
 	initPathway := NewStartingPathway(graph)
-	return AssemblyPathway(graph, initPathway, numWorkers, chanBufferSize, variant)
+	return AssemblyPathwayMode(graph, initPathway, numWorkers, chanBufferSize, variant, edgeMode)
 }
 
 // AssemblyPathway is called by Assembly to generate pathways based on an initial graph. This can also be used as an entry
-// point if starting with a pathway, e.g. to specify a duplicate that must be used.
+// point if starting with a pathway, e.g. to specify a duplicate that must be used. The graph should be valid, graphs
+// built rather than read from a file can be checked with ValidateGraph before starting what may be a very long search
 func AssemblyPathway(graph Graph, initPathway Pathway, numWorkers int, chanBufferSize int, variant string) []Pathway {
+	return AssemblyPathwayMode(graph, initPathway, numWorkers, chanBufferSize, variant, true)
+}
+
+// AssemblyPathwayMode is the same as AssemblyPathway, with edge or vertex based assembly selected by edgeMode
+func AssemblyPathwayMode(graph Graph, initPathway Pathway, numWorkers int, chanBufferSize int, variant string, edgeMode bool) []Pathway {
+	return assemblyPathwayConstrained(graph, initPathway, numWorkers, chanBufferSize, variant, edgeMode, nil)
+}
+
+// AssemblyConstrained is the same as Assembly, but only uses duplicates allowed by the constraints (see NewConstraints)
+func AssemblyConstrained(graph Graph, constraints *Constraints, numWorkers int, chanBufferSize int, variant string) []Pathway {
+	return AssemblyPathwayConstrained(graph, NewStartingPathway(graph), constraints, numWorkers, chanBufferSize, variant)
+}
+
+// AssemblyPathwayConstrained is the same as AssemblyPathway, but only adds duplicates allowed by the constraints. The
+// duplicates already in initPathway are not checked
+func AssemblyPathwayConstrained(graph Graph, initPathway Pathway, constraints *Constraints, numWorkers int, chanBufferSize int, variant string) []Pathway {
+	return assemblyPathwayConstrained(graph, initPathway, numWorkers, chanBufferSize, variant, true, constraints)
+}
+
+// assemblyPathwayConstrained runs the search for AssemblyPathwayMode and AssemblyPathwayConstrained
+func assemblyPathwayConstrained(graph Graph, initPathway Pathway, numWorkers int, chanBufferSize int, variant string, edgeMode bool,
+	constraints *Constraints) []Pathway {
 
 	// will return shortest pathway, or all shortest pathways depending on the variant
 	// could be extended to all pathways
@@ -172,7 +207,7 @@ func AssemblyPathway(graph Graph, initPathway Pathway, numWorkers int, chanBuffe
 	// var workerMu sync.Mutex
 
 	for i := 0; i < numWorkers; i++ {
-		go Worker(jobs, &graph, &bestPathways, &activeWorkers, variant, done)
+		go Worker(jobs, &graph, &bestPathways, &activeWorkers, variant, done, edgeMode, constraints)
 	}
 
 
@@ -187,8 +222,9 @@ func AssemblyPathway(graph Graph, initPathway Pathway, numWorkers int, chanBuffe
 // In a depth-first fashion. The matching process cycles through subgraphs within the remnant graph based on the path trace algorithm
 // described in "Automatic Enumeration of All Connected Subgraphs, Rucker &  Rucker, 2000". For each of those subgraphs, CheckSubgraphMatches
 // is called which uses a similar subgraph search on the remaining part of the remnant, checking for matches. There is some
-// pruning within the process also.
-func ExtendPathway(currentPathway *Pathway, bestPathways *[]Pathway, originalGraph *Graph, variant string, jobs chan Pathway, activeWorkers *WorkerCounter) {
+// pruning within the process also. Subgraphs are not grown past the maximum fragment size of the constraints, which may be nil
+func ExtendPathway(currentPathway *Pathway, bestPathways *[]Pathway, originalGraph *Graph, variant string, jobs chan Pathway, activeWorkers *WorkerCounter,
+	constraints *Constraints) {
 
 	// If this pathway cannot in principle be extended to a better pathway than the best found so far, then return
 	// TODO: If implementing output of all pathways, this will need to be disabled
@@ -221,7 +257,7 @@ func ExtendPathway(currentPathway *Pathway, bestPathways *[]Pathway, originalGra
 			neighbour, found := nonForbiddenNeighbour(sub, edgeAdjacencies, forbidden)
 
 			// grow the subgraph if a valid neighbour is found
-			if found && (len(sub) <= sizesToCheck) {
+			if found && (len(sub) <= sizesToCheck) && constraints.canGrow(len(sub)) {
 				sub = append(sub, neighbour)
 
 				// break out this subgraph from the main graph
@@ -232,7 +268,7 @@ func ExtendPathway(currentPathway *Pathway, bestPathways *[]Pathway, originalGra
 				// CheckSubgraphMatches returns true if any matches are found (there might be multiple matches)
 				match := true
 				if len(sub) > 1 {
-					match = CheckSubgraphMatches(currentPathway, bestPathways, originalGraph, &subgraph, &remnant, variant, jobs, activeWorkers)
+					match = CheckSubgraphMatches(currentPathway, bestPathways, originalGraph, &subgraph, &remnant, variant, jobs, activeWorkers, constraints)
 				}
 
 				// if we have found matches of the current subgraph, or if the subgraph is of size 1, then we continue and keep trying to grow the
@@ -264,8 +300,9 @@ func ExtendPathway(currentPathway *Pathway, bestPathways *[]Pathway, originalGra
 
 // CheckSubgraphMatches takes the takes a remnant graph from a pathway, and a subgraph of that graph, and looks for matches within the remaining part of the remnant.
 // It does this by searching through subgraphs of the remnant in a similar way to how the input subgraph was found in ExtendPathway
+// Matches that the constraints don't allow still count as matches, so larger subgraphs are still tried, but no pathway is made
 func CheckSubgraphMatches(currentPathway *Pathway, bestPathways *[]Pathway, originalGraph *Graph, subgraph *Graph, remnant *Graph,
-	variant string, jobs chan Pathway, activeWorkers *WorkerCounter) bool {
+	variant string, jobs chan Pathway, activeWorkers *WorkerCounter, constraints *Constraints) bool {
 
 	// We are only looking for subgraphs of size k to match
 	k := len(subgraph.Edges)
@@ -276,7 +313,7 @@ func CheckSubgraphMatches(currentPathway *Pathway, bestPathways *[]Pathway, orig
 	forbiddenSize := make(map[int]int)           // map of the size of the list a vertex was forbidden from
 	var sub []int                                // the current subgraph under construction
 	match := false
-
+	allowed := constraints.allowsFragment(currentPathway, subgraph)
 
 	for i := 0; i < len(remnant.Edges); i++ {
 
@@ -302,7 +339,9 @@ func CheckSubgraphMatches(currentPathway *Pathway, bestPathways *[]Pathway, orig
 					// all matchine pairs of subgraphs would be investigated twice
 					if SubgraphEdgeCompare(subgraph.Edges, possibleDuplicate.Edges) && GraphsIsomorphic(subgraph, &possibleDuplicate) {
 						match = true
-
+						if !allowed || !constraints.keepsBonds(currentPathway, &possibleDuplicate) {
+							continue
+						}
 
 						newPathway := CopyPathway(currentPathway)
 						newPathway.pathway = append(newPathway.pathway, CopyGraph(subgraph))
@@ -317,9 +356,7 @@ func CheckSubgraphMatches(currentPathway *Pathway, bestPathways *[]Pathway, orig
 						// but no longer connected. TODO: refactor variable names - I have inadvertenly made them quite confusing
 						// As an example, if the graph was A-B-C-D (with A, B, C, D being subgraphs), then we found A was the same as B
 						// The new pathway would have duplicate A and Remnant B C-D (i.e. with B not connected to C-D)
-						newGraph, vertexMap := RecombineGraphs(&newRemnant, &possibleDuplicate)
-						newPathway.remnant = CopyGraph(&newGraph)
-						UpdateAtomEquivalents(&newPathway, vertexMap)
+						RecombineRemnant(&newPathway, &newRemnant, &possibleDuplicate)
 
 						// The newPathway will be added to the jobs queue, if there is space in the queue
 						// If there is not, then this goroutine will also extend the pathway, i.e. proceed in a depth first way
@@ -331,7 +368,7 @@ func CheckSubgraphMatches(currentPathway *Pathway, bestPathways *[]Pathway, orig
 							activeWorkers.Increment()
 						default:
 							activeWorkers.Increment()
-							ExtendPathway(&newPathway, bestPathways, originalGraph, variant, jobs, activeWorkers)
+							ExtendPathway(&newPathway, bestPathways, originalGraph, variant, jobs, activeWorkers, constraints)
 
 						}
 
@@ -364,10 +401,15 @@ func CheckSubgraphMatches(currentPathway *Pathway, bestPathways *[]Pathway, orig
 // and appends to bestPathway is newPathway is equal in length to bestPathway and we are using
 // all_shortest variant (note: all_shortest not yet fully implemented/tested)
 func BestPathwayListUpdate(bestPathways *[]Pathway, newPathway *Pathway, variant string) {
+	bestPathwayListUpdateMode(bestPathways, newPathway, variant, true)
+}
+
+// bestPathwayListUpdateMode is BestPathwayListUpdate with steps saved counted in edge or vertex mode
+func bestPathwayListUpdateMode(bestPathways *[]Pathway, newPathway *Pathway, variant string, edgeMode bool) {
 	var mutex = &sync.Mutex{}
 	mutex.Lock()
-	bestStepsSaved := PathwayStepsSaved(&(*bestPathways)[0], true)
-	newStepsSaved := PathwayStepsSaved(newPathway, true)
+	bestStepsSaved := PathwayStepsSaved(&(*bestPathways)[0], edgeMode)
+	newStepsSaved := PathwayStepsSaved(newPathway, edgeMode)
 
 	// if more steps are saved, replace the contents of bestStepsSaved
 	// if all the shortest paths are requred and the same number of steps is saved, then append the new pathway to the best pathway list
@@ -393,12 +435,14 @@ func ValidateVariants(variant string) {
 
 
 // SubgraphEdgeCompare compares two subgraphs to see if the sorted edge list of one is less than the sorted edge list of the other
+// Equal edge lists return true. Two edge-disjoint subgraphs can only have equal edge lists if they are made of parallel edges in
+// a multigraph, and these must still be checked as possible duplicates
 func SubgraphEdgeCompare(left [][2]int, right [][2]int) bool {
 
 	sortedLeft := Flatten(EdgeSort(left))
 	sortedRight := Flatten(EdgeSort(right))
 
-	return helpers.SliceCompare(sortedLeft, sortedRight)
+	return !helpers.SliceCompare(sortedRight, sortedLeft)
 }
 
 // EdgeSort sorts edges by their vertices, and then sorts the whole edge list by the first element. It returns a slice of slice, rather than a
@@ -427,8 +471,12 @@ func EdgeSort(edges [][2]int) [][]int {
 			return len(newEdgeList[i]) == 0 // empty slice listed "first" (change to != 0 to put them last)
 		}
 
-		// both slices len() > 0, so can test this now:
-		return newEdgeList[i][0] < newEdgeList[j][0]
+		// both slices len() > 0, so can test this now. Ties on the first vertex are ordered by the second, so that
+		// the same set of edges always gives the same list
+		if newEdgeList[i][0] != newEdgeList[j][0] {
+			return newEdgeList[i][0] < newEdgeList[j][0]
+		}
+		return newEdgeList[i][1] < newEdgeList[j][1]
 	})
 
 
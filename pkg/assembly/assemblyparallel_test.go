@@ -29,6 +29,26 @@ func TestAssembly(t *testing.T) {
 			MolColourGraph("testdata/aspirin.mol"),
 			8,
 		},
+		{
+			// pairs of parallel edges 1=2 and 2=3 with the same colours, so either pair or either 1-2-3 path is a duplicate
+			NewMultigraph([]int{1, 2, 3}, [][2]int{{1, 2}, {1, 2}, {2, 3}, {2, 3}}, []string{"A", "A", "A"}, []string{"X", "Y", "X", "Y"}),
+			2,
+		},
+		{
+			// directed path 1->2->3->4->5 has the duplicate 1->2->3 / 3->4->5
+			NewDirectedGraph([]int{1, 2, 3, 4, 5}, [][2]int{{1, 2}, {2, 3}, {3, 4}, {4, 5}}, []string{}, []string{}),
+			2,
+		},
+		{
+			// 1->2->3->4<-5: 1->2->3 and 3->4<-5 are not the same, although they are as undirected graphs
+			NewDirectedGraph([]int{1, 2, 3, 4, 5}, [][2]int{{1, 2}, {2, 3}, {3, 4}, {5, 4}}, []string{}, []string{}),
+			3,
+		},
+		{
+			// parallel edge colours differ, so there are no duplicates
+			NewMultigraph([]int{1, 2, 3}, [][2]int{{1, 2}, {1, 2}, {2, 3}, {2, 3}}, []string{"A", "A", "A"}, []string{"X", "Y", "Z", "Z"}),
+			3,
+		},
 
 	}
 
@@ -0,0 +1,314 @@
+package assembly
+
+import (
+	"GoAssembly/pkg/helpers"
+	"fmt"
+	"sort"
+)
+
+// Code for turning a Pathway into an explicit assembly space: the sequence of joins that builds the original graph from
+// single edges. This works for edge based pathways (see Assembly).
+//
+// Every edge of the original graph ends up either in the final remnant or in exactly one duplicate that was removed
+// (the left of its Duplicates). Each removed duplicate is a copy of its right, which stayed in the remnant and was then
+// built from edges and later duplicates inside it. So the original graph is built by building each right from its parts,
+// latest first, reusing it for the matching left, and finally joining all the parts of the whole graph. Each join takes
+// one step, and the number of joins is the AssemblyIndex of the pathway
+
+// AssemblySpaceNode is an object in an assembly space. Graph is the object as a subgraph of the original graph. Parents are
+// the two nodes that were joined to make it, or -1 for basic building blocks (single edges). As a node can be used more
+// than once, Parts gives the original edge positions that came from each parent in this join
+type AssemblySpaceNode struct {
+	Graph   Graph
+	Parents [2]int
+	Parts   [2][]int
+}
+
+// AssemblySpace is a DAG of nodes, with parents always before the nodes they are joined into. The last node is the
+// original graph
+type AssemblySpace struct {
+	Nodes []AssemblySpaceNode
+}
+
+// Joins returns the number of joins in the assembly space, i.e. the nodes that are not basic building blocks
+func (space *AssemblySpace) Joins() int {
+	joins := 0
+	for _, n := range space.Nodes {
+		if n.Parents[0] != -1 {
+			joins++
+		}
+	}
+	return joins
+}
+
+// spaceBlock is a part of the original graph that has been built: the node that built it, and the original edge
+// positions where it is used
+type spaceBlock struct {
+	node  int
+	edges []int
+}
+
+// PathwayAssemblySpace reconstructs the assembly space of an edge based pathway on originalGraph. An error is returned if
+// the pathway is not consistent with the original graph, e.g. if it has duplicates that are not in the original graph
+func PathwayAssemblySpace(pathway *Pathway, originalGraph *Graph) (AssemblySpace, error) {
+	space := AssemblySpace{}
+	if len(pathway.duplicates) != len(pathway.pathway) {
+		return space, fmt.Errorf("pathway has %v graphs but %v duplicates", len(pathway.pathway), len(pathway.duplicates))
+	}
+
+	// map everything back to original edge positions. The lefts and the remnant split up the original edges, but the
+	// rights overlap them
+	edgeFinder := newOriginalEdgeFinder(originalGraph)
+	lefts := make([][]int, len(pathway.duplicates))
+	rights := make([][]int, len(pathway.duplicates))
+	for i, d := range pathway.duplicates {
+		var err error
+		if lefts[i], err = edgeFinder.find(pathway, d.left); err != nil {
+			return space, err
+		}
+		rightFinder := newOriginalEdgeFinder(originalGraph)
+		if rights[i], err = rightFinder.find(pathway, d.right); err != nil {
+			return space, err
+		}
+	}
+	remnantEdges, err := edgeFinder.find(pathway, pathway.remnant.Edges)
+	if err != nil {
+		return space, err
+	}
+	if unused := edgeFinder.unused(); len(unused) != 0 {
+		return space, fmt.Errorf("original edges %v are not in the remnant or any duplicate", unused)
+	}
+
+	// each part (a remnant edge or a left) belongs to the latest right that contains it, or the whole graph (-1)
+	container := func(edges []int, before int) int {
+		for j := before - 1; j >= 0; j-- {
+			if helpers.ContainsAll(rights[j], edges) {
+				return j
+			}
+		}
+		return -1
+	}
+	children := make(map[int][]spaceBlock)
+	builtRights := make([]spaceBlock, len(pathway.duplicates))
+
+	for _, e := range remnantEdges {
+		node := len(space.Nodes)
+		g, _ := BreakGraphOnEdges(originalGraph, []int{e})
+		space.Nodes = append(space.Nodes, AssemblySpaceNode{g, [2]int{-1, -1}, [2][]int{}})
+		owner := container([]int{e}, len(pathway.duplicates))
+		children[owner] = append(children[owner], spaceBlock{node, []int{e}})
+	}
+
+	// build the rights, latest first, so their parts have always been built
+	for i := len(pathway.duplicates) - 1; i >= 0; i-- {
+		for j := len(pathway.duplicates) - 1; j > i; j-- {
+			if container(rights[j], j) == i {
+				children[i] = append(children[i], builtRights[j])
+			}
+			if container(lefts[j], j) == i {
+				children[i] = append(children[i], spaceBlock{builtRights[j].node, lefts[j]})
+			}
+		}
+		block, err := joinBlocks(&space, originalGraph, children[i])
+		if err != nil {
+			return space, fmt.Errorf("duplicate %v: %v", i, err)
+		}
+		builtRights[i] = block
+	}
+
+	for j := len(pathway.duplicates) - 1; j >= 0; j-- {
+		if container(rights[j], j) == -1 {
+			children[-1] = append(children[-1], builtRights[j])
+		}
+		if container(lefts[j], j) == -1 {
+			children[-1] = append(children[-1], spaceBlock{builtRights[j].node, lefts[j]})
+		}
+	}
+	if _, err := joinBlocks(&space, originalGraph, children[-1]); err != nil {
+		return space, err
+	}
+	return space, nil
+}
+
+// joinBlocks joins blocks one at a time, adding a node for each join. Where possible each block joined shares a vertex
+// with those already joined, so the intermediates are connected. It returns the block for the whole
+func joinBlocks(space *AssemblySpace, originalGraph *Graph, blocks []spaceBlock) (spaceBlock, error) {
+	if len(blocks) == 0 {
+		return spaceBlock{}, fmt.Errorf("nothing to join")
+	}
+
+	current := blocks[0]
+	remaining := blocks[1:]
+	for len(remaining) > 0 {
+		next := 0
+		for n, block := range remaining {
+			if edgesShareVertex(originalGraph, current.edges, block.edges) {
+				next = n
+				break
+			}
+		}
+		block := remaining[next]
+		remaining = append(remaining[:next:next], remaining[next+1:]...)
+
+		joined := append(append([]int{}, current.edges...), block.edges...)
+		sort.Ints(joined)
+		g, _ := BreakGraphOnEdges(originalGraph, joined)
+		space.Nodes = append(space.Nodes, AssemblySpaceNode{g, [2]int{current.node, block.node}, [2][]int{current.edges, block.edges}})
+		current = spaceBlock{len(space.Nodes) - 1, joined}
+	}
+	return current, nil
+}
+
+// edgesShareVertex returns true if two sets of original edge positions have a vertex in common
+func edgesShareVertex(g *Graph, edges1 []int, edges2 []int) bool {
+	vertices := make(map[int]bool)
+	for _, e := range edges1 {
+		vertices[g.Edges[e][0]] = true
+		vertices[g.Edges[e][1]] = true
+	}
+	for _, e := range edges2 {
+		if vertices[g.Edges[e][0]] || vertices[g.Edges[e][1]] {
+			return true
+		}
+	}
+	return false
+}
+
+// originalEdgeFinder finds the positions in the original graph of edges from a pathway, using each original edge once
+type originalEdgeFinder struct {
+	g         *Graph
+	positions map[[2]int][]int
+	used      map[int]bool
+}
+
+func newOriginalEdgeFinder(g *Graph) originalEdgeFinder {
+	finder := originalEdgeFinder{g, make(map[[2]int][]int), make(map[int]bool)}
+	for i, e := range g.Edges {
+		key := finder.key(e)
+		finder.positions[key] = append(finder.positions[key], i)
+	}
+	return finder
+}
+
+func (finder *originalEdgeFinder) key(e [2]int) [2]int {
+	return edgeKey(finder.g, e)
+}
+
+// edgeKey returns an edge with its vertices in order, unless g is directed, so the same edge always has the same key
+func edgeKey(g *Graph, e [2]int) [2]int {
+	if !g.Directed && e[0] > e[1] {
+		return [2]int{e[1], e[0]}
+	}
+	return e
+}
+
+// copy returns a finder with the same original edges used, which can be used without changing this one
+func (finder *originalEdgeFinder) copy() originalEdgeFinder {
+	used := make(map[int]bool, len(finder.used))
+	for p := range finder.used {
+		used[p] = true
+	}
+	return originalEdgeFinder{finder.g, finder.positions, used}
+}
+
+// find returns the original positions of pathway edges, mapping relabelled vertices back to the original graph
+func (finder *originalEdgeFinder) find(pathway *Pathway, edges [][2]int) ([]int, error) {
+	return finder.findWith(edges, func(v int) int { return originalVertex(pathway, v) })
+}
+
+// findWith is find, with vertices mapped back to the original graph by originalOf
+func (finder *originalEdgeFinder) findWith(edges [][2]int, originalOf func(int) int) ([]int, error) {
+	var positions []int
+	for _, e := range edges {
+		original := finder.key([2]int{originalOf(e[0]), originalOf(e[1])})
+		found := false
+		for _, p := range finder.positions[original] {
+			if !finder.used[p] {
+				finder.used[p] = true
+				positions = append(positions, p)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("edge %v (original %v) is not in the original graph, or is used more than once", e, original)
+		}
+	}
+	return positions, nil
+}
+
+// unused returns the original edge positions that have not been found
+func (finder *originalEdgeFinder) unused() []int {
+	var unused []int
+	for i := range finder.g.Edges {
+		if !finder.used[i] {
+			unused = append(unused, i)
+		}
+	}
+	return unused
+}
+
+// ValidateAssemblySpace replays every join in an assembly space against the original graph. It checks that basic
+// building blocks are single edges of the original graph, that each join is of two earlier nodes whose parts are
+// disjoint and isomorphic to them, that each node is the union of its parts, that the last node is the original graph,
+// and that the number of joins is the AssemblyIndex of the pathway
+func ValidateAssemblySpace(space *AssemblySpace, pathway *Pathway, originalGraph *Graph) error {
+	if len(space.Nodes) == 0 {
+		return fmt.Errorf("assembly space has no nodes")
+	}
+
+	for i, n := range space.Nodes {
+		if n.Parents[0] == -1 {
+			if len(n.Graph.Edges) != 1 {
+				return fmt.Errorf("node %v is a basic building block with %v edges", i, len(n.Graph.Edges))
+			}
+			continue
+		}
+
+		var joined []int
+		for side, parent := range n.Parents {
+			if parent < 0 || parent >= i {
+				return fmt.Errorf("node %v has parent %v, which is not an earlier node", i, parent)
+			}
+			for _, e := range n.Parts[side] {
+				if e < 0 || e >= len(originalGraph.Edges) || helpers.Contains(joined, e) {
+					return fmt.Errorf("node %v has part edge %v, which is not in the original graph or is in both parts", i, e)
+				}
+				joined = append(joined, e)
+			}
+			part, _ := BreakGraphOnEdges(originalGraph, n.Parts[side])
+			if !GraphsIsomorphic(&part, &space.Nodes[parent].Graph) {
+				return fmt.Errorf("node %v part %v is not the same as parent %v", i, side, parent)
+			}
+		}
+
+		sort.Ints(joined)
+		union, _ := BreakGraphOnEdges(originalGraph, joined)
+		if !GraphsIsomorphic(&union, &n.Graph) {
+			return fmt.Errorf("node %v is not the join of its parts", i)
+		}
+	}
+
+	last := space.Nodes[len(space.Nodes)-1].Graph
+	if !GraphsIsomorphic(&last, originalGraph) {
+		return fmt.Errorf("the last node is not the original graph")
+	}
+	if joins, index := space.Joins(), AssemblyIndex(pathway, originalGraph); joins != index {
+		return fmt.Errorf("assembly space has %v joins, but the assembly index is %v", joins, index)
+	}
+	return nil
+}
+
+// AssemblySpaceString returns the nodes of an assembly space, one per line, with their parents and edges
+func AssemblySpaceString(space *AssemblySpace) string {
+	outString := "ASSEMBLY SPACE\n"
+	for i, n := range space.Nodes {
+		if n.Parents[0] == -1 {
+			outString += fmt.Sprintf("%v: edge %v %v\n", i, n.Graph.Edges, n.Graph.EdgeColourNames())
+		} else {
+			outString += fmt.Sprintf("%v: join %v + %v -> %v\n", i, n.Parents[0], n.Parents[1], n.Graph.Edges)
+		}
+	}
+	outString += fmt.Sprintf("Joins: %v\n", space.Joins())
+	return outString
+}
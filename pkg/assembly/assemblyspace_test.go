@@ -0,0 +1,62 @@
+package assembly
+
+import (
+	"testing"
+)
+
+func TestPathwayAssemblySpace(t *testing.T) {
+	tests := []Graph{
+		NewGraphOnlyFromFile("testdata/graphs/square.txt"),
+		NewGraphOnlyFromFile("testdata/graphs/triangle.txt"),
+		MolColourGraph("testdata/aspirin.mol"),
+		NewDirectedGraph([]int{1, 2, 3, 4, 5}, [][2]int{{1, 2}, {2, 3}, {3, 4}, {4, 5}}, []string{}, []string{}),
+		StringGraph("ATGATGATGATG"),
+	}
+
+	for _, g := range tests {
+		pathway := Assembly(g, 100, 100, "shortest")[0]
+		space, err := PathwayAssemblySpace(&pathway, &g)
+		if err != nil {
+			t.Errorf("PathwayAssemblySpace error on %v: %v\n%v", g, err, PathwayString(&pathway))
+			continue
+		}
+		if err := ValidateAssemblySpace(&space, &pathway, &g); err != nil {
+			t.Errorf("ValidateAssemblySpace error on %v: %v\n%v\n%v", g, err, PathwayString(&pathway), AssemblySpaceString(&space))
+		}
+	}
+}
+
+func TestStringAssemblySpace(t *testing.T) {
+	for _, s := range []string{"abab", "bananaban", "aaaaaaaa", "ATGCATGCTT"} {
+		g := StringGraph(s)
+		pathway := StringAssembly(s)[0]
+		space, err := PathwayAssemblySpace(&pathway, &g)
+		if err == nil {
+			err = ValidateAssemblySpace(&space, &pathway, &g)
+		}
+		if err != nil {
+			t.Errorf("StringAssembly assembly space error on %v: %v\n%v", s, err, PathwayString(&pathway))
+		}
+	}
+}
+
+func TestValidateAssemblySpace(t *testing.T) {
+	g := NewGraphOnlyFromFile("testdata/graphs/square.txt")
+	pathway := Assembly(g, 100, 100, "shortest")[0]
+	space, err := PathwayAssemblySpace(&pathway, &g)
+	check(err)
+
+	// a join that reuses a node for a part that it is not the same as
+	broken := AssemblySpace{append([]AssemblySpaceNode{}, space.Nodes...)}
+	last := len(broken.Nodes) - 1
+	broken.Nodes[last].Parents = [2]int{0, 0}
+	if ValidateAssemblySpace(&broken, &pathway, &g) == nil {
+		t.Errorf("ValidateAssemblySpace should give an error for a join of the wrong parents")
+	}
+
+	// fewer joins than the assembly index
+	short := AssemblySpace{space.Nodes[:len(space.Nodes)-1]}
+	if ValidateAssemblySpace(&short, &pathway, &g) == nil {
+		t.Errorf("ValidateAssemblySpace should give an error if the last node is not the original graph")
+	}
+}
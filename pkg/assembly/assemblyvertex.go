@@ -0,0 +1,134 @@
+package assembly
+
+import (
+	"GoAssembly/pkg/helpers"
+	"math"
+)
+
+// This file contains the vertex based version of the assembly algorithm (edgeMode = false). Here the building units are
+// vertices rather than edges, and fragments are connected sets of vertices along with all the edges between them (induced
+// subgraphs). Joining two fragments adds the edges between them. The search follows ExtendPathway and CheckSubgraphMatches,
+// but the path tracing is over vertex adjacencies rather than edge adjacencies
+
+// ExtendPathwayVertex is the vertex mode version of ExtendPathway. It grows connected vertex sets in the remnant, and for each
+// one looks for a disjoint connected vertex set in the rest of the remnant with an isomorphic induced subgraph
+func ExtendPathwayVertex(currentPathway *Pathway, bestPathways *[]Pathway, originalGraph *Graph, variant string, jobs chan Pathway, activeWorkers *WorkerCounter) {
+
+	// If this pathway cannot in principle be extended to a better pathway than the best found so far, then return
+	if AssemblyIndexMode(&(*bestPathways)[0], originalGraph, false) < BestAssemblyIndexMode(originalGraph, currentPathway, false) {
+		activeWorkers.Decrement()
+		return
+	}
+
+	// We only need to consider vertex sets up to half the size of the remnant
+	sizesToCheck := int(math.Floor(float64(len(currentPathway.remnant.Vertices)) / 2))
+
+	bestPathwayListUpdateMode(bestPathways, currentPathway, variant, false)
+
+	// Initialisation for the path tracing algorithm to find all connected vertex sets
+	vertexAdjacencies := currentPathway.remnant.VertexAdjacencies()
+	forbidden := make(map[int]bool)
+	forbiddenSize := make(map[int]int)
+	var sub []int
+
+	for _, v := range currentPathway.remnant.Vertices {
+		sub = []int{v}
+		for {
+
+			neighbour, found := nonForbiddenNeighbour(sub, vertexAdjacencies, forbidden)
+
+			if found && (len(sub) < sizesToCheck) {
+				sub = append(sub, neighbour)
+
+				subgraph, remnant := BreakGraphOnVertices(&currentPathway.remnant, sub)
+
+				// as in ExtendPathway, if there are no matches for this vertex set there will be none for larger ones containing it
+				if CheckVertexSubgraphMatches(currentPathway, bestPathways, originalGraph, &subgraph, &remnant, variant, jobs, activeWorkers) {
+					continue
+				}
+			}
+
+			// backtracking steps
+			thisForbidSize := len(sub)
+			thisForbid := sub[len(sub)-1]
+			sub = sub[:len(sub)-1]
+			forbidUpdate(thisForbid, thisForbidSize, forbidden, forbiddenSize)
+
+			if len(sub) == 0 {
+				break
+			}
+		}
+	}
+
+	activeWorkers.Decrement()
+}
+
+// CheckVertexSubgraphMatches is the vertex mode version of CheckSubgraphMatches. It looks for connected vertex sets in remnant
+// with the same number of vertices as subgraph, whose induced subgraphs are isomorphic to subgraph
+func CheckVertexSubgraphMatches(currentPathway *Pathway, bestPathways *[]Pathway, originalGraph *Graph, subgraph *Graph, remnant *Graph,
+	variant string, jobs chan Pathway, activeWorkers *WorkerCounter) bool {
+
+	k := len(subgraph.Vertices)
+	subVertices := helpers.SortedCopy(subgraph.Vertices)
+
+	vertexAdjacencies := remnant.VertexAdjacencies()
+	forbidden := make(map[int]bool)
+	forbiddenSize := make(map[int]int)
+	var sub []int
+	match := false
+
+	for _, v := range remnant.Vertices {
+
+		sub = []int{v}
+
+		for {
+
+			neighbour, found := nonForbiddenNeighbour(sub, vertexAdjacencies, forbidden)
+
+			if found && (len(sub) < k) {
+
+				sub = append(sub, neighbour)
+
+				if len(sub) == k {
+
+					possibleDuplicate, newRemnant := BreakGraphOnVertices(remnant, sub)
+
+					// only check each pair of vertex sets once, with the lower set of vertices as the subgraph
+					if helpers.SliceCompare(subVertices, helpers.SortedCopy(sub)) &&
+						len(possibleDuplicate.Edges) == len(subgraph.Edges) && GraphsIsomorphic(subgraph, &possibleDuplicate) {
+						match = true
+
+						newPathway := CopyPathway(currentPathway)
+						newPathway.pathway = append(newPathway.pathway, CopyGraph(subgraph))
+						newDuplicate := Duplicates{CopyEdgeList(subgraph.Edges), CopyEdgeList(possibleDuplicate.Edges)}
+						newPathway.duplicates = append(newPathway.duplicates, newDuplicate)
+
+						// the duplicate stays in the remnant, but is no longer joined to the rest of it
+						RecombineRemnant(&newPathway, &newRemnant, &possibleDuplicate)
+
+						select {
+						case jobs <- newPathway:
+							activeWorkers.Increment()
+						default:
+							activeWorkers.Increment()
+							ExtendPathwayVertex(&newPathway, bestPathways, originalGraph, variant, jobs, activeWorkers)
+						}
+					}
+				}
+				continue
+			}
+
+			// backtracking steps
+			thisForbidSize := len(sub)
+			thisForbid := sub[len(sub)-1]
+			sub = sub[:len(sub)-1]
+			forbidUpdate(thisForbid, thisForbidSize, forbidden, forbiddenSize)
+
+			if len(sub) == 0 {
+				break
+			}
+		}
+	}
+
+	return match
+}
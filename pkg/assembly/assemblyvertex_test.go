@@ -0,0 +1,53 @@
+package assembly
+
+import (
+	"testing"
+)
+
+func TestAssemblyVertexMode(t *testing.T) {
+
+	// This testing covers ExtendPathwayVertex and CheckVertexSubgraphMatches
+
+	tests := []struct {
+		graph         Graph
+		assemblyIndex int
+	}{
+		{
+			// 1-2 and 3-4 are the same, so join them: 3 - 1 = 2 steps
+			NewColourGraph([]int{1, 2, 3, 4}, [][2]int{{1, 2}, {2, 3}, {3, 4}}, []string{}, []string{}),
+			2,
+		},
+		{
+			// a path of 8 vertices can be built by doubling 1, 2, 4, 8
+			NewColourGraph([]int{1, 2, 3, 4, 5, 6, 7, 8}, [][2]int{{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 7}, {7, 8}}, []string{}, []string{}),
+			3,
+		},
+		{
+			// X-Y-X-Y has the duplicate X-Y
+			NewColourGraph([]int{1, 2, 3, 4}, [][2]int{{1, 2}, {2, 3}, {3, 4}}, []string{"X", "Y", "X", "Y"}, []string{}),
+			2,
+		},
+		{
+			// X-X-Y-Y has no duplicates
+			NewColourGraph([]int{1, 2, 3, 4}, [][2]int{{1, 2}, {2, 3}, {3, 4}}, []string{"X", "X", "Y", "Y"}, []string{}),
+			3,
+		},
+		{
+			// the square has the duplicate 1-2 / 3-4, and the remaining two edges are added in the final join
+			NewGraphOnlyFromFile("testdata/graphs/square.txt"),
+			2,
+		},
+	}
+
+	workers := 100
+	buf := 100
+	for _, tt := range tests {
+		testPathway := AssemblyMode(tt.graph, workers, buf, "shortest", false)[0]
+		pathwayString := PathwayString(&testPathway)
+		assemblyIndex := AssemblyIndexMode(&testPathway, &tt.graph, false)
+		if assemblyIndex != tt.assemblyIndex {
+			t.Errorf("Vertex mode assembly error in graph %v\nExpected %v got %v\n%v",
+				tt.graph, tt.assemblyIndex, assemblyIndex, pathwayString)
+		}
+	}
+}
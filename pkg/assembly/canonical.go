@@ -79,6 +79,32 @@ func DegreeInPart(graph *Graph, vertex int, part []int) int {
 
 }
 
+// OutInDegreeInPart returns the number of edges from a vertex into a part, and from the part into the vertex, for
+// directed graphs
+func OutInDegreeInPart(graph *Graph, vertex int, part []int) (int, int) {
+	outDegree := 0
+	inDegree := 0
+	for _, edge := range graph.Edges {
+		if vertex == edge[0] && helpers.Contains(part, edge[1]) {
+			outDegree++
+		}
+		if vertex == edge[1] && helpers.Contains(part, edge[0]) {
+			inDegree++
+		}
+	}
+	return outDegree, inDegree
+}
+
+// degreeKeyInPart returns the value used to split parts in Shatter. This is DegreeInPart for undirected graphs. For
+// directed graphs both the out and in degrees must match, so they are combined into a single int, ordered by out degree first
+func degreeKeyInPart(graph *Graph, vertex int, part []int) int {
+	if !graph.Directed {
+		return DegreeInPart(graph, vertex, part)
+	}
+	outDegree, inDegree := OutInDegreeInPart(graph, vertex, part)
+	return outDegree*(len(graph.Edges)+1) + inDegree
+}
+
 // Shatter returns the shattering of partLeft by partRight, splitting partLeft into parts ordered by degree into partRight
 // for example, take the square graph with edges (1,2), (2,3), (3, 4), (4, 1) with partLeft being (1, 2, 3) and partRight being (4).
 // then 2 has degree 0 with respect to (4) and both 1 and 3 have degree 1 with respect to 4, so the output is ((2), (1, 3))
@@ -88,7 +114,7 @@ func Shatter(graph *Graph, partLeft []int, partRight []int) [][]int {
 	degreeMap := make(map[int][]int)
 
 	for _, v := range partLeft {
-		degreeInPart := DegreeInPart(graph, v, partRight)
+		degreeInPart := degreeKeyInPart(graph, v, partRight)
 		helpers.MapUpdate(degreeInPart, v, degreeMap)
 	}
 
@@ -321,15 +347,15 @@ func PermuteGraph(graph *Graph, permutation []int) Graph {
 	// handle coloured graphs - edge colours should be the same
 	vertexColoured := len(graph.VertexColours) == len(graph.Vertices)
 	edgeColoured := len(graph.EdgeColours) == len(graph.Edges)
-	var newVertexColours []string
-	var newEdgeColours []string
-	var vertexColourMap map[int]string
+	var newVertexColours []int
+	var newEdgeColours []int
+	var vertexColourMap map[int]int
 
 	if vertexColoured {
-		newVertexColours = make([]string, len(graph.Vertices))
+		newVertexColours = make([]int, len(graph.Vertices))
 		vertexColourMap = VertexColourMap(graph)
 	} else {
-		newVertexColours = make([]string, 0)
+		newVertexColours = make([]int, 0)
 	}
 
 
@@ -363,7 +389,9 @@ func PermuteGraph(graph *Graph, permutation []int) Graph {
 	}
 
 
-	return NewColourGraph(newVertices, newEdges, newVertexColours, newEdgeColours)
+	permutedGraph := NewColourGraphFromIDs(newVertices, newEdges, newVertexColours, newEdgeColours)
+	permutedGraph.copyKind(graph)
+	return permutedGraph
 }
 
 // PermuteColouring returns a permuted colouring (partition). This was just used for testing, not part of the canonical algorithm
@@ -453,17 +481,18 @@ func SliceEqual(intSliceLeft []int, intSliceRight []int) bool {
 
 // GraphGreaterThan returns true if graphLeft is greater than graphRight, as determined by the lexographic ordering of the sorted edge list
 func GraphGreaterThan(graphLeft *Graph, graphRight *Graph) bool {
-	edgeListLeft := ListPairSort(graphLeft.Edges)
+	edgeListLeft := SortedEdges(graphLeft)
 	flatEdgeListLeft := FlattenEdgeList(edgeListLeft)
 
-	edgeListRight := ListPairSort(graphRight.Edges)
+	edgeListRight := SortedEdges(graphRight)
 	flatEdgeListRight := FlattenEdgeList(edgeListRight)
 
 	return SliceGreaterThan(flatEdgeListLeft, flatEdgeListRight)
 }
 
 // GraphColourPartition creates an initial partition for a graph based on colours. If there are no graph colours the initial partition just contains one
-// part with all of the vertices. If there are colours, then the initial partition is based on those, in order of colour.
+// part with all of the vertices. If there are colours, then the initial partition is based on those, in order of colour name.
+// Ordering by name rather than id keeps canonical forms reproducible between runs, whatever order colours were interned in.
 func GraphColourPartition(graph *Graph) [][]int {
 
 	var outputPartition [][]int
@@ -475,13 +504,13 @@ func GraphColourPartition(graph *Graph) [][]int {
 	}
 
 	// make list of all the graph colours
-	var colours []string
+	var colours []int
 	for _, c := range graph.VertexColours {
-		if !helpers.ContainsStr(colours, c) {
+		if !helpers.Contains(colours, c) {
 			colours = append(colours, c)
 		}
 	}
-	sort.Strings(colours)  // colours must be in order
+	SortColoursByName(colours)  // colours must be in order
 
 	// generate partition based on ordered colours
 	outputPartition = make([][]int, len(colours))
@@ -599,9 +628,19 @@ func RandomGraph(numVertices int, numExtraEdges int, colours []string) Graph {
 	}
 
 
+	// extra edges that duplicate an existing edge are skipped, so the graph may have fewer than numExtraEdges extra edges
 	for i := 0; i < numExtraEdges; i++ {
 		randomVertices := RandomPermutation(vertices)
-		edges = append(edges, [2]int{randomVertices[0], randomVertices[1]})
+		newEdge := ListPairSort([][2]int{{randomVertices[0], randomVertices[1]}})[0]
+		duplicate := false
+		for _, e := range ListPairSort(edges) {
+			if e == newEdge {
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			edges = append(edges, [2]int{randomVertices[0], randomVertices[1]})
+		}
 	}
 
 	if len(colours) != 0 {
@@ -668,7 +707,15 @@ func GraphsIsomorphic(graphLeft *Graph, graphRight *Graph) bool {
 	canonicalLeft := SearchTree(&checkGraphLeft, GraphColourPartition(&checkGraphLeft), true)
 	canonicalRight := SearchTree(&checkGraphRight, GraphColourPartition(&checkGraphRight), true)
 
-	return GraphEquals(&canonicalLeft, &canonicalRight)
+	if !GraphEquals(&canonicalLeft, &canonicalRight) {
+		return false
+	}
+
+	// the canonical forms ignore stereochemistry, so check there is an isomorphism that keeps it
+	if graphLeft.Stereo != nil || graphRight.Stereo != nil {
+		return stereoIsomorphic(graphLeft, graphRight)
+	}
+	return true
 }
 
 // GraphVertexRelabel returns a relabeled version of the input graph with the vertices and edges relabeled according to the given labeling
@@ -692,12 +739,14 @@ func GraphVertexRelabel(graph *Graph, labeling []int) Graph {
 	}
 
 	// vertex and edge colours are unchanged
-	newVertexColours := make([]string, len(graph.VertexColours))
-	newEdgeColours := make([]string, len(graph.EdgeColours))
+	newVertexColours := make([]int, len(graph.VertexColours))
+	newEdgeColours := make([]int, len(graph.EdgeColours))
 	copy(newVertexColours, graph.VertexColours)
 	copy(newEdgeColours, graph.EdgeColours)
 
-	return NewColourGraph(labeling, newEdges,newVertexColours, newEdgeColours)
+	relabeledGraph := NewColourGraphFromIDs(labeling, newEdges,newVertexColours, newEdgeColours)
+	relabeledGraph.copyKind(graph)
+	return relabeledGraph
 
 }
 
@@ -705,6 +754,7 @@ func CanonicalInitialCheck(graphLeft *Graph, graphRight *Graph) bool{
 
 	// Some basic canonicalisation checks to ensure that the partition of colours is the same
 	possibleMatch := len(graphLeft.Vertices) == len(graphRight.Vertices)
+	possibleMatch = possibleMatch && (graphLeft.Directed == graphRight.Directed)
 	possibleMatch = possibleMatch &&  (len(graphLeft.Edges) == len(graphRight.Edges))
 	possibleMatch = possibleMatch &&  (len(graphLeft.VertexColours) == len(graphRight.VertexColours))
 	possibleMatch = possibleMatch &&  (len(graphLeft.EdgeColours) == len(graphRight.EdgeColours))
@@ -713,23 +763,11 @@ func CanonicalInitialCheck(graphLeft *Graph, graphRight *Graph) bool{
 		return false
 	}
 
-	leftEdgeColours := make([]string, len(graphLeft.EdgeColours))
-	copy(leftEdgeColours, graphLeft.EdgeColours)
-	sort.Strings(leftEdgeColours)
-	rightEdgeColours := make([]string, len(graphRight.EdgeColours))
-	copy(rightEdgeColours, graphRight.EdgeColours)
-	sort.Strings(rightEdgeColours)
-	if !reflect.DeepEqual(leftEdgeColours, rightEdgeColours){
+	if !colourCountsEqual(graphLeft.EdgeColours, graphRight.EdgeColours){
 		return false
 	}
 
-	leftVertexColours := make([]string, len(graphLeft.VertexColours))
-	copy(leftVertexColours, graphLeft.VertexColours)
-	sort.Strings(leftVertexColours)
-	rightVertexColours := make([]string, len(graphRight.VertexColours))
-	copy(rightVertexColours, graphRight.VertexColours)
-	sort.Strings(rightVertexColours)
-	if !reflect.DeepEqual(leftVertexColours, rightVertexColours){
+	if !colourCountsEqual(graphLeft.VertexColours, graphRight.VertexColours){
 		return false
 	}
 
@@ -739,6 +777,24 @@ func CanonicalInitialCheck(graphLeft *Graph, graphRight *Graph) bool{
 
 }
 
+// colourCountsEqual returns true if two slices of colour ids contain the same colours the same number of times
+func colourCountsEqual(left []int, right []int) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	counts := make(map[int]int)
+	for _, c := range left {
+		counts[c]++
+	}
+	for _, c := range right {
+		counts[c]--
+		if counts[c] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // EdgeColourConversion takes a graph with edge colours and converts it to a layered graph with a different layer for each edge colour. E.g. if there are 3 edge colours
 // 1, 2, 3 and vertex colours a, b, c, then each vertex of colour a is split into a linear graph a1-a2-a3, with edges of type 1 going between vertices of type 1, and so on.
 // There are more space efficient ways of doing this, e.g. type 1 is layer 1, type 2 is layer 2, type 3 is both layers 1 and 2. Not implemented for now though.
@@ -749,27 +805,49 @@ func EdgeColourConversion(graph *Graph) Graph {
 		return CopyGraph(graph)
 	}
 
-	outGraph := NewColourGraph([]int{}, [][2]int{}, []string{}, []string{})
+	outGraph := NewColourGraphFromIDs([]int{}, [][2]int{}, []int{}, []int{})
+	outGraph.copyKind(graph)
+
+	// vertices without colours are treated as all having the same, empty, colour
+	vertexColours := graph.VertexColours
+	if !GraphIsVertexColoured(graph) {
+		vertexColours = make([]int, len(graph.Vertices))
+		for i := range vertexColours {
+			vertexColours[i] = Colours.Intern("")
+		}
+	}
 
 	vertexMap := make(map[int][]int)
 	nextVertex := helpers.MaxIntSlice(graph.Vertices) + 1 // the next vertex index to use
 
-	var edgeColours []string
+	var edgeColours []int
 	for _, c := range graph.EdgeColours {
-		if !helpers.ContainsStr(edgeColours, c) {
+		if !helpers.Contains(edgeColours, c) {
 			edgeColours = append(edgeColours, c)
 		}
 	}
-	sort.Strings(edgeColours)
+	SortColoursByName(edgeColours)
 
 	numLayers := len(edgeColours)
 
 	// used to map the edge colours to the correct layers
-	edgeColourMap := make(map[string]int)
+	edgeColourMap := make(map[int]int)
 	for i, c := range edgeColours {
 		edgeColourMap[c] = i
 	}
 
+	// the vertices on each layer have a different colour, e.g. colour C on layer 1 becomes C1. These are interned once per
+	// vertex colour rather than once per vertex
+	layerColours := make(map[int][]int)
+	for _, c := range vertexColours {
+		if _, ok := layerColours[c]; !ok {
+			layerColours[c] = make([]int, numLayers)
+			for i := 0; i < numLayers; i++ {
+				layerColours[c][i] = Colours.Intern(Colours.Name(c) + strconv.Itoa(i))
+			}
+		}
+	}
+
 	// add new vertices to the graph and link the vertices in each layer
 	for k, v := range graph.Vertices {
 		vertexMap[v] = make([]int, numLayers)
@@ -783,7 +861,7 @@ func EdgeColourConversion(graph *Graph) Graph {
 				outGraph.Edges = append(outGraph.Edges, [2]int{vertexMap[v][i-1], vertexMap[v][i]}) // link the layers with edges
 				nextVertex++
 			}
-			outGraph.VertexColours = append(outGraph.VertexColours, graph.VertexColours[k]+strconv.Itoa(i)) // the vertices on each layer have a different colour
+			outGraph.VertexColours = append(outGraph.VertexColours, layerColours[vertexColours[k]][i])
 		}
 	}
 
@@ -805,7 +883,7 @@ func EdgeColourRandomGraph(numVertices int, numExtraEdges int, vertexColours []s
 	randomGraph := RandomGraph(numVertices, numExtraEdges, vertexColours)
 
 	for i:=0;i<len(randomGraph.Edges);i++ {
-		randomGraph.EdgeColours = append(randomGraph.EdgeColours, edgeColours[rand.Intn(len(edgeColours))])
+		randomGraph.EdgeColours = append(randomGraph.EdgeColours, Colours.Intern(edgeColours[rand.Intn(len(edgeColours))]))
 	}
 
 	return randomGraph
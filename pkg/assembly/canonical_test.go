@@ -676,3 +676,66 @@ func TestGraphVertexRelabel(t *testing.T) {
 		}
 	}
 }
+
+func TestMultigraphsIsomorphic(t *testing.T) {
+	left := NewMultigraph([]int{1, 2, 3}, [][2]int{{1, 2}, {1, 2}, {2, 3}, {3, 3}}, []string{}, []string{"X", "Y", "X", "Z"})
+	tests := []struct{
+		graphRight Graph
+		isomorphic bool
+	}{
+		{
+			NewMultigraph([]int{1, 2, 3}, [][2]int{{3, 2}, {2, 1}, {2, 3}, {1, 1}}, []string{}, []string{"X", "X", "Y", "Z"}),
+			true,
+		},
+		{
+			// the parallel edges have the same colour, so the colours of the parallel edges no longer match
+			NewMultigraph([]int{1, 2, 3}, [][2]int{{3, 2}, {2, 1}, {2, 3}, {1, 1}}, []string{}, []string{"X", "Y", "Y", "Z"}),
+			false,
+		},
+		{
+			// the self-loop is on a different vertex
+			NewMultigraph([]int{1, 2, 3}, [][2]int{{3, 2}, {2, 1}, {2, 3}, {2, 2}}, []string{}, []string{"X", "X", "Y", "Z"}),
+			false,
+		},
+	}
+
+	for _, tt := range tests{
+		isomorphic := GraphsIsomorphic(&left, &tt.graphRight)
+		if isomorphic != tt.isomorphic{
+			t.Errorf("GraphsIsomorphic multigraph error, graphLeft %v, graphRight %v, expected %v, got %v",
+				GraphPrint(&left), GraphPrint(&tt.graphRight), tt.isomorphic, isomorphic)
+		}
+	}
+}
+
+func TestDirectedGraphsIsomorphic(t *testing.T) {
+	cycle := NewDirectedGraph([]int{1, 2, 3}, [][2]int{{1, 2}, {2, 3}, {3, 1}}, []string{}, []string{})
+	tests := []struct{
+		graphRight Graph
+		isomorphic bool
+	}{
+		{
+			// the same cycle, going the other way round
+			NewDirectedGraph([]int{1, 2, 3}, [][2]int{{2, 1}, {3, 2}, {1, 3}}, []string{}, []string{}),
+			true,
+		},
+		{
+			// same undirected graph, but not a directed cycle
+			NewDirectedGraph([]int{1, 2, 3}, [][2]int{{1, 2}, {2, 3}, {1, 3}}, []string{}, []string{}),
+			false,
+		},
+		{
+			// undirected graphs never match directed ones
+			NewColourGraph([]int{1, 2, 3}, [][2]int{{1, 2}, {2, 3}, {3, 1}}, []string{}, []string{}),
+			false,
+		},
+	}
+
+	for _, tt := range tests{
+		isomorphic := GraphsIsomorphic(&cycle, &tt.graphRight)
+		if isomorphic != tt.isomorphic{
+			t.Errorf("GraphsIsomorphic directed error, graphLeft %v, graphRight %v, expected %v, got %v",
+				cycle, tt.graphRight, tt.isomorphic, isomorphic)
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package assembly
+
+import (
+	"GoAssembly/pkg/helpers"
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Code for coarse graining a graph before assembly, for graphs too large to assemble at the atom level. Substructures
+// (ring systems, functional groups, residues...) are contracted into single vertices coloured by the rule that found
+// them, the contracted graph is assembled, and the pathway is expanded back to the original graph. The rules are read
+// from a rule file, one per line, with # comments:
+//
+//	rings <name>                   each ring system (rings sharing a vertex or edge) is a vertex
+//	group <name> <fragment>        each occurrence of a SMILES-like fragment (see ParseFragment) is a vertex
+//	vertices <name> <v1> <v2> ...  the given vertices of the graph are a vertex
+//	atoms <name> <a1> <a2> ...     the given mol file atoms (counting from 1) are a vertex
+//
+// Rules are applied in order, and a vertex already contracted by an earlier rule is not used again, so occurrences that
+// overlap it are skipped. Ring systems that are not isomorphic are given different colours, name_1, name_2..., unless
+// they are all the same, when the colour is name
+
+// Coarse graining rule kinds
+const (
+	CoarseRings    = "rings"
+	CoarseGroup    = "group"
+	CoarseVertices = "vertices"
+	CoarseAtoms    = "atoms"
+)
+
+// CoarseRule is a single coarse graining rule. Fragment is only used by group rules, and Vertices by vertices and atoms
+// rules, which are vertex labels and mol file atom numbers respectively
+type CoarseRule struct {
+	Kind     string
+	Name     string
+	Fragment Graph
+	Vertices []int
+}
+
+// CoarseGraph is a graph with substructures contracted into single vertices. Each contracted vertex is labelled with the
+// smallest of the original vertices it contains. Members gives the original vertices of each vertex of Graph, and Edges
+// gives the position in Original of each edge of Graph
+type CoarseGraph struct {
+	Graph    Graph
+	Original Graph
+	Members  map[int][]int
+	Edges    []int
+	internal map[int][]int // positions in Original of the edges inside each contracted vertex
+}
+
+// ParseCoarseRulesScanner reads coarse graining rules from a scanner
+func ParseCoarseRulesScanner(scanner *bufio.Scanner) ([]CoarseRule, error) {
+	var rules []CoarseRule
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %v: rule %v has no name", lineNumber, fields[0])
+		}
+
+		rule := CoarseRule{Kind: fields[0], Name: fields[1]}
+		switch rule.Kind {
+		case CoarseRings:
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %v: rings rule should only have a name", lineNumber)
+			}
+		case CoarseGroup:
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("line %v: group rule should have a name and a fragment", lineNumber)
+			}
+			fragment, err := ParseFragment(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %v", lineNumber, err)
+			}
+			if len(fragment.Edges) == 0 {
+				return nil, fmt.Errorf("line %v: group fragment %v has no bonds", lineNumber, fields[2])
+			}
+			rule.Fragment = fragment
+		case CoarseVertices, CoarseAtoms:
+			if len(fields) < 3 {
+				return nil, fmt.Errorf("line %v: %v rule has no vertices", lineNumber, rule.Kind)
+			}
+			for _, field := range fields[2:] {
+				v, err := strconv.Atoi(field)
+				if err != nil {
+					return nil, fmt.Errorf("line %v: %v", lineNumber, err)
+				}
+				rule.Vertices = append(rule.Vertices, v)
+			}
+		default:
+			return nil, fmt.Errorf("line %v: unknown rule %v, must be rings, group, vertices or atoms", lineNumber, rule.Kind)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// ParseCoarseRulesFile reads coarse graining rules from a rule file
+func ParseCoarseRulesFile(filePath string) ([]CoarseRule, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseCoarseRulesScanner(bufio.NewScanner(file))
+}
+
+// ParseCoarseRulesString reads coarse graining rules from a string
+func ParseCoarseRulesString(rules string) ([]CoarseRule, error) {
+	return ParseCoarseRulesScanner(bufio.NewScanner(strings.NewReader(rules)))
+}
+
+// CoarseGrain contracts the substructures found by the rules into single vertices. numbering is needed for atoms rules,
+// and can be nil otherwise. An error is returned if a vertices or atoms rule is not in the graph, or overlaps a vertex
+// that is already contracted
+func CoarseGrain(g Graph, rules []CoarseRule, numbering *MolNumbering) (CoarseGraph, error) {
+	contracted := make(map[int]bool)
+	var groups [][]int
+	var groupColours []string
+
+	// add a group of vertices to contract, unless it overlaps one already found
+	add := func(vertices []int, colour string) bool {
+		for _, v := range vertices {
+			if contracted[v] {
+				return false
+			}
+		}
+		for _, v := range vertices {
+			contracted[v] = true
+		}
+		groups = append(groups, helpers.SortedCopy(vertices))
+		groupColours = append(groupColours, colour)
+		return true
+	}
+
+	for i, rule := range rules {
+		switch rule.Kind {
+		case CoarseRings:
+			systems := RingSystems(&g)
+			colours := isomorphismClassColours(&g, systems, rule.Name)
+			for j, system := range systems {
+				add(system, colours[j])
+			}
+		case CoarseGroup:
+			for _, edges := range FindFragmentOccurrences(&g, &rule.Fragment) {
+				add(edgeVertices(&g, edges), rule.Name)
+			}
+		case CoarseVertices, CoarseAtoms:
+			vertices := rule.Vertices
+			if rule.Kind == CoarseAtoms {
+				var err error
+				vertices, err = atomVertices(numbering, rule.Vertices)
+				if err != nil {
+					return CoarseGraph{}, fmt.Errorf("rule %v: %v", i, err)
+				}
+			}
+			for _, v := range vertices {
+				if !helpers.Contains(g.Vertices, v) {
+					return CoarseGraph{}, fmt.Errorf("rule %v: vertex %v is not in the graph", i, v)
+				}
+			}
+			if !add(vertices, rule.Name) {
+				return CoarseGraph{}, fmt.Errorf("rule %v: vertices %v are already contracted by an earlier rule", i, rule.Vertices)
+			}
+		default:
+			return CoarseGraph{}, fmt.Errorf("rule %v: unknown rule %v", i, rule.Kind)
+		}
+	}
+
+	return contractVertices(g, groups, groupColours), nil
+}
+
+// contractVertices makes the CoarseGraph with each group of vertices contracted into a vertex of the given colour
+func contractVertices(g Graph, groups [][]int, groupColours []string) CoarseGraph {
+	coarse := CoarseGraph{Original: CopyGraph(&g), Members: make(map[int][]int), internal: make(map[int][]int)}
+
+	label := make(map[int]int)
+	inGroup := make(map[int]bool)
+	for _, group := range groups {
+		for _, v := range group {
+			label[v] = group[0]
+			inGroup[v] = true
+		}
+	}
+
+	// the contracted vertices need colours, so if g is not vertex coloured the others are all given the colour vertex
+	vertexColoured := GraphIsVertexColoured(&g) || len(groups) > 0
+	var vertices, vertexColours []int
+	for i, v := range g.Vertices {
+		if inGroup[v] {
+			continue
+		}
+		label[v] = v
+		coarse.Members[v] = []int{v}
+		vertices = append(vertices, v)
+		if GraphIsVertexColoured(&g) {
+			vertexColours = append(vertexColours, g.VertexColours[i])
+		} else if vertexColoured {
+			vertexColours = append(vertexColours, Colours.Intern("vertex"))
+		}
+	}
+	for i, group := range groups {
+		coarse.Members[group[0]] = group
+		vertices = append(vertices, group[0])
+		vertexColours = append(vertexColours, Colours.Intern(groupColours[i]))
+	}
+
+	var edges [][2]int
+	var edgeColours []int
+	seen := make(map[[2]int]bool)
+	multigraph := g.Multigraph
+	for i, e := range g.Edges {
+		newEdge := [2]int{label[e[0]], label[e[1]]}
+		if newEdge[0] == newEdge[1] && inGroup[e[0]] {
+			coarse.internal[newEdge[0]] = append(coarse.internal[newEdge[0]], i)
+			continue
+		}
+		key := edgeKey(&g, newEdge)
+		if seen[key] {
+			multigraph = true
+		}
+		seen[key] = true
+		edges = append(edges, newEdge)
+		if GraphIsEdgeColoured(&g) {
+			edgeColours = append(edgeColours, g.EdgeColours[i])
+		}
+		coarse.Edges = append(coarse.Edges, i)
+	}
+
+	if vertexColours == nil {
+		vertexColours = []int{}
+	}
+	if edgeColours == nil {
+		edgeColours = []int{}
+	}
+
+	coarse.Graph = NewColourGraphFromIDs(vertices, edges, vertexColours, edgeColours)
+	coarse.Graph.copyKind(&g)
+	coarse.Graph.Multigraph = multigraph
+	coarse.Graph.Stereo = nil // contracted vertices have no stereochemistry
+	return coarse
+}
+
+// RingSystems returns the vertices of each ring system of g, i.e. the connected sets of edges that are on cycles, with
+// rings that share a vertex or an edge in the same system. See FusedRingSystems for systems that only share edges
+func RingSystems(g *Graph) [][]int {
+	var ringEdges []int
+	for i, inRing := range RingEdges(g) {
+		if inRing {
+			ringEdges = append(ringEdges, i)
+		}
+	}
+	ringGraph, _ := BreakGraphOnEdges(g, ringEdges)
+	systems := ConnectedComponentVertices(&ringGraph)
+	for _, system := range systems {
+		sort.Ints(system)
+	}
+	sort.Slice(systems, func(i, j int) bool { return systems[i][0] < systems[j][0] })
+	return systems
+}
+
+// isomorphismClassColours returns a colour for each group of vertices, the same for groups with isomorphic induced
+// subgraphs. The colour is name if all the groups are the same, otherwise name_1, name_2... in order of first occurrence
+func isomorphismClassColours(g *Graph, groups [][]int, name string) []string {
+	var representatives []Graph
+	classes := make([]int, len(groups))
+	for i, group := range groups {
+		subgraph := InducedColourSubgraph(g, group)
+		classes[i] = -1
+		for class := range representatives {
+			if GraphsIsomorphic(&subgraph, &representatives[class]) {
+				classes[i] = class
+				break
+			}
+		}
+		if classes[i] == -1 {
+			classes[i] = len(representatives)
+			representatives = append(representatives, subgraph)
+		}
+	}
+
+	colours := make([]string, len(groups))
+	for i, class := range classes {
+		colours[i] = name
+		if len(representatives) > 1 {
+			colours[i] = fmt.Sprintf("%v_%v", name, class+1)
+		}
+	}
+	return colours
+}
+
+// edgeVertices returns the sorted vertices of the edges at the given positions
+func edgeVertices(g *Graph, edges []int) []int {
+	var vertices []int
+	seen := make(map[int]bool)
+	for _, e := range edges {
+		for _, v := range g.Edges[e] {
+			if !seen[v] {
+				seen[v] = true
+				vertices = append(vertices, v)
+			}
+		}
+	}
+	sort.Ints(vertices)
+	return vertices
+}
+
+// ExpandOccurrence returns the occurrence in the original graph of an occurrence in the coarse graph, e.g. from
+// PathwayOccurrences with the coarse graph. The edges inside each contracted vertex are included, and if numbering is not
+// nil the occurrence also has mol file atom and bond numbers
+func (coarse *CoarseGraph) ExpandOccurrence(occurrence FragmentOccurrence, numbering *MolNumbering) FragmentOccurrence {
+	var edges []int
+	for _, e := range occurrence.Edges {
+		edges = append(edges, coarse.Edges[e])
+	}
+	for _, v := range occurrence.Vertices {
+		edges = append(edges, coarse.internal[v]...)
+	}
+	return edgeOccurrence(&coarse.Original, edges, numbering)
+}
+
+// ExpandPathway returns a pathway for the original graph from a pathway for the coarse graph. The expanded occurrences of
+// each duplicate are added in order, then a duplicate for each contracted vertex with the same colour as an earlier one.
+// Duplicates that are not the same in the original graph, e.g. as they are attached to a ring at different atoms, or
+// that overlap an earlier one, are left out, so the assembly index of the expanded pathway is an upper bound on the assembly
+// index of the original graph
+func (coarse *CoarseGraph) ExpandPathway(pathway *Pathway) (Pathway, error) {
+	occurrences, _, err := PathwayOccurrences(pathway, &coarse.Graph, nil)
+	if err != nil {
+		return Pathway{}, err
+	}
+
+	expanded := NewStartingPathway(CopyGraph(&coarse.Original))
+	addExpanded := func(left []int, right []int) {
+		var sides [2][][2]int
+		for side, edges := range [][]int{left, right} {
+			for _, e := range edges {
+				sides[side] = append(sides[side], coarse.Original.Edges[e])
+			}
+		}
+		// the duplicate is left out if it can't be added
+		_ = AddPathwayDuplicate(&expanded, NewDuplicates(sides[0], sides[1]))
+	}
+
+	for _, o := range occurrences {
+		addExpanded(coarse.ExpandOccurrence(o[0], nil).Edges, coarse.ExpandOccurrence(o[1], nil).Edges)
+	}
+
+	// each contracted vertex is a copy of the first with the same colour
+	first := make(map[int]int)
+	for i, v := range coarse.Graph.Vertices {
+		if len(coarse.internal[v]) == 0 {
+			continue
+		}
+		colour := coarse.Graph.VertexColours[i]
+		if earlier, ok := first[colour]; ok {
+			addExpanded(coarse.internal[v], coarse.internal[earlier])
+		} else {
+			first[colour] = v
+		}
+	}
+	return expanded, nil
+}
+
+// CoarseAssembly coarse grains g with the rules, assembles the coarse graph (edge mode), and expands the first pathway
+// found back to g. The assembly index of the coarse graph is from the pathways, and an upper bound on the assembly index of
+// g is from the expanded pathway
+func CoarseAssembly(g Graph, rules []CoarseRule, numbering *MolNumbering, numWorkers int, chanBufferSize int, variant string) (CoarseGraph, []Pathway, Pathway, error) {
+	coarse, err := CoarseGrain(g, rules, numbering)
+	if err != nil {
+		return coarse, nil, Pathway{}, err
+	}
+	pathways := Assembly(coarse.Graph, numWorkers, chanBufferSize, variant)
+	expanded, err := coarse.ExpandPathway(&pathways[0])
+	return coarse, pathways, expanded, err
+}
+
+// CoarseAssemblyString returns the contracted vertices, the coarse pathways (as AssemblyString) and the occurrences of the
+// duplicates of the expanded pathway in the original graph (as OccurrencesString)
+func CoarseAssemblyString(coarse *CoarseGraph, pathways []Pathway, expanded *Pathway, numbering *MolNumbering) (string, error) {
+	outString := "CONTRACTED VERTICES\n"
+	for i, v := range coarse.Graph.Vertices {
+		if len(coarse.Members[v]) > 1 || len(coarse.internal[v]) > 0 {
+			members := edgeOccurrence(&coarse.Original, append([]int{}, coarse.internal[v]...), numbering)
+			members.Vertices = coarse.Members[v]
+			outString += fmt.Sprintf("%v %v %v\n", v, Colours.Name(coarse.Graph.VertexColours[i]), members)
+		}
+	}
+	outString += "+++++++++++++++\n"
+	outString += AssemblyString(pathways, &coarse.Graph)
+
+	occurrences, remnant, err := PathwayOccurrences(expanded, &coarse.Original, numbering)
+	if err != nil {
+		return outString, err
+	}
+	return outString + "EXPANDED " + OccurrencesString(occurrences, remnant), nil
+}
+
+// atomVertices returns the vertices of mol file atom numbers
+func atomVertices(numbering *MolNumbering, atoms []int) ([]int, error) {
+	if numbering == nil {
+		return nil, fmt.Errorf("atoms rules need a mol file")
+	}
+	vertices := make(map[int]int)
+	for v, atom := range numbering.Atoms {
+		vertices[atom] = v
+	}
+	var out []int
+	for _, atom := range atoms {
+		v, ok := vertices[atom]
+		if !ok {
+			return nil, fmt.Errorf("atom %v is not in the molecule graph, e.g. it is a hydrogen", atom)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
@@ -0,0 +1,167 @@
+package assembly
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCoarseRules(t *testing.T) {
+	rules, err := ParseCoarseRulesFile("testdata/coarse_rules.txt")
+	if err != nil {
+		t.Fatalf("ParseCoarseRulesFile error: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Kind != CoarseGroup || rules[0].Name != "COO" || len(rules[0].Fragment.Edges) != 2 ||
+		rules[1].Kind != CoarseRings || rules[1].Name != "Ring" {
+		t.Errorf("ParseCoarseRulesFile gave %v", rules)
+	}
+
+	rules, err = ParseCoarseRulesString("vertices Res 0 1 2\n\natoms Res2 4 5 # not a comment\n")
+	if err == nil {
+		t.Errorf("ParseCoarseRulesString should give an error for a comment after atoms, got %v", rules)
+	}
+	rules, err = ParseCoarseRulesString("vertices Res 0 1 2\n\natoms Res2 4 5\n")
+	if err != nil || !reflect.DeepEqual(rules[0].Vertices, []int{0, 1, 2}) || !reflect.DeepEqual(rules[1].Vertices, []int{4, 5}) {
+		t.Errorf("ParseCoarseRulesString gave %v, %v", rules, err)
+	}
+
+	errorTests := []string{"rings", "rings Ring extra", "group COO", "group C C", "vertices Res", "residue Res 1 2"}
+	for _, s := range errorTests {
+		if _, err := ParseCoarseRulesString(s); err == nil {
+			t.Errorf("ParseCoarseRulesString %v should give an error", s)
+		}
+	}
+}
+
+func TestCoarseGrain(t *testing.T) {
+	squares := NewGraphOnlyFromFile("testdata/graphs/two_joined_squares.txt")
+	rules, err := ParseCoarseRulesString("rings Ring")
+	check(err)
+	coarse, err := CoarseGrain(squares, rules, nil)
+	if err != nil {
+		t.Fatalf("CoarseGrain error: %v", err)
+	}
+	if !reflect.DeepEqual(coarse.Graph.Vertices, []int{0, 4}) || !reflect.DeepEqual(coarse.Graph.Edges, [][2]int{{0, 4}}) ||
+		!reflect.DeepEqual(coarse.Graph.VertexColourNames(), []string{"Ring", "Ring"}) {
+		t.Errorf("CoarseGrain of two joined squares should give two Ring vertices joined by an edge, got %v", GraphPrint(&coarse.Graph))
+	}
+	if !reflect.DeepEqual(coarse.Members[4], []int{4, 5, 6, 7}) || !reflect.DeepEqual(coarse.Edges, []int{4}) {
+		t.Errorf("CoarseGrain members of 4 expected [4 5 6 7] and edges [4], got %v and %v", coarse.Members[4], coarse.Edges)
+	}
+
+	// the squares overlap the contracted bridge between them, so they are not contracted
+	rules, err = ParseCoarseRulesString("vertices Bridge 3 4\nrings Ring")
+	check(err)
+	coarse, err = CoarseGrain(squares, rules, nil)
+	check(err)
+	if !reflect.DeepEqual(coarse.Graph.VertexColourNames(), []string{"C", "C", "C", "C", "C", "C", "Bridge"}) {
+		t.Errorf("CoarseGrain should give the bridge vertex and the others uncontracted, got %v", GraphPrint(&coarse.Graph))
+	}
+
+	// vertices that are not contracted are given a colour if the graph is not coloured
+	square := NewGraphOnlyFromFile("testdata/graphs/square.txt")
+	rules, err = ParseCoarseRulesString("vertices Pair 1 2")
+	check(err)
+	coarse, err = CoarseGrain(square, rules, nil)
+	check(err)
+	if !reflect.DeepEqual(coarse.Graph.VertexColourNames(), []string{"vertex", "vertex", "Pair"}) {
+		t.Errorf("CoarseGrain of a square should give two vertex vertices and a Pair, got %v", GraphPrint(&coarse.Graph))
+	}
+
+	aspirin := MolColourGraph("testdata/aspirin.mol")
+	numbering := MolNumberingFromFile("testdata/aspirin.mol")
+	rules, err = ParseCoarseRulesFile("testdata/coarse_rules.txt")
+	check(err)
+	coarse, err = CoarseGrain(aspirin, rules, &numbering)
+	check(err)
+	if !reflect.DeepEqual(coarse.Graph.VertexColourNames(), []string{"C", "COO", "COO", "Ring"}) || len(coarse.Graph.Edges) != 3 {
+		t.Errorf("CoarseGrain of aspirin should give two COO and a Ring, got %v", GraphPrint(&coarse.Graph))
+	}
+
+	errorRules := []string{"vertices Res 0 99", "atoms Res 1 2", "vertices Res 0 1\nvertices Res 1 2"}
+	for _, s := range errorRules {
+		rules, err := ParseCoarseRulesString(s)
+		check(err)
+		if _, err := CoarseGrain(squares, rules, nil); err == nil {
+			t.Errorf("CoarseGrain %v should give an error", s)
+		}
+	}
+}
+
+func TestRingSystems(t *testing.T) {
+	tests := []struct {
+		g       Graph
+		systems [][]int
+	}{
+		{NewGraphOnlyFromFile("testdata/graphs/two_joined_squares.txt"), [][]int{{0, 1, 2, 3}, {4, 5, 6, 7}}},
+		{NewGraphOnlyFromFile("testdata/graphs/chain16.txt"), nil},
+		{MolColourGraph("testdata/aspirin.mol"), [][]int{{0, 1, 2, 4, 5, 7}}},
+	}
+	for _, tt := range tests {
+		if systems := RingSystems(&tt.g); !reflect.DeepEqual(systems, tt.systems) {
+			t.Errorf("RingSystems expected %v, got %v", tt.systems, systems)
+		}
+	}
+}
+
+func TestCoarseAssembly(t *testing.T) {
+	squares := NewGraphOnlyFromFile("testdata/graphs/two_joined_squares.txt")
+	aspirin := MolColourGraph("testdata/aspirin.mol")
+	numbering := MolNumberingFromFile("testdata/aspirin.mol")
+	rings, err := ParseCoarseRulesString("rings Ring")
+	check(err)
+	fileRules, err := ParseCoarseRulesFile("testdata/coarse_rules.txt")
+	check(err)
+
+	tests := []struct {
+		g           Graph
+		rules       []CoarseRule
+		numbering   *MolNumbering
+		coarseIndex int
+		atomIndex   int
+	}{
+		{squares, rings, nil, 0, 5},
+		{aspirin, fileRules, &numbering, 2, 11},
+	}
+
+	for _, tt := range tests {
+		coarse, pathways, expanded, err := CoarseAssembly(tt.g, tt.rules, tt.numbering, 100, 100, "shortest")
+		if err != nil {
+			t.Fatalf("CoarseAssembly error: %v", err)
+		}
+		if index := AssemblyIndex(&pathways[0], &coarse.Graph); index != tt.coarseIndex {
+			t.Errorf("CoarseAssembly coarse index expected %v, got %v", tt.coarseIndex, index)
+		}
+		if index := AssemblyIndex(&expanded, &tt.g); index != tt.atomIndex {
+			t.Errorf("CoarseAssembly expanded index expected %v, got %v", tt.atomIndex, index)
+		}
+		if err := VerifyPathway(&tt.g, &expanded); err != nil {
+			t.Errorf("CoarseAssembly expanded pathway is not valid: %v", err)
+		}
+		if _, err := CoarseAssemblyString(&coarse, pathways, &expanded, tt.numbering); err != nil {
+			t.Errorf("CoarseAssemblyString error: %v", err)
+		}
+	}
+}
+
+func TestExpandOccurrence(t *testing.T) {
+	aspirin := MolColourGraph("testdata/aspirin.mol")
+	numbering := MolNumberingFromFile("testdata/aspirin.mol")
+	rules, err := ParseCoarseRulesFile("testdata/coarse_rules.txt")
+	check(err)
+	coarse, err := CoarseGrain(aspirin, rules, &numbering)
+	check(err)
+
+	// the edge from the ring to the ester expands to the ring, the ester and the bond between them
+	var edge int
+	for e, coarseEdge := range coarse.Graph.Edges {
+		if coarseEdge == [2]int{0, 3} {
+			edge = e
+		}
+	}
+	occurrence := coarse.ExpandOccurrence(FragmentOccurrence{Vertices: []int{0, 3}, Edges: []int{edge}}, &numbering)
+	expectedAtoms := []int{1, 2, 3, 4, 5, 6, 8, 11, 12}
+	expectedBonds := []int{1, 2, 3, 4, 5, 7, 8, 9, 12}
+	if !reflect.DeepEqual(occurrence.Atoms, expectedAtoms) || !reflect.DeepEqual(occurrence.Bonds, expectedBonds) {
+		t.Errorf("ExpandOccurrence expected atoms %v and bonds %v, got %v", expectedAtoms, expectedBonds, occurrence)
+	}
+}
@@ -0,0 +1,212 @@
+package assembly
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Code for choosing how molecule graphs are coloured. By default vertices are coloured by element and edges by bond type,
+// but the assembly index depends on which atoms and bonds count as the same, so a Colouring can add more chemical detail
+// to the vertex colours (charge, isotope, radical, aromaticity, hybridisation) or take the bond order away from the edge colours.
+// It can also add the stereochemistry to the graph, which doesn't change the colours but is checked by GraphsIsomorphic,
+// or normalise the bonds of aromatic rings so they are coloured the same whatever tool wrote the mol file
+
+// Colouring options, which can be combined, e.g. "charge,isotope". ColouringElement is the default, with none of the others
+const (
+	ColouringElement         = "element"
+	ColouringCharge          = "charge"
+	ColouringIsotope         = "isotope"
+	ColouringRadical         = "radical"
+	ColouringAromaticity     = "aromaticity"
+	ColouringHybridisation   = "hybridisation"
+	ColouringIgnoreBondOrder = "nobondorder"
+	ColouringStereo          = "stereo"
+	ColouringAromatise       = "aromatise"
+	ColouringKekulise        = "kekulise"
+)
+
+// Colouring selects the detail in the colours of molecule graphs. Vertex colours are the element symbol, with the isotope
+// mass number before it and the charge after it, e.g. 13C or N+1, then .singlet, .doublet or .triplet for radicals, .ar
+// for atoms with aromatic bonds and .sp, .sp2 or .sp3 for the hybridisation. Edge colours are the bond type, or bond for every edge if IgnoreBondOrder is set.
+// If Stereo is set, stereo centres and double bonds are perceived (see perceiveStereo) and kept in Graph.Stereo, so
+// fragments are only the same if their configurations match. Aromatise and Kekulise normalise the bond types before
+// colouring, see AromatiseBonds and KekuliseBonds, and can't both be set. Hydrogens chooses whether hydrogen atoms are
+// stripped, kept or added, and isn't one of the comma separated options of ParseColouring and String. The zero value is
+// the default, element only and without hydrogens
+type Colouring struct {
+	Charge          bool
+	Isotope         bool
+	Radical         bool
+	Aromaticity     bool
+	Hybridisation   bool
+	IgnoreBondOrder bool
+	Stereo          bool
+	Aromatise       bool
+	Kekulise        bool
+	Hydrogens       Hydrogens
+}
+
+// MolAtom is the attributes of an atom used by a Colouring, as read from a mol file. Isotope is the mass number, or 0 for
+// the natural abundance, and Radical is 0 for none, or 1, 2 or 3 for singlet, doublet or triplet, as in M  RAD lines
+type MolAtom struct {
+	Element string
+	Charge  int
+	Isotope int
+	Radical int
+}
+
+// ParseColouring returns the Colouring for comma separated colouring options, e.g. "charge,isotope"
+func ParseColouring(s string) (Colouring, error) {
+	var colouring Colouring
+	for _, option := range strings.Split(s, ",") {
+		switch strings.TrimSpace(option) {
+		case ColouringElement:
+		case ColouringCharge:
+			colouring.Charge = true
+		case ColouringIsotope:
+			colouring.Isotope = true
+		case ColouringRadical:
+			colouring.Radical = true
+		case ColouringAromaticity:
+			colouring.Aromaticity = true
+		case ColouringHybridisation:
+			colouring.Hybridisation = true
+		case ColouringIgnoreBondOrder:
+			colouring.IgnoreBondOrder = true
+		case ColouringStereo:
+			colouring.Stereo = true
+		case ColouringAromatise:
+			colouring.Aromatise = true
+		case ColouringKekulise:
+			colouring.Kekulise = true
+		default:
+			return colouring, fmt.Errorf("unknown colouring %v, must be %v, %v, %v, %v, %v, %v, %v, %v, %v or %v", option,
+				ColouringElement, ColouringCharge, ColouringIsotope, ColouringRadical, ColouringAromaticity,
+				ColouringHybridisation, ColouringIgnoreBondOrder, ColouringStereo, ColouringAromatise, ColouringKekulise)
+		}
+	}
+	if colouring.Aromatise && colouring.Kekulise {
+		return colouring, fmt.Errorf("colourings %v and %v can't be used together", ColouringAromatise, ColouringKekulise)
+	}
+	return colouring, nil
+}
+
+// String returns the colouring options, in the form read by ParseColouring
+func (colouring Colouring) String() string {
+	options := []string{ColouringElement}
+	for _, option := range []struct {
+		set  bool
+		name string
+	}{
+		{colouring.Charge, ColouringCharge},
+		{colouring.Isotope, ColouringIsotope},
+		{colouring.Radical, ColouringRadical},
+		{colouring.Aromaticity, ColouringAromaticity},
+		{colouring.Hybridisation, ColouringHybridisation},
+		{colouring.IgnoreBondOrder, ColouringIgnoreBondOrder},
+		{colouring.Stereo, ColouringStereo},
+		{colouring.Aromatise, ColouringAromatise},
+		{colouring.Kekulise, ColouringKekulise},
+	} {
+		if option.set {
+			options = append(options, option.name)
+		}
+	}
+	if len(options) > 1 {
+		options = options[1:]
+	}
+	return strings.Join(options, ",")
+}
+
+// molAtoms returns the MolAtoms for atoms with only an element
+func molAtoms(elements []string) []MolAtom {
+	atoms := make([]MolAtom, len(elements))
+	for i, element := range elements {
+		atoms[i] = MolAtom{Element: element}
+	}
+	return atoms
+}
+
+// normaliseBonds returns the bond types (mol file numbers) with aromatic rings normalised as chosen by colouring, or
+// the bond types unchanged if neither Aromatise nor Kekulise is set
+func (colouring Colouring) normaliseBonds(atoms []MolAtom, bonds [][2]int, bondTypes []int) ([]int, error) {
+	switch {
+	case colouring.Aromatise:
+		return AromatiseBonds(atoms, bonds, bondTypes)
+	case colouring.Kekulise:
+		return KekuliseBonds(atoms, bonds, bondTypes)
+	}
+	return bondTypes, nil
+}
+
+// atomColours returns the vertex colour of each atom, with bond types (mol file numbers) to find aromaticity and
+// hybridisation
+func (colouring Colouring) atomColours(atoms []MolAtom, bonds [][2]int, bondTypes []int) []string {
+	// count the bonds of each type at each atom
+	bondCounts := make([]map[int]int, len(atoms))
+	for i := range bondCounts {
+		bondCounts[i] = make(map[int]int)
+	}
+	for i, b := range bonds {
+		bondCounts[b[0]][bondTypes[i]]++
+		bondCounts[b[1]][bondTypes[i]]++
+	}
+
+	colours := make([]string, len(atoms))
+	for i, atom := range atoms {
+		colour := atom.Element
+		if colouring.Isotope && atom.Isotope != 0 {
+			colour = fmt.Sprintf("%v%v", atom.Isotope, colour)
+		}
+		if colouring.Charge && atom.Charge != 0 {
+			colour += fmt.Sprintf("%+d", atom.Charge)
+		}
+		if colouring.Radical && atom.Radical >= 1 && atom.Radical <= 3 {
+			colour += []string{".singlet", ".doublet", ".triplet"}[atom.Radical-1]
+		}
+		if colouring.Aromaticity && bondCounts[i][4] > 0 {
+			colour += ".ar"
+		}
+		if colouring.Hybridisation {
+			colour += "." + hybridisation(bondCounts[i])
+		}
+		colours[i] = colour
+	}
+	return colours
+}
+
+// hybridisation guesses the hybridisation of an atom from the number of bonds of each type, ignoring lone pairs and
+// charges: a triple bond or two double bonds is sp, a double or aromatic bond sp2, and only single bonds sp3
+func hybridisation(bondCounts map[int]int) string {
+	switch {
+	case bondCounts[3] > 0 || bondCounts[2] > 1:
+		return "sp"
+	case bondCounts[2] > 0 || bondCounts[4] > 0:
+		return "sp2"
+	default:
+		return "sp3"
+	}
+}
+
+// bondColours returns the edge colour of each bond type. Types 1 to 4 are single, double, triple and aromatic, and any
+// other type is error
+func (colouring Colouring) bondColours(bondTypes []int) []string {
+	colours := make([]string, len(bondTypes))
+	for i, bondType := range bondTypes {
+		switch {
+		case colouring.IgnoreBondOrder:
+			colours[i] = "bond"
+		case bondType == 1:
+			colours[i] = "single"
+		case bondType == 2:
+			colours[i] = "double"
+		case bondType == 3:
+			colours[i] = "triple"
+		case bondType == 4:
+			colours[i] = "aromatic"
+		default:
+			colours[i] = "error"
+		}
+	}
+	return colours
+}
@@ -0,0 +1,103 @@
+package assembly
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseColouring(t *testing.T) {
+	tests := []struct {
+		s         string
+		colouring Colouring
+		str       string
+	}{
+		{"element", Colouring{}, "element"},
+		{"charge,isotope", Colouring{Charge: true, Isotope: true}, "charge,isotope"},
+		{"nobondorder, aromaticity", Colouring{Aromaticity: true, IgnoreBondOrder: true}, "aromaticity,nobondorder"},
+		{"hybridisation", Colouring{Hybridisation: true}, "hybridisation"},
+		{"radical,charge", Colouring{Charge: true, Radical: true}, "charge,radical"},
+		{"stereo,nobondorder", Colouring{IgnoreBondOrder: true, Stereo: true}, "nobondorder,stereo"},
+		{"aromatise,aromaticity", Colouring{Aromaticity: true, Aromatise: true}, "aromaticity,aromatise"},
+	}
+	for _, tt := range tests {
+		colouring, err := ParseColouring(tt.s)
+		if err != nil || colouring != tt.colouring {
+			t.Errorf("ParseColouring %v expected %v, got %v %v", tt.s, tt.colouring, colouring, err)
+		}
+		if colouring.String() != tt.str {
+			t.Errorf("Colouring String expected %v, got %v", tt.str, colouring.String())
+		}
+	}
+	if _, err := ParseColouring("element,colour"); err == nil {
+		t.Errorf("ParseColouring should give an error for an unknown colouring")
+	}
+	if _, err := ParseColouring("kekulise,aromatise"); err == nil {
+		t.Errorf("ParseColouring should give an error for both aromatise and kekulise")
+	}
+}
+
+func TestAtomColours(t *testing.T) {
+	atoms := []MolAtom{{"C", 0, 13, 0}, {"N", 1, 0, 0}, {"O", -1, 18, 2}, {"C", 0, 0, 0}}
+	bonds := [][2]int{{0, 1}, {1, 2}, {1, 3}}
+	bondTypes := []int{3, 1, 4}
+
+	tests := []struct {
+		colouring Colouring
+		colours   []string
+	}{
+		{Colouring{}, []string{"C", "N", "O", "C"}},
+		{Colouring{Charge: true}, []string{"C", "N+1", "O-1", "C"}},
+		{Colouring{Isotope: true}, []string{"13C", "N", "18O", "C"}},
+		{Colouring{Charge: true, Isotope: true}, []string{"13C", "N+1", "18O-1", "C"}},
+		{Colouring{Radical: true}, []string{"C", "N", "O.doublet", "C"}},
+		{Colouring{Aromaticity: true}, []string{"C", "N.ar", "O", "C.ar"}},
+		{Colouring{Hybridisation: true}, []string{"C.sp", "N.sp", "O.sp3", "C.sp2"}},
+	}
+	for _, tt := range tests {
+		if colours := tt.colouring.atomColours(atoms, bonds, bondTypes); !reflect.DeepEqual(colours, tt.colours) {
+			t.Errorf("atomColours %v expected %v, got %v", tt.colouring, tt.colours, colours)
+		}
+	}
+
+	if colours := (Colouring{}).bondColours([]int{1, 2, 3, 4, 8}); !reflect.DeepEqual(colours, []string{"single", "double", "triple", "aromatic", "error"}) {
+		t.Errorf("bondColours gave %v", colours)
+	}
+	if colours := (Colouring{IgnoreBondOrder: true}).bondColours([]int{1, 2}); !reflect.DeepEqual(colours, []string{"bond", "bond"}) {
+		t.Errorf("bondColours ignoring bond order gave %v", colours)
+	}
+}
+
+func TestMolGraphColouring(t *testing.T) {
+	tests := []struct {
+		molFile   string
+		colouring Colouring
+		vColours  []string
+		eColours  []string
+	}{
+		{"testdata/toluene_aromatic.mol", Colouring{}, []string{"C", "C", "C", "C", "C", "C", "C"},
+			[]string{"aromatic", "aromatic", "aromatic", "aromatic", "aromatic", "aromatic", "single"}},
+		{"testdata/toluene_aromatic.mol", Colouring{Aromaticity: true, IgnoreBondOrder: true},
+			[]string{"C.ar", "C.ar", "C.ar", "C.ar", "C.ar", "C.ar", "C"}, []string{"bond", "bond", "bond", "bond", "bond", "bond", "bond"}},
+		{"testdata/toluene_aromatic.mol", Colouring{Hybridisation: true},
+			[]string{"C.sp2", "C.sp2", "C.sp2", "C.sp2", "C.sp2", "C.sp2", "C.sp3"},
+			[]string{"aromatic", "aromatic", "aromatic", "aromatic", "aromatic", "aromatic", "single"}},
+	}
+	for _, tt := range tests {
+		g, err := MolGraphFromFileColouring(tt.molFile, tt.colouring)
+		if err != nil {
+			t.Errorf("MolGraphFromFileColouring %v error: %v", tt.molFile, err)
+			continue
+		}
+		if !reflect.DeepEqual(g.VertexColourNames(), tt.vColours) || !reflect.DeepEqual(g.EdgeColourNames(), tt.eColours) {
+			t.Errorf("MolGraphFromFileColouring %v %v expected %v %v, got %v %v", tt.molFile, tt.colouring, tt.vColours,
+				tt.eColours, g.VertexColourNames(), g.EdgeColourNames())
+		}
+	}
+
+	// carbons with different hybridisation are no longer the same, so aspirin has fewer duplicates
+	aspirin, err := MolGraphFromFileColouring("testdata/aspirin.mol", Colouring{Hybridisation: true})
+	check(err)
+	if index := AssemblyIndex(&Assembly(aspirin, 100, 100, "shortest")[0], &aspirin); index != 9 {
+		t.Errorf("Assembly of aspirin coloured by hybridisation should give 9, got %v", index)
+	}
+}
@@ -0,0 +1,93 @@
+package assembly
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// This file defines the colour dictionary, which interns vertex and edge colour strings to small integers.
+// Graph operations, partitioning and canonicalisation all work on the integer colours, and the strings are only
+// restored when reading or writing graphs
+
+// ColourDictionary maps colour strings to integer ids and back again. Ids are allocated in the order colours are first seen,
+// so they are only stable within a single process
+type ColourDictionary struct {
+	ids   map[string]int
+	names []string
+	mu    sync.RWMutex
+}
+
+// NewColourDictionary returns an empty colour dictionary
+func NewColourDictionary() *ColourDictionary {
+	return &ColourDictionary{ids: make(map[string]int)}
+}
+
+// Intern returns the integer id of a colour string, adding it to the dictionary if it has not been seen before
+func (d *ColourDictionary) Intern(colour string) int {
+	d.mu.RLock()
+	id, ok := d.ids[colour]
+	d.mu.RUnlock()
+	if ok {
+		return id
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if id, ok = d.ids[colour]; ok {
+		return id
+	}
+	id = len(d.names)
+	d.ids[colour] = id
+	d.names = append(d.names, colour)
+	return id
+}
+
+// InternSlice interns each colour in a slice, returning the slice of ids
+func (d *ColourDictionary) InternSlice(colours []string) []int {
+	ids := make([]int, len(colours))
+	for i, c := range colours {
+		ids[i] = d.Intern(c)
+	}
+	return ids
+}
+
+// Name returns the colour string for an id. Ids that are not in the dictionary are written as #id
+func (d *ColourDictionary) Name(id int) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if id >= 0 && id < len(d.names) {
+		return d.names[id]
+	}
+	return fmt.Sprintf("#%v", id)
+}
+
+// Names returns the colour strings for a slice of ids
+func (d *ColourDictionary) Names(ids []int) []string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = d.Name(id)
+	}
+	return names
+}
+
+// Len returns the number of colours in the dictionary
+func (d *ColourDictionary) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.names)
+}
+
+// SortColoursByName sorts a slice of colour ids by their colour names in the Colours dictionary. Ids depend on the order
+// colours were first interned, so anything that must be reproducible between runs (e.g. canonical labelling) should
+// order colours with this rather than by id
+func SortColoursByName(ids []int) {
+	sort.Slice(ids, func(i, j int) bool {
+		return Colours.Name(ids[i]) < Colours.Name(ids[j])
+	})
+}
+
+// Colours is the colour dictionary used for the whole process. It only grows - colours are never removed, as graphs
+// built earlier may still hold their ids. The number of distinct colours in chemical graphs is small, so this is not
+// a problem in practice, but a long-lived process that reads arbitrary user colours will keep every one it has seen
+var Colours = NewColourDictionary()
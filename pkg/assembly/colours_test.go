@@ -0,0 +1,105 @@
+package assembly
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestColourDictionaryIntern(t *testing.T) {
+	tests := []struct {
+		colours []string
+		ids     []int
+		names   []string
+	}{
+		{
+			[]string{"C", "O", "C", "N", "O"},
+			[]int{0, 1, 0, 2, 1},
+			[]string{"C", "O", "C", "N", "O"},
+		},
+		{
+			[]string{},
+			[]int{},
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		d := NewColourDictionary()
+		ids := d.InternSlice(tt.colours)
+		names := d.Names(ids)
+		if !reflect.DeepEqual(ids, tt.ids) || !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("ColourDictionary error, colours %v\nExpected ids %v names %v\nGot ids %v names %v",
+				tt.colours, tt.ids, tt.names, ids, names)
+		}
+
+		// interning again must give the same ids
+		if again := d.InternSlice(tt.colours); !reflect.DeepEqual(again, ids) {
+			t.Errorf("ColourDictionary ids not stable, first %v second %v", ids, again)
+		}
+	}
+}
+
+func TestColourDictionaryName(t *testing.T) {
+	d := NewColourDictionary()
+	d.Intern("single")
+
+	tests := []struct {
+		id   int
+		name string
+	}{
+		{0, "single"},
+		{1, "#1"},
+		{-1, "#-1"},
+	}
+
+	for _, tt := range tests {
+		if name := d.Name(tt.id); name != tt.name {
+			t.Errorf("ColourDictionary.Name error, id %v, expected %v, got %v", tt.id, tt.name, name)
+		}
+	}
+}
+
+func TestColourDictionaryConcurrent(t *testing.T) {
+	d := NewColourDictionary()
+	numColours := 50
+	numGoroutines := 20
+
+	results := make([][]int, numGoroutines)
+	var wg sync.WaitGroup
+	for g := 0; g < numGoroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < numColours; i++ {
+				results[g] = append(results[g], d.Intern(strconv.Itoa(i)))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if d.Len() != numColours {
+		t.Errorf("ColourDictionary concurrent error, expected %v colours, got %v", numColours, d.Len())
+	}
+	for g := 1; g < numGoroutines; g++ {
+		if !reflect.DeepEqual(results[g], results[0]) {
+			t.Errorf("ColourDictionary concurrent error, goroutines got different ids %v %v", results[0], results[g])
+		}
+	}
+	for i, id := range results[0] {
+		if d.Name(id) != strconv.Itoa(i) {
+			t.Errorf("ColourDictionary concurrent error, id %v has name %v, expected %v", id, d.Name(id), i)
+		}
+	}
+}
+
+func TestSortColoursByName(t *testing.T) {
+	ids := Colours.InternSlice([]string{"zz", "Blue", "Red", "aa"})
+	SortColoursByName(ids)
+	names := Colours.Names(ids)
+	expected := []string{"Blue", "Red", "aa", "zz"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("SortColoursByName error, expected %v, got %v", expected, names)
+	}
+}
@@ -0,0 +1,149 @@
+package assembly
+
+import "fmt"
+
+// Code for constraining the edge based search, e.g. to keep aromatic rings intact or to never split a metal complex core.
+// The constraints are checked by ExtendPathway and CheckSubgraphMatches as they enumerate subgraphs, so a pathway that
+// breaks them is never made. A nil *Constraints is no constraints
+
+// Constraints restricts the fragments that can be used as duplicates:
+// kept bonds are edges of the original graph that must not be cut. The kept bonds that share vertices form groups, and a
+// fragment (either occurrence of a duplicate) must contain all of the edges of a group or none of them;
+// forbidden fragments can not be used as duplicates, nor can anything isomorphic to them;
+// fragments must have at least minSize and at most maxSize edges, where 0 is no limit
+type Constraints struct {
+	keepGroups map[[2]int]int // edge key of each kept bond in original vertex labels, to its group
+	keepSizes  []int          // number of edges in each group
+	forbidden  []Graph
+	minSize    int
+	maxSize    int
+	directed   bool
+}
+
+// NewConstraints returns the constraints for the search on g, with keepBonds in the vertex labels of g. An error is
+// returned if a kept bond is not an edge of g, or the size limits are negative or the wrong way round
+func NewConstraints(g *Graph, keepBonds [][2]int, forbidden []Graph, minSize int, maxSize int) (*Constraints, error) {
+	if minSize < 0 || maxSize < 0 {
+		return nil, fmt.Errorf("fragment size limits must not be negative, got %v and %v", minSize, maxSize)
+	}
+	if maxSize != 0 && maxSize < minSize {
+		return nil, fmt.Errorf("maximum fragment size %v is less than the minimum %v", maxSize, minSize)
+	}
+
+	constraints := Constraints{keepGroups: make(map[[2]int]int), minSize: minSize, maxSize: maxSize, directed: g.Directed}
+	for _, f := range forbidden {
+		constraints.forbidden = append(constraints.forbidden, CopyGraph(&f))
+	}
+
+	edgeCount := make(map[[2]int]int)
+	for _, e := range g.Edges {
+		edgeCount[edgeKey(g, e)]++
+	}
+
+	// the groups are the connected components of the kept bonds, found by merging groups that share a vertex
+	vertexGroup := make(map[int]int)
+	var groupOf []int // groupOf[i] is the group that group i was merged into, or i
+	find := func(i int) int {
+		for groupOf[i] != i {
+			i = groupOf[i]
+		}
+		return i
+	}
+	var keys [][2]int
+	for _, bond := range keepBonds {
+		key := edgeKey(g, bond)
+		if edgeCount[key] == 0 {
+			return nil, fmt.Errorf("kept bond %v is not an edge of the graph", bond)
+		}
+		if _, seen := constraints.keepGroups[key]; seen {
+			continue
+		}
+		group := len(groupOf)
+		groupOf = append(groupOf, group)
+		for _, v := range key {
+			if other, ok := vertexGroup[v]; ok {
+				groupOf[find(other)] = group
+			}
+			vertexGroup[v] = group
+		}
+		constraints.keepGroups[key] = group
+		keys = append(keys, key)
+	}
+
+	// number the merged groups from 0, counting the edges of each, including parallel edges
+	number := make(map[int]int)
+	for _, key := range keys {
+		root := find(constraints.keepGroups[key])
+		if _, ok := number[root]; !ok {
+			number[root] = len(constraints.keepSizes)
+			constraints.keepSizes = append(constraints.keepSizes, 0)
+		}
+		constraints.keepGroups[key] = number[root]
+		constraints.keepSizes[number[root]] += edgeCount[key]
+	}
+
+	return &constraints, nil
+}
+
+// KeepMolBonds returns the edges of a molecule graph for mol file bond numbers (counting from 1), using the MolNumbering
+// of the graph, e.g. to pass to NewConstraints
+func KeepMolBonds(g *Graph, numbering MolNumbering, bonds []int) ([][2]int, error) {
+	edges := make(map[int][2]int)
+	for e, bond := range numbering.Bonds {
+		edges[bond] = g.Edges[e]
+	}
+	var keep [][2]int
+	for _, bond := range bonds {
+		e, ok := edges[bond]
+		if !ok {
+			return nil, fmt.Errorf("bond %v is not in the molecule graph, e.g. it is to a hydrogen", bond)
+		}
+		keep = append(keep, e)
+	}
+	return keep, nil
+}
+
+// canGrow returns true if a subgraph of size edges can have another edge added
+func (constraints *Constraints) canGrow(size int) bool {
+	return constraints == nil || constraints.maxSize == 0 || size < constraints.maxSize
+}
+
+// allowsFragment returns true if fragment, a subgraph of the remnant of pathway, can be used as a duplicate
+func (constraints *Constraints) allowsFragment(pathway *Pathway, fragment *Graph) bool {
+	if constraints == nil {
+		return true
+	}
+	size := len(fragment.Edges)
+	if size < constraints.minSize || (constraints.maxSize != 0 && size > constraints.maxSize) {
+		return false
+	}
+	for i := range constraints.forbidden {
+		if GraphsIsomorphic(fragment, &constraints.forbidden[i]) {
+			return false
+		}
+	}
+	return constraints.keepsBonds(pathway, fragment)
+}
+
+// keepsBonds returns true if fragment, a subgraph of the remnant of pathway, has all or none of each group of kept bonds
+func (constraints *Constraints) keepsBonds(pathway *Pathway, fragment *Graph) bool {
+	if constraints == nil || len(constraints.keepSizes) == 0 {
+		return true
+	}
+	counts := make(map[int]int)
+	for _, e := range fragment.Edges {
+		key := [2]int{originalVertex(pathway, e[0]), originalVertex(pathway, e[1])}
+		if !constraints.directed && key[0] > key[1] {
+			key = [2]int{key[1], key[0]}
+		}
+		if group, ok := constraints.keepGroups[key]; ok {
+			counts[group]++
+		}
+	}
+	for group, count := range counts {
+		if count != constraints.keepSizes[group] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package assembly
+
+import (
+	"testing"
+)
+
+func TestAssemblyConstrained(t *testing.T) {
+	aspirin := MolColourGraph("testdata/aspirin.mol")
+	numbering := MolNumberingFromFile("testdata/aspirin.mol")
+	ring, err := KeepMolBonds(&aspirin, numbering, []int{1, 2, 4, 5, 8, 9})
+	check(err)
+	acid, err := ParseFragment("C(=O)O")
+	check(err)
+
+	tests := []struct {
+		keep      [][2]int
+		forbidden []Graph
+		minSize   int
+		maxSize   int
+		index     int
+	}{
+		{nil, nil, 0, 0, 8},
+		{nil, nil, 0, 1, 12},
+		{nil, nil, 0, 2, 9},
+		{nil, nil, 7, 0, 12},
+		{nil, []Graph{acid}, 0, 0, 8},
+		{ring, nil, 0, 0, 10},
+	}
+
+	for _, tt := range tests {
+		constraints, err := NewConstraints(&aspirin, tt.keep, tt.forbidden, tt.minSize, tt.maxSize)
+		check(err)
+		pathways := AssemblyConstrained(aspirin, constraints, 100, 100, "shortest")
+		if index := AssemblyIndex(&pathways[0], &aspirin); index != tt.index {
+			t.Errorf("AssemblyConstrained %v expected index %v, got %v", tt, tt.index, index)
+		}
+		if err := VerifyPathway(&aspirin, &pathways[0]); err != nil {
+			t.Errorf("AssemblyConstrained gave an invalid pathway: %v", err)
+		}
+
+		for _, fragment := range pathways[0].Fragments() {
+			if size := len(fragment.Edges); size < tt.minSize || (tt.maxSize != 0 && size > tt.maxSize) {
+				t.Errorf("AssemblyConstrained %v used a fragment of size %v", tt, size)
+			}
+			if len(tt.forbidden) > 0 && GraphsIsomorphic(&fragment, &tt.forbidden[0]) {
+				t.Errorf("AssemblyConstrained %v used a forbidden fragment", tt)
+			}
+		}
+
+		// each occurrence has all of the ring or none of it
+		kept := make(map[[2]int]bool)
+		for _, e := range tt.keep {
+			kept[e] = true
+		}
+		occurrences, _, err := PathwayOccurrences(&pathways[0], &aspirin, nil)
+		check(err)
+		for _, o := range occurrences {
+			for _, occurrence := range o {
+				count := 0
+				for _, e := range occurrence.Edges {
+					if kept[aspirin.Edges[e]] {
+						count++
+					}
+				}
+				if count != 0 && count != len(tt.keep) {
+					t.Errorf("AssemblyConstrained %v cut a kept bond, occurrence %v", tt, occurrence)
+				}
+			}
+		}
+	}
+}
+
+func TestNewConstraints(t *testing.T) {
+	square := NewGraphOnlyFromFile("testdata/graphs/square.txt")
+	errorTests := []struct {
+		keep    [][2]int
+		minSize int
+		maxSize int
+	}{
+		{[][2]int{{0, 2}}, 0, 0},
+		{nil, -1, 0},
+		{nil, 3, 2},
+	}
+	for _, tt := range errorTests {
+		if _, err := NewConstraints(&square, tt.keep, nil, tt.minSize, tt.maxSize); err == nil {
+			t.Errorf("NewConstraints %v should give an error", tt)
+		}
+	}
+
+	// keeping two opposite edges of a square makes two groups, so the square can still be split in half
+	keep := [][2]int{square.Edges[0], square.Edges[2]}
+	constraints, err := NewConstraints(&square, keep, nil, 0, 0)
+	check(err)
+	if len(constraints.keepSizes) != 2 {
+		t.Errorf("NewConstraints should give two groups of kept bonds, got %v", constraints.keepSizes)
+	}
+	if index := AssemblyIndex(&AssemblyConstrained(square, constraints, 100, 100, "shortest")[0], &square); index != 2 {
+		t.Errorf("AssemblyConstrained of a square with opposite edges kept should give 2, got %v", index)
+	}
+
+	// keeping two adjacent edges makes one group, and a duplicate would split it
+	keep = [][2]int{square.Edges[0], square.Edges[1], square.Edges[2]}
+	constraints, err = NewConstraints(&square, keep, nil, 0, 0)
+	check(err)
+	if len(constraints.keepSizes) != 1 || constraints.keepSizes[0] != 3 {
+		t.Errorf("NewConstraints should give one group of three kept bonds, got %v", constraints.keepSizes)
+	}
+	if index := AssemblyIndex(&AssemblyConstrained(square, constraints, 100, 100, "shortest")[0], &square); index != 3 {
+		t.Errorf("AssemblyConstrained of a square with a path of three edges kept should give 3, got %v", index)
+	}
+}
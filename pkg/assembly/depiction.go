@@ -0,0 +1,294 @@
+package assembly
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Code for the positions and labels of the vertices of a graph, to draw it (see SVGString). Molecules are drawn from the
+// 2D coordinates of the mol file in the usual skeletal style, with carbon atoms unlabelled and hydrogens in the labels of
+// the other atoms. Graphs, and mol files without coordinates, are laid out with a simple spring embedder
+
+// Depiction is how to draw a graph. Positions and Labels are by vertex, and BondTypes and BondStereo are the mol file
+// bond type and stereo flag of each edge, by position in the graph's Edges. BondTypes and BondStereo are nil for graphs
+// that aren't molecules, which are drawn with single lines
+type Depiction struct {
+	Positions  map[int][2]float64
+	Labels     map[int]DepictionLabel
+	BondTypes  []int
+	BondStereo []int
+}
+
+// DepictionLabel is the text drawn at a vertex: Text followed by Hydrogens hydrogen atoms, with the isotope (0 for none)
+// and charge as superscripts. A vertex with no Text is drawn as a bare junction of bonds, as carbon atoms are
+type DepictionLabel struct {
+	Text      string
+	Hydrogens int
+	Charge    int
+	Isotope   int
+}
+
+// MolDepictionFromFile returns the Depiction of the graph from MolGraphFromFileColouring with hydrogens as the Hydrogens
+// option. An error is returned if the mol file can't be read, or the implicit hydrogens can't be found
+func MolDepictionFromFile(molFile string, hydrogens Hydrogens) (Depiction, error) {
+	f, err := os.Open(molFile)
+	if err != nil {
+		return Depiction{}, err
+	}
+	defer f.Close()
+	block, err := parseMolBlock(bufio.NewScanner(f))
+	if err != nil {
+		return Depiction{}, err
+	}
+	return molBlockDepiction(block, hydrogens)
+}
+
+// MolDepictionFromString is the same as MolDepictionFromFile, for the graph from MolGraphFromStringColouring
+func MolDepictionFromString(molBlock string, hydrogens Hydrogens) (Depiction, error) {
+	block, err := parseMolBlock(bufio.NewScanner(strings.NewReader(molBlock)))
+	if err != nil {
+		return Depiction{}, err
+	}
+	return molBlockDepiction(block, hydrogens)
+}
+
+// molBlockDepiction finds the Depiction of a mol block, with its atoms and bonds in the same order as the graph from
+// molBlockGraph. Stripped hydrogen atoms are counted in the labels of their atoms, along with the implicit hydrogens,
+// and added hydrogen atoms are placed around their atoms. If the mol block has no 2D coordinates, the molecule is laid
+// out instead
+func molBlockDepiction(block molBlock, hydrogens Hydrogens) (Depiction, error) {
+	implicit, err := implicitHydrogens(block.atoms, block.bonds, block.bondTypes)
+	if err != nil {
+		if hydrogens == HydrogensAdd {
+			return Depiction{}, err
+		}
+		// the labels are drawn without the implicit hydrogens, as the graph doesn't need them
+		implicit = make([]int, len(block.atoms))
+	}
+
+	// the position of each mol file atom in the graph, or -1 if it is stripped
+	vertex := make([]int, len(block.atoms))
+	var atoms []MolAtom
+	hCounts := []int{}
+	hasCoordinates := false
+	positions := make(map[int][2]float64)
+	for i, atom := range block.atoms {
+		if hydrogens == HydrogensStrip && atom.Element == "H" {
+			vertex[i] = -1
+			continue
+		}
+		vertex[i] = len(atoms)
+		positions[vertex[i]] = [2]float64{block.coordinates[i][0], block.coordinates[i][1]}
+		hasCoordinates = hasCoordinates || block.coordinates[i][0] != 0 || block.coordinates[i][1] != 0
+		atoms = append(atoms, atom)
+		if hydrogens == HydrogensAdd {
+			hCounts = append(hCounts, 0)
+		} else {
+			hCounts = append(hCounts, implicit[i])
+		}
+	}
+
+	depiction := Depiction{Labels: make(map[int]DepictionLabel), BondTypes: []int{}, BondStereo: []int{}}
+	var bonds [][2]int
+	for i, b := range block.bonds {
+		if vertex[b[0]] == -1 || vertex[b[1]] == -1 {
+			if vertex[b[0]] != -1 {
+				hCounts[vertex[b[0]]]++
+			} else if vertex[b[1]] != -1 {
+				hCounts[vertex[b[1]]]++
+			}
+			continue
+		}
+		bonds = append(bonds, [2]int{vertex[b[0]], vertex[b[1]]})
+		depiction.BondTypes = append(depiction.BondTypes, block.bondTypes[i])
+		depiction.BondStereo = append(depiction.BondStereo, block.bondStereo[i])
+	}
+
+	// added hydrogens come after the other atoms, in the order of the atoms they are on, as in addHAtoms
+	added := make(map[int][]int)
+	if hydrogens == HydrogensAdd {
+		for i, count := range implicit {
+			for j := 0; j < count; j++ {
+				h := len(atoms)
+				atoms = append(atoms, MolAtom{Element: "H"})
+				hCounts = append(hCounts, 0)
+				bonds = append(bonds, [2]int{vertex[i], h})
+				depiction.BondTypes = append(depiction.BondTypes, 1)
+				depiction.BondStereo = append(depiction.BondStereo, 0)
+				added[vertex[i]] = append(added[vertex[i]], h)
+			}
+		}
+	}
+
+	degrees := make([]int, len(atoms))
+	for _, b := range bonds {
+		degrees[b[0]]++
+		degrees[b[1]]++
+	}
+	vertices := make([]int, len(atoms))
+	for v, atom := range atoms {
+		vertices[v] = v
+		label := DepictionLabel{Text: atom.Element, Hydrogens: hCounts[v], Charge: atom.Charge, Isotope: atom.Isotope}
+		if atom.Element == "C" && degrees[v] > 0 && atom.Charge == 0 && atom.Isotope == 0 && atom.Radical == 0 {
+			label = DepictionLabel{}
+		}
+		depiction.Labels[v] = label
+	}
+
+	if hasCoordinates {
+		placeHydrogens(positions, bonds, added)
+		depiction.Positions = positions
+	} else {
+		depiction.Positions = layout(vertices, bonds)
+	}
+	return depiction, nil
+}
+
+// GraphDepiction returns a Depiction of a graph that isn't a molecule. It is laid out (see layout), and each vertex is
+// labelled with its colour, or with the vertex itself if the graph isn't vertex coloured
+func GraphDepiction(g *Graph) Depiction {
+	depiction := Depiction{Positions: layout(g.Vertices, g.Edges), Labels: make(map[int]DepictionLabel)}
+	colours := g.VertexColourNames()
+	for i, v := range g.Vertices {
+		if GraphIsVertexColoured(g) {
+			depiction.Labels[v] = DepictionLabel{Text: colours[i]}
+		} else {
+			depiction.Labels[v] = DepictionLabel{Text: fmt.Sprint(v)}
+		}
+	}
+	return depiction
+}
+
+// placeHydrogens positions the hydrogen atoms added to each atom, spread evenly around it on the side away from its other
+// bonds, at the median bond length
+func placeHydrogens(positions map[int][2]float64, bonds [][2]int, added map[int][]int) {
+	isAdded := make(map[int]bool)
+	for _, hs := range added {
+		for _, h := range hs {
+			isAdded[h] = true
+		}
+	}
+	neighbours := make(map[int][]int)
+	var lengths []float64
+	for _, b := range bonds {
+		if isAdded[b[0]] || isAdded[b[1]] {
+			continue
+		}
+		neighbours[b[0]] = append(neighbours[b[0]], b[1])
+		neighbours[b[1]] = append(neighbours[b[1]], b[0])
+		lengths = append(lengths, distance(positions[b[0]], positions[b[1]]))
+	}
+	length := median(lengths)
+
+	for v, hs := range added {
+		p := positions[v]
+		// the hydrogens are centred on the direction opposite the sum of the directions of the other bonds
+		var sum [2]float64
+		for _, u := range neighbours[v] {
+			d := distance(p, positions[u])
+			if d > 0 {
+				sum[0] += (positions[u][0] - p[0]) / d
+				sum[1] += (positions[u][1] - p[1]) / d
+			}
+		}
+		centre := math.Atan2(-sum[1], -sum[0])
+		// for bonds that cancel out, e.g. a linear atom, the hydrogens are to one side of them
+		if math.Hypot(sum[0], sum[1]) < 1e-6 && len(neighbours[v]) > 0 {
+			u := positions[neighbours[v][0]]
+			centre = math.Atan2(u[1]-p[1], u[0]-p[0]) + math.Pi/2
+		}
+		step := 2 * math.Pi / float64(len(neighbours[v])+len(hs))
+		for j, h := range hs {
+			angle := centre + (float64(j)-float64(len(hs)-1)/2)*step
+			positions[h] = [2]float64{p[0] + length*math.Cos(angle), p[1] + length*math.Sin(angle)}
+		}
+	}
+}
+
+// layout returns positions for the vertices of a graph with no coordinates, with edges about length 1. The vertices
+// start on a circle, in order, and are moved by a spring embedder (Fruchterman and Reingold): edges pull their vertices
+// together and all vertices push each other apart, with the moves limited by a temperature that falls each iteration
+// so the layout settles. It is deterministic, so the same graph is always drawn the same way
+func layout(vertices []int, edges [][2]int) map[int][2]float64 {
+	const iterations = 300
+	n := len(vertices)
+	position := make(map[int]int, n)
+	points := make([][2]float64, n)
+	radius := float64(n) / (2 * math.Pi)
+	for i, v := range vertices {
+		position[v] = i
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		points[i] = [2]float64{radius * math.Cos(angle), radius * math.Sin(angle)}
+	}
+
+	temperature := math.Max(radius, 1) / 2
+	for iteration := 0; iteration < iterations; iteration++ {
+		moves := make([][2]float64, n)
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				dx, dy := points[i][0]-points[j][0], points[i][1]-points[j][1]
+				d2 := dx*dx + dy*dy
+				if d2 < 1e-9 {
+					dx, dy, d2 = 1e-3*float64(j-i), 1e-3, 1e-6*float64((j-i)*(j-i))+1e-6
+				}
+				// repulsion k^2/d along the unit vector, with k = 1
+				moves[i][0] += dx / d2
+				moves[i][1] += dy / d2
+				moves[j][0] -= dx / d2
+				moves[j][1] -= dy / d2
+			}
+		}
+		for _, e := range edges {
+			i, j := position[e[0]], position[e[1]]
+			if i == j {
+				continue
+			}
+			dx, dy := points[i][0]-points[j][0], points[i][1]-points[j][1]
+			// attraction d^2/k along the unit vector
+			d := math.Hypot(dx, dy)
+			moves[i][0] -= dx * d
+			moves[i][1] -= dy * d
+			moves[j][0] += dx * d
+			moves[j][1] += dy * d
+		}
+		for i, move := range moves {
+			size := math.Hypot(move[0], move[1])
+			if size > temperature {
+				move[0], move[1] = move[0]*temperature/size, move[1]*temperature/size
+			}
+			points[i][0] += move[0]
+			points[i][1] += move[1]
+		}
+		temperature *= 0.98
+	}
+
+	positions := make(map[int][2]float64, n)
+	for i, v := range vertices {
+		positions[v] = points[i]
+	}
+	return positions
+}
+
+// distance returns the distance between two points
+func distance(p [2]float64, q [2]float64) float64 {
+	return math.Hypot(p[0]-q[0], p[1]-q[1])
+}
+
+// median returns the median of the positive values, or 1 if there are none
+func median(values []float64) float64 {
+	var positive []float64
+	for _, value := range values {
+		if value > 0 {
+			positive = append(positive, value)
+		}
+	}
+	if len(positive) == 0 {
+		return 1
+	}
+	sort.Float64s(positive)
+	return positive[len(positive)/2]
+}
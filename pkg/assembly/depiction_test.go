@@ -0,0 +1,166 @@
+package assembly
+
+import (
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMolDepiction(t *testing.T) {
+	tests := []struct {
+		molFile   string
+		hydrogens Hydrogens
+	}{
+		{"testdata/aspirin.mol", HydrogensStrip},
+		{"testdata/aspirin.mol", HydrogensAdd},
+		{"testdata/aspirin_with_H.mol", HydrogensStrip},
+		{"testdata/aspirin_with_H.mol", HydrogensKeep},
+		{"testdata/glycine_zwitterion.mol", HydrogensStrip},
+		{"testdata/glycine_zwitterion.mol", HydrogensAdd},
+		{"testdata/dichlorodifluorooctane_wedge.mol", HydrogensStrip},
+	}
+
+	for _, tt := range tests {
+		g, err := MolGraphFromFileColouring(tt.molFile, Colouring{Hydrogens: tt.hydrogens})
+		if err != nil {
+			t.Fatalf("MolGraphFromFileColouring %v %v error: %v", tt.molFile, tt.hydrogens, err)
+		}
+		depiction, err := MolDepictionFromFile(tt.molFile, tt.hydrogens)
+		if err != nil {
+			t.Errorf("MolDepictionFromFile %v %v error: %v", tt.molFile, tt.hydrogens, err)
+			continue
+		}
+		if len(depiction.Positions) != len(g.Vertices) || len(depiction.Labels) != len(g.Vertices) ||
+			len(depiction.BondTypes) != len(g.Edges) || len(depiction.BondStereo) != len(g.Edges) {
+			t.Errorf("MolDepictionFromFile %v %v has %v positions and %v bond types, graph has %v vertices and %v edges",
+				tt.molFile, tt.hydrogens, len(depiction.Positions), len(depiction.BondTypes), len(g.Vertices), len(g.Edges))
+			continue
+		}
+
+		// every atom has its own position, and the bonds are about the same length
+		lengths := make([]float64, len(g.Edges))
+		for i, e := range g.Edges {
+			lengths[i] = distance(depiction.Positions[e[0]], depiction.Positions[e[1]])
+		}
+		length := median(lengths)
+		for _, l := range lengths {
+			if l < 0.5*length || l > 2*length {
+				t.Errorf("MolDepictionFromFile %v %v has a bond of length %v, median %v", tt.molFile, tt.hydrogens, l, length)
+			}
+		}
+		for _, u := range g.Vertices {
+			for _, v := range g.Vertices {
+				if u < v && distance(depiction.Positions[u], depiction.Positions[v]) < 0.2*length {
+					t.Errorf("MolDepictionFromFile %v %v has atoms %v and %v on top of each other", tt.molFile,
+						tt.hydrogens, u, v)
+				}
+			}
+		}
+	}
+}
+
+func TestMolDepictionLabels(t *testing.T) {
+	// the hydrogens are in the labels, from the mol file or implicit, unless they are atoms in the graph
+	tests := []struct {
+		molFile   string
+		hydrogens Hydrogens
+		expected  []DepictionLabel
+	}{
+		{"testdata/glycine_zwitterion.mol", HydrogensStrip, []DepictionLabel{{}, {}, {"N", 3, 1, 0}, {"O", 0, 0, 0},
+			{"O", 0, -1, 0}}},
+		{"testdata/glycine_13C.mol", HydrogensStrip, []DepictionLabel{{}, {"C", 0, 0, 13}, {"N", 2, 0, 15},
+			{"O", 0, 0, 0}, {"O", 1, 0, 0}}},
+		{"testdata/formic_acid_with_H.mol", HydrogensKeep, []DepictionLabel{{}, {"O", 0, 0, 0}, {"O", 0, 0, 0},
+			{"H", 0, 0, 0}, {"H", 0, 0, 0}}},
+	}
+
+	for _, tt := range tests {
+		depiction, err := MolDepictionFromFile(tt.molFile, tt.hydrogens)
+		if err != nil {
+			t.Errorf("MolDepictionFromFile %v error: %v", tt.molFile, err)
+			continue
+		}
+		labels := make([]DepictionLabel, len(depiction.Labels))
+		for v, label := range depiction.Labels {
+			labels[v] = label
+		}
+		if !reflect.DeepEqual(labels, tt.expected) {
+			t.Errorf("MolDepictionFromFile %v labels %v, expected %v", tt.molFile, labels, tt.expected)
+		}
+	}
+}
+
+func TestSVGString(t *testing.T) {
+	g := MolColourGraph("testdata/aspirin.mol")
+	pathway := Assembly(g, 100, 100, "shortest")[0]
+	highlight, err := NewHighlight(&pathway, &g)
+	if err != nil {
+		t.Fatalf("NewHighlight error: %v", err)
+	}
+	depiction, err := MolDepictionFromFile("testdata/aspirin.mol", HydrogensStrip)
+	if err != nil {
+		t.Fatalf("MolDepictionFromFile error: %v", err)
+	}
+	square := NewGraphOnlyFromFile("testdata/graphs/square_coloured.txt")
+	squareDepiction := GraphDepiction(&square)
+	wedge := MolColourGraph("testdata/dichlorodifluorooctane_wedge.mol")
+	wedgeDepiction, err := MolDepictionFromFile("testdata/dichlorodifluorooctane_wedge.mol", HydrogensStrip)
+	if err != nil {
+		t.Fatalf("MolDepictionFromFile error: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		svg       string
+		fragments int
+		contains  []string
+	}{
+		{"aspirin", SVGString(&g, &depiction, &highlight), len(highlight.Occurrences), []string{"fragment 0, "}},
+		{"aspirin without highlight", SVGString(&g, &depiction, nil), 0, []string{"<tspan>O</tspan><tspan>H</tspan>"}},
+		{"square", SVGString(&square, &squareDepiction, nil), 0, []string{"<tspan>Red</tspan>"}},
+		{"wedge", SVGString(&wedge, &wedgeDepiction, nil), 0, []string{"<polygon"}},
+	}
+
+	for _, tt := range tests {
+		// the SVG is well formed XML, with a highlight group for each fragment
+		decoder := xml.NewDecoder(strings.NewReader(tt.svg))
+		for {
+			_, err := decoder.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("SVGString %v is not well formed: %v\n%v", tt.name, err, tt.svg)
+				break
+			}
+		}
+		if groups := strings.Count(tt.svg, "<title>fragment"); groups != tt.fragments {
+			t.Errorf("SVGString %v has %v fragments, expected %v", tt.name, groups, tt.fragments)
+		}
+		for _, s := range tt.contains {
+			if !strings.Contains(tt.svg, s) {
+				t.Errorf("SVGString %v should contain %v:\n%v", tt.name, s, tt.svg)
+			}
+		}
+	}
+}
+
+func TestSVGLabel(t *testing.T) {
+	tests := []struct {
+		label    DepictionLabel
+		expected string
+	}{
+		{DepictionLabel{Text: "O", Hydrogens: 1}, `<tspan>O</tspan><tspan>H</tspan>`},
+		{DepictionLabel{Text: "N", Hydrogens: 3, Charge: 1},
+			`<tspan>N</tspan><tspan>H</tspan><tspan dy="4" font-size="70%">3</tspan><tspan dy="-9" font-size="70%">+</tspan>`},
+		{DepictionLabel{Text: "C", Isotope: 13, Charge: -2},
+			`<tspan dy="-5" font-size="70%">13</tspan><tspan dy="5">C</tspan><tspan dy="-5" font-size="70%">2−</tspan>`},
+	}
+	for _, tt := range tests {
+		if label := svgLabel(tt.label); label != tt.expected {
+			t.Errorf("svgLabel %v gave %v, expected %v", tt.label, label, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package assembly
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Code relating to reading peptide and nucleic acid sequences from FASTA files, and converting them to graphs. Graphs
+// can be atom level, built from the residue templates below with hydrogens removed (as with mol files), or coarse grained,
+// with each residue as a vertex coloured by its residue name and directed backbone edges from the N to C terminus (protein)
+// or 5' to 3' end (DNA / RNA)
+
+// Sequence types
+const (
+	SequenceProtein = "protein"
+	SequenceDNA     = "dna"
+	SequenceRNA     = "rna"
+)
+
+// FastaRecord is a single sequence from a FASTA file. Name is the header line without the leading >
+type FastaRecord struct {
+	Name     string
+	Sequence string
+}
+
+// ParseFastaScanner reads FASTA records from a scanner. Sequence lines are joined, with whitespace removed, and ; comment
+// lines are ignored
+func ParseFastaScanner(scanner *bufio.Scanner) ([]FastaRecord, error) {
+	var records []FastaRecord
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, ">") {
+			records = append(records, FastaRecord{Name: strings.TrimSpace(line[1:])})
+			continue
+		}
+		if len(records) == 0 {
+			return nil, fmt.Errorf("fasta sequence %v before the first > header line", line)
+		}
+		records[len(records)-1].Sequence += strings.Join(strings.Fields(line), "")
+	}
+	return records, scanner.Err()
+}
+
+// ParseFastaFile reads all the FASTA records in a file
+func ParseFastaFile(filePath string) ([]FastaRecord, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseFastaScanner(bufio.NewScanner(file))
+}
+
+// ParseFastaString reads all the FASTA records in a string
+func ParseFastaString(fasta string) ([]FastaRecord, error) {
+	return ParseFastaScanner(bufio.NewScanner(strings.NewReader(fasta)))
+}
+
+// DetectSequenceType guesses the type of a sequence. Sequences of only A, C, G and T are DNA, those of only A, C, G and U
+// are RNA, and anything else is protein
+func DetectSequenceType(sequence string) string {
+	sequence = strings.ToUpper(sequence)
+	if strings.Trim(sequence, "ACGT") == "" {
+		return SequenceDNA
+	}
+	if strings.Trim(sequence, "ACGU") == "" {
+		return SequenceRNA
+	}
+	return SequenceProtein
+}
+
+// SequenceGraph returns the graph of a sequence. sequenceType is one of SequenceProtein, SequenceDNA or SequenceRNA, or
+// "" to use DetectSequenceType. If atomLevel is true the graph has atoms as vertices and bonds as edges, as from a mol file,
+// otherwise it is the coarse grained graph of residues. An error is returned for residues that are not in the library
+func SequenceGraph(sequence string, sequenceType string, atomLevel bool) (Graph, error) {
+	sequence = strings.TrimSuffix(strings.ToUpper(sequence), "*")
+	if sequence == "" {
+		return Graph{}, fmt.Errorf("empty sequence")
+	}
+	if sequenceType == "" {
+		sequenceType = DetectSequenceType(sequence)
+	}
+
+	var names []string
+	for i, r := range sequence {
+		name, ok := residueName(r, sequenceType)
+		if !ok {
+			return Graph{}, fmt.Errorf("unknown %v residue %q at position %v", sequenceType, r, i+1)
+		}
+		names = append(names, name)
+	}
+
+	if !atomLevel {
+		return coarseSequenceGraph(names), nil
+	}
+	if sequenceType == SequenceProtein {
+		return peptideGraph(sequence)
+	}
+	return nucleicAcidGraph(sequence, sequenceType)
+}
+
+// residueName returns the PDB residue name for a one letter code
+func residueName(code rune, sequenceType string) (string, bool) {
+	switch sequenceType {
+	case SequenceProtein:
+		template, ok := aminoAcidTemplates[code]
+		return template.name, ok
+	case SequenceDNA:
+		if strings.ContainsRune("ACGT", code) {
+			return "D" + string(code), true
+		}
+	case SequenceRNA:
+		if strings.ContainsRune("ACGU", code) {
+			return string(code), true
+		}
+	}
+	return "", false
+}
+
+// coarseSequenceGraph returns a directed path graph with one vertex per residue
+func coarseSequenceGraph(names []string) Graph {
+	vertices := make([]int, len(names))
+	var edges [][2]int
+	var edgeColours []string
+	for i := range names {
+		vertices[i] = i
+		if i > 0 {
+			edges = append(edges, [2]int{i - 1, i})
+			edgeColours = append(edgeColours, "backbone")
+		}
+	}
+	g := NewColourGraphFromIDs(vertices, edges, Colours.InternSlice(names), Colours.InternSlice(edgeColours))
+	g.Directed = true
+	return g
+}
+
+// residueTemplate is the heavy atoms of a residue, with bonds given as atom positions and mol file bond type
+type residueTemplate struct {
+	name  string
+	atoms []string
+	bonds [][3]int
+}
+
+// Amino acid positions of the backbone atoms. Side chain atoms are from position 4
+const (
+	aminoN = 0
+	aminoC = 2
+)
+
+// aminoAcidTemplates are the amino acids as residues in a chain. The C terminal residue also gets the OXT oxygen
+var aminoAcidTemplates = map[rune]residueTemplate{
+	'G': {"GLY", []string{"N", "C", "C", "O"}, nil},
+	'A': {"ALA", []string{"N", "C", "C", "O", "C"}, [][3]int{{1, 4, 1}}},
+	'S': {"SER", []string{"N", "C", "C", "O", "C", "O"}, [][3]int{{1, 4, 1}, {4, 5, 1}}},
+	'C': {"CYS", []string{"N", "C", "C", "O", "C", "S"}, [][3]int{{1, 4, 1}, {4, 5, 1}}},
+	'V': {"VAL", []string{"N", "C", "C", "O", "C", "C", "C"}, [][3]int{{1, 4, 1}, {4, 5, 1}, {4, 6, 1}}},
+	'T': {"THR", []string{"N", "C", "C", "O", "C", "O", "C"}, [][3]int{{1, 4, 1}, {4, 5, 1}, {4, 6, 1}}},
+	'L': {"LEU", []string{"N", "C", "C", "O", "C", "C", "C", "C"}, [][3]int{{1, 4, 1}, {4, 5, 1}, {5, 6, 1}, {5, 7, 1}}},
+	'I': {"ILE", []string{"N", "C", "C", "O", "C", "C", "C", "C"}, [][3]int{{1, 4, 1}, {4, 5, 1}, {4, 6, 1}, {5, 7, 1}}},
+	'M': {"MET", []string{"N", "C", "C", "O", "C", "C", "S", "C"}, [][3]int{{1, 4, 1}, {4, 5, 1}, {5, 6, 1}, {6, 7, 1}}},
+	'P': {"PRO", []string{"N", "C", "C", "O", "C", "C", "C"}, [][3]int{{1, 4, 1}, {4, 5, 1}, {5, 6, 1}, {6, 0, 1}}},
+	'F': {"PHE", []string{"N", "C", "C", "O", "C", "C", "C", "C", "C", "C", "C"},
+		[][3]int{{1, 4, 1}, {4, 5, 1}, {5, 6, 2}, {5, 7, 1}, {6, 8, 1}, {7, 9, 2}, {8, 10, 2}, {9, 10, 1}}},
+	'Y': {"TYR", []string{"N", "C", "C", "O", "C", "C", "C", "C", "C", "C", "C", "O"},
+		[][3]int{{1, 4, 1}, {4, 5, 1}, {5, 6, 2}, {5, 7, 1}, {6, 8, 1}, {7, 9, 2}, {8, 10, 2}, {9, 10, 1}, {10, 11, 1}}},
+	'W': {"TRP", []string{"N", "C", "C", "O", "C", "C", "C", "C", "N", "C", "C", "C", "C", "C"},
+		[][3]int{{1, 4, 1}, {4, 5, 1}, {5, 6, 2}, {6, 8, 1}, {8, 9, 1}, {9, 7, 2}, {7, 5, 1},
+			{9, 11, 1}, {11, 13, 2}, {13, 12, 1}, {12, 10, 2}, {10, 7, 1}}},
+	'H': {"HIS", []string{"N", "C", "C", "O", "C", "C", "N", "C", "C", "N"},
+		[][3]int{{1, 4, 1}, {4, 5, 1}, {5, 6, 1}, {6, 8, 1}, {8, 9, 2}, {9, 7, 1}, {7, 5, 2}}},
+	'D': {"ASP", []string{"N", "C", "C", "O", "C", "C", "O", "O"}, [][3]int{{1, 4, 1}, {4, 5, 1}, {5, 6, 2}, {5, 7, 1}}},
+	'E': {"GLU", []string{"N", "C", "C", "O", "C", "C", "C", "O", "O"},
+		[][3]int{{1, 4, 1}, {4, 5, 1}, {5, 6, 1}, {6, 7, 2}, {6, 8, 1}}},
+	'N': {"ASN", []string{"N", "C", "C", "O", "C", "C", "O", "N"}, [][3]int{{1, 4, 1}, {4, 5, 1}, {5, 6, 2}, {5, 7, 1}}},
+	'Q': {"GLN", []string{"N", "C", "C", "O", "C", "C", "C", "O", "N"},
+		[][3]int{{1, 4, 1}, {4, 5, 1}, {5, 6, 1}, {6, 7, 2}, {6, 8, 1}}},
+	'K': {"LYS", []string{"N", "C", "C", "O", "C", "C", "C", "C", "N"},
+		[][3]int{{1, 4, 1}, {4, 5, 1}, {5, 6, 1}, {6, 7, 1}, {7, 8, 1}}},
+	'R': {"ARG", []string{"N", "C", "C", "O", "C", "C", "C", "N", "C", "N", "N"},
+		[][3]int{{1, 4, 1}, {4, 5, 1}, {5, 6, 1}, {6, 7, 1}, {7, 8, 1}, {8, 9, 2}, {8, 10, 1}}},
+}
+
+// aminoAcidBackbone is the bonds shared by all amino acid templates: N-CA, CA-C and C=O
+var aminoAcidBackbone = [][3]int{{0, 1, 1}, {1, 2, 1}, {2, 3, 2}}
+
+// Sugar positions of the atoms that link nucleotides and bases. The sugar atoms are O5', C5', C4', O4', C3', O3', C2', C1',
+// and O2' for ribose
+const (
+	sugarO5 = 0
+	sugarO3 = 5
+	sugarC1 = 7
+)
+
+var deoxyriboseTemplate = residueTemplate{"deoxyribose", []string{"O", "C", "C", "O", "C", "O", "C", "C"},
+	[][3]int{{0, 1, 1}, {1, 2, 1}, {2, 3, 1}, {2, 4, 1}, {4, 5, 1}, {4, 6, 1}, {6, 7, 1}, {7, 3, 1}}}
+
+var riboseTemplate = residueTemplate{"ribose", []string{"O", "C", "C", "O", "C", "O", "C", "C", "O"},
+	[][3]int{{0, 1, 1}, {1, 2, 1}, {2, 3, 1}, {2, 4, 1}, {4, 5, 1}, {4, 6, 1}, {6, 7, 1}, {7, 3, 1}, {6, 8, 1}}}
+
+// baseTemplates are the nucleobases, each joined to C1' of the sugar through its first atom (N9 for purines, N1 for
+// pyrimidines)
+var baseTemplates = map[rune]residueTemplate{
+	'A': {"adenine", []string{"N", "C", "N", "C", "C", "N", "N", "C", "N", "C"},
+		[][3]int{{0, 1, 1}, {1, 2, 2}, {2, 3, 1}, {3, 9, 2}, {9, 0, 1}, {3, 4, 1}, {4, 6, 2}, {4, 5, 1}, {6, 7, 1}, {7, 8, 2}, {8, 9, 1}}},
+	'G': {"guanine", []string{"N", "C", "N", "C", "C", "O", "N", "C", "N", "N", "C"},
+		[][3]int{{0, 1, 1}, {1, 2, 2}, {2, 3, 1}, {3, 10, 2}, {10, 0, 1}, {3, 4, 1}, {4, 5, 2}, {4, 6, 1}, {6, 7, 1}, {7, 8, 1}, {7, 9, 2}, {9, 10, 1}}},
+	'C': {"cytosine", []string{"N", "C", "O", "N", "C", "N", "C", "C"},
+		[][3]int{{0, 1, 1}, {1, 2, 2}, {1, 3, 1}, {3, 4, 2}, {4, 5, 1}, {4, 6, 1}, {6, 7, 2}, {7, 0, 1}}},
+	'T': {"thymine", []string{"N", "C", "O", "N", "C", "O", "C", "C", "C"},
+		[][3]int{{0, 1, 1}, {1, 2, 2}, {1, 3, 1}, {3, 4, 1}, {4, 5, 2}, {4, 6, 1}, {6, 7, 1}, {6, 8, 2}, {8, 0, 1}}},
+	'U': {"uracil", []string{"N", "C", "O", "N", "C", "O", "C", "C"},
+		[][3]int{{0, 1, 1}, {1, 2, 2}, {1, 3, 1}, {3, 4, 1}, {4, 5, 2}, {4, 6, 1}, {6, 7, 2}, {7, 0, 1}}},
+}
+
+// atomGraphBuilder collects atoms and bonds as residues are added, in the form returned by ParseMolFile
+type atomGraphBuilder struct {
+	atoms     []string
+	bonds     [][2]int
+	bondTypes []int
+}
+
+// addTemplate adds the atoms and bonds of a template, returning the position of its first atom
+func (b *atomGraphBuilder) addTemplate(template residueTemplate, bonds [][3]int) int {
+	offset := len(b.atoms)
+	b.atoms = append(b.atoms, template.atoms...)
+	for _, bond := range bonds {
+		b.addBond(offset+bond[0], offset+bond[1], bond[2])
+	}
+	return offset
+}
+
+// addAtom adds an atom, returning its position
+func (b *atomGraphBuilder) addAtom(atom string) int {
+	b.atoms = append(b.atoms, atom)
+	return len(b.atoms) - 1
+}
+
+func (b *atomGraphBuilder) addBond(a1 int, a2 int, bondType int) {
+	b.bonds = append(b.bonds, [2]int{a1, a2})
+	b.bondTypes = append(b.bondTypes, bondType)
+}
+
+func (b *atomGraphBuilder) graph() (Graph, error) {
+	atomIndices := make([]int, len(b.atoms))
+	for i := range atomIndices {
+		atomIndices[i] = i
+	}
+	return molGraph(b.atoms, b.bonds, b.bondTypes, atomIndices)
+}
+
+// peptideGraph returns the atom level graph of a peptide, with peptide bonds from C of each residue to N of the next
+func peptideGraph(sequence string) (Graph, error) {
+	var b atomGraphBuilder
+	previousC := -1
+	for _, code := range sequence {
+		template := aminoAcidTemplates[code]
+		offset := b.addTemplate(template, append(append([][3]int{}, aminoAcidBackbone...), template.bonds...))
+		if previousC != -1 {
+			b.addBond(previousC, offset+aminoN, 1)
+		}
+		previousC = offset + aminoC
+	}
+	b.addBond(previousC, b.addAtom("O"), 1)
+	return b.graph()
+}
+
+// nucleicAcidGraph returns the atom level graph of DNA or RNA, with phosphates joining O3' of each nucleotide to O5' of
+// the next. The 5' and 3' ends are hydroxyls
+func nucleicAcidGraph(sequence string, sequenceType string) (Graph, error) {
+	sugar := deoxyriboseTemplate
+	if sequenceType == SequenceRNA {
+		sugar = riboseTemplate
+	}
+
+	var b atomGraphBuilder
+	previousO3 := -1
+	for _, code := range sequence {
+		base := baseTemplates[code]
+		sugarOffset := b.addTemplate(sugar, sugar.bonds)
+		baseOffset := b.addTemplate(base, base.bonds)
+		b.addBond(sugarOffset+sugarC1, baseOffset, 1)
+
+		if previousO3 != -1 {
+			p := b.addAtom("P")
+			b.addBond(previousO3, p, 1)
+			b.addBond(p, sugarOffset+sugarO5, 1)
+			b.addBond(p, b.addAtom("O"), 2)
+			b.addBond(p, b.addAtom("O"), 1)
+		}
+		previousO3 = sugarOffset + sugarO3
+	}
+	return b.graph()
+}
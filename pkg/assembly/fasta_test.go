@@ -0,0 +1,110 @@
+package assembly
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFastaFile(t *testing.T) {
+	records, err := ParseFastaFile("testdata/sequences.fasta")
+	expected := []FastaRecord{
+		{"peptide1 test peptide", "GGAGGAG"},
+		{"dna1", "ATGCATGC"},
+		{"rna1", "acgu"},
+	}
+	if err != nil || !reflect.DeepEqual(records, expected) {
+		t.Errorf("ParseFastaFile error, expected %v, got %v %v", expected, records, err)
+	}
+
+	if _, err := ParseFastaString("GGG\n>name\nGGG"); err == nil {
+		t.Errorf("ParseFastaString should give an error for a sequence before the first header")
+	}
+}
+
+func TestDetectSequenceType(t *testing.T) {
+	tests := []struct {
+		sequence     string
+		sequenceType string
+	}{
+		{"ATGC", SequenceDNA},
+		{"acgu", SequenceRNA},
+		{"MKTAYIAK", SequenceProtein},
+		{"GATTACA", SequenceDNA},
+	}
+
+	for _, tt := range tests {
+		if sequenceType := DetectSequenceType(tt.sequence); sequenceType != tt.sequenceType {
+			t.Errorf("DetectSequenceType error, sequence %v, expected %v, got %v", tt.sequence, tt.sequenceType, sequenceType)
+		}
+	}
+}
+
+func TestSequenceGraph(t *testing.T) {
+	tests := []struct {
+		sequence     string
+		sequenceType string
+		atoms        int
+		bonds        int
+	}{
+		// heavy atoms of the free amino acids, and nucleosides
+		{"G", SequenceProtein, 5, 4},
+		{"A", SequenceProtein, 6, 5},
+		{"P", SequenceProtein, 8, 8},
+		{"F", SequenceProtein, 12, 12},
+		{"Y", SequenceProtein, 13, 13},
+		{"W", SequenceProtein, 15, 16},
+		{"H", SequenceProtein, 11, 11},
+		{"R", SequenceProtein, 12, 11},
+		{"A", SequenceDNA, 18, 20},
+		{"G", SequenceDNA, 19, 21},
+		{"C", SequenceDNA, 16, 17},
+		{"T", SequenceDNA, 17, 18},
+		{"A", SequenceRNA, 19, 21},
+		{"U", SequenceRNA, 17, 18},
+		// a peptide bond adds no atoms once water is lost, and a phosphate adds 3
+		{"GG", SequenceProtein, 9, 8},
+		{"AT", SequenceDNA, 38, 42},
+	}
+
+	for _, tt := range tests {
+		g, err := SequenceGraph(tt.sequence, tt.sequenceType, true)
+		if err != nil || len(g.Vertices) != tt.atoms || len(g.Edges) != tt.bonds {
+			t.Errorf("SequenceGraph error, %v %v, expected %v atoms %v bonds, got %v %v %v",
+				tt.sequenceType, tt.sequence, tt.atoms, tt.bonds, len(g.Vertices), len(g.Edges), err)
+		}
+	}
+
+	coarse, err := SequenceGraph("GAG*", SequenceProtein, false)
+	expected := NewDirectedGraph([]int{0, 1, 2}, [][2]int{{0, 1}, {1, 2}}, []string{"GLY", "ALA", "GLY"}, []string{"backbone", "backbone"})
+	if err != nil || !GraphEquals(&coarse, &expected) {
+		t.Errorf("SequenceGraph coarse grained error, expected %v, got %v %v", expected, coarse, err)
+	}
+
+	if _, err := SequenceGraph("GXG", SequenceProtein, false); err == nil {
+		t.Errorf("SequenceGraph should give an error for an unknown residue")
+	}
+}
+
+func TestSequenceAssembly(t *testing.T) {
+	tests := []struct {
+		sequence      string
+		atomLevel     bool
+		assemblyIndex int
+	}{
+		// GLY-GLY can be joined to GLY-GLY
+		{"GGGGG", false, 2},
+		{"GAGAG", false, 2},
+		// each glycine residue is N-C-C=O, built in 3 steps and duplicated, then OXT is added
+		{"GG", true, 5},
+	}
+
+	for _, tt := range tests {
+		g, err := SequenceGraph(tt.sequence, SequenceProtein, tt.atomLevel)
+		check(err)
+		pathway := Assembly(g, 100, 100, "shortest")[0]
+		if assemblyIndex := AssemblyIndex(&pathway, &g); assemblyIndex != tt.assemblyIndex {
+			t.Errorf("Sequence assembly error, %v atom level %v, expected %v, got %v\n%v",
+				tt.sequence, tt.atomLevel, tt.assemblyIndex, assemblyIndex, PathwayString(&pathway))
+		}
+	}
+}
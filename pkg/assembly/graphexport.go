@@ -0,0 +1,297 @@
+package assembly
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Code for exporting graphs as Graphviz DOT or GraphML, e.g. to draw a pathway with Graphviz or Gephi. Each duplicated
+// fragment of the pathway is given a colour, and its two occurrences in the original graph are drawn in it, with the
+// components of the final remnant drawn dashed. The attributes can be read back with NewGraphFromFormatFile
+
+// highlightColours are the colours of the duplicated fragments, which are used in turn
+var highlightColours = []string{"#e41a1c", "#377eb8", "#4daf4a", "#984ea3", "#ff7f00", "#a65628", "#f781bf", "#1b9e77",
+	"#d95f02", "#7570b3", "#e7298a", "#66a61e", "#e6ab02", "#17becf", "#bcbd22", "#8c564b"}
+
+// remnantColour is the colour of the parts of the graph only in the remnant
+const remnantColour = "#999999"
+
+// highlightColour returns the colour of the duplicated fragment at position i in the pathway
+func highlightColour(i int) string {
+	return highlightColours[i%len(highlightColours)]
+}
+
+// Highlight is the occurrences of the duplicated fragments and the remnant of a pathway in its original graph, to
+// highlight when exporting the graph. See PathwayOccurrences
+type Highlight struct {
+	Occurrences [][2]FragmentOccurrence
+	Remnant     FragmentOccurrence
+}
+
+// NewHighlight returns the Highlight of a pathway on its original graph. An error is returned if the pathway is not
+// consistent with the original graph
+func NewHighlight(pathway *Pathway, originalGraph *Graph) (Highlight, error) {
+	occurrences, remnant, err := PathwayOccurrences(pathway, originalGraph, nil)
+	return Highlight{occurrences, remnant}, err
+}
+
+// elementHighlight is how a vertex or edge is highlighted: the fragment occurrences it is in, as e.g. "0 left", their
+// colours, and the remnant component it is in, or -1 if none
+type elementHighlight struct {
+	fragments []string
+	colours   []string
+	remnant   int
+}
+
+// elements returns the highlight of each vertex and edge of g. Remnant components are numbered from 0 in order of their
+// lowest edge
+func (highlight *Highlight) elements(g *Graph) (map[int]*elementHighlight, []*elementHighlight) {
+	vertices := make(map[int]*elementHighlight)
+	for _, v := range g.Vertices {
+		vertices[v] = &elementHighlight{remnant: -1}
+	}
+	edges := make([]*elementHighlight, len(g.Edges))
+	for i := range edges {
+		edges[i] = &elementHighlight{remnant: -1}
+	}
+	if highlight == nil {
+		return vertices, edges
+	}
+
+	for i, pair := range highlight.Occurrences {
+		for side, occurrence := range pair {
+			fragment := fmt.Sprintf("%v %v", i, []string{"left", "right"}[side])
+			for _, v := range occurrence.Vertices {
+				vertices[v].fragments = append(vertices[v].fragments, fragment)
+				vertices[v].colours = appendNew(vertices[v].colours, highlightColour(i))
+			}
+			for _, e := range occurrence.Edges {
+				edges[e].fragments = append(edges[e].fragments, fragment)
+				edges[e].colours = appendNew(edges[e].colours, highlightColour(i))
+			}
+		}
+	}
+
+	for e, component := range edgeComponents(g, highlight.Remnant.Edges) {
+		edges[e].remnant = component
+		for _, v := range g.Edges[e] {
+			vertices[v].remnant = component
+		}
+	}
+	return vertices, edges
+}
+
+// appendNew appends s to list if it isn't already in it
+func appendNew(list []string, s string) []string {
+	for _, existing := range list {
+		if existing == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// edgeComponents returns the connected component of each of the edges (positions in g.Edges), numbered from 0 in order
+// of their lowest edge
+func edgeComponents(g *Graph, edges []int) map[int]int {
+	sorted := append([]int{}, edges...)
+	sort.Ints(sorted)
+	incident := make(map[int][]int)
+	for _, e := range sorted {
+		incident[g.Edges[e][0]] = append(incident[g.Edges[e][0]], e)
+		incident[g.Edges[e][1]] = append(incident[g.Edges[e][1]], e)
+	}
+	components := make(map[int]int)
+	next := 0
+	for _, start := range sorted {
+		if _, ok := components[start]; ok {
+			continue
+		}
+		components[start] = next
+		queue := []int{start}
+		for len(queue) > 0 {
+			e := queue[0]
+			queue = queue[1:]
+			for _, v := range g.Edges[e] {
+				for _, f := range incident[v] {
+					if _, ok := components[f]; !ok {
+						components[f] = next
+						queue = append(queue, f)
+					}
+				}
+			}
+		}
+		next++
+	}
+	return components
+}
+
+// DOTString returns g as a Graphviz DOT graph called name. Colours are written as the attributes named by options, and
+// also as labels. If highlight is not nil, each vertex and edge in a fragment occurrence is drawn in the colours of its
+// fragments, with a fragments attribute listing the occurrences, and those in the remnant are dashed, with a remnant
+// attribute giving the remnant component
+func DOTString(g *Graph, name string, highlight *Highlight, options GraphFormatOptions) string {
+	vertices, edges := highlight.elements(g)
+	graphType, edgeOp := "graph", "--"
+	if g.Directed {
+		graphType, edgeOp = "digraph", "->"
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "%v %v {\n", graphType, dotQuote(name))
+	if highlight != nil {
+		for i := range highlight.Occurrences {
+			fmt.Fprintf(&out, "  // fragment %v: %v\n", i, highlightColour(i))
+		}
+	}
+
+	vertexColours := g.VertexColourNames()
+	for i, v := range g.Vertices {
+		attrs := [][2]string{}
+		if GraphIsVertexColoured(g) {
+			attrs = append(attrs, [2]string{"label", vertexColours[i]}, [2]string{options.VertexColour, vertexColours[i]})
+		}
+		attrs = append(attrs, vertices[v].dotAttributes(true)...)
+		fmt.Fprintf(&out, "  %v%v;\n", v, dotAttributeList(attrs))
+	}
+
+	edgeColours := g.EdgeColourNames()
+	for i, e := range g.Edges {
+		attrs := [][2]string{}
+		if GraphIsEdgeColoured(g) {
+			attrs = append(attrs, [2]string{"label", edgeColours[i]}, [2]string{options.EdgeColour, edgeColours[i]})
+		}
+		attrs = append(attrs, edges[i].dotAttributes(false)...)
+		fmt.Fprintf(&out, "  %v %v %v%v;\n", e[0], edgeOp, e[1], dotAttributeList(attrs))
+	}
+	out.WriteString("}\n")
+	return out.String()
+}
+
+// dotAttributes returns the DOT attributes that draw the highlight of a vertex or edge. Vertices in several fragments
+// are drawn as wedges, and edges as parallel lines, one for each colour
+func (h *elementHighlight) dotAttributes(vertex bool) [][2]string {
+	var attrs [][2]string
+	var styles []string
+	if len(h.colours) > 0 {
+		attrs = append(attrs, [2]string{"fragments", strings.Join(h.fragments, ",")})
+		if vertex {
+			fill := "filled"
+			if len(h.colours) > 1 {
+				fill = "wedged"
+			}
+			styles = append(styles, fill)
+			attrs = append(attrs, [2]string{"fillcolor", strings.Join(h.colours, ":")})
+		} else {
+			attrs = append(attrs, [2]string{"color", strings.Join(h.colours, ":")}, [2]string{"penwidth", "2"})
+		}
+	}
+	if h.remnant != -1 {
+		attrs = append(attrs, [2]string{"remnant", fmt.Sprint(h.remnant)})
+		styles = append(styles, "dashed")
+		if len(h.colours) == 0 && !vertex {
+			attrs = append(attrs, [2]string{"color", remnantColour})
+		}
+	}
+	if len(styles) > 0 {
+		attrs = append(attrs, [2]string{"style", strings.Join(styles, ",")})
+	}
+	return attrs
+}
+
+// dotAttributeList returns the attributes as a DOT attribute list, or nothing if there are none
+func dotAttributeList(attrs [][2]string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	list := make([]string, len(attrs))
+	for i, a := range attrs {
+		list[i] = dotQuote(a[0]) + "=" + dotQuote(a[1])
+	}
+	return " [" + strings.Join(list, ", ") + "]"
+}
+
+// dotQuote returns s as a quoted DOT ID
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// GraphMLString returns g as a GraphML graph with id name. Colours are written as the attributes named by options. If
+// highlight is not nil, each vertex and edge also has the attributes fragments (the fragment occurrences it is in, e.g.
+// "0 left,1 right"), highlight (the colour of the first of them) and remnant (the remnant component it is in, or -1)
+func GraphMLString(g *Graph, name string, highlight *Highlight, options GraphFormatOptions) string {
+	vertices, edges := highlight.elements(g)
+
+	var out bytes.Buffer
+	out.WriteString(xml.Header)
+	out.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	type key struct {
+		id, kind, name, attrType string
+	}
+	keys := []key{}
+	if GraphIsVertexColoured(g) {
+		keys = append(keys, key{"vc", "node", options.VertexColour, "string"})
+	}
+	if GraphIsEdgeColoured(g) {
+		keys = append(keys, key{"ec", "edge", options.EdgeColour, "string"})
+	}
+	if highlight != nil {
+		for _, kind := range []string{"node", "edge"} {
+			prefix := kind[:1]
+			keys = append(keys, key{prefix + "f", kind, "fragments", "string"}, key{prefix + "h", kind, "highlight", "string"},
+				key{prefix + "r", kind, "remnant", "int"})
+		}
+	}
+	for _, k := range keys {
+		fmt.Fprintf(&out, `  <key id="%v" for="%v" attr.name="%v" attr.type="%v"/>`+"\n", k.id, k.kind, xmlEscape(k.name), k.attrType)
+	}
+
+	edgeDefault := "undirected"
+	if g.Directed {
+		edgeDefault = "directed"
+	}
+	fmt.Fprintf(&out, `  <graph id="%v" edgedefault="%v">`+"\n", xmlEscape(name), edgeDefault)
+	data := func(key string, value string) string {
+		return fmt.Sprintf(`<data key="%v">%v</data>`, key, xmlEscape(value))
+	}
+	highlightData := func(prefix string, h *elementHighlight) string {
+		if highlight == nil {
+			return ""
+		}
+		colour := remnantColour
+		if len(h.colours) > 0 {
+			colour = h.colours[0]
+		}
+		return data(prefix+"f", strings.Join(h.fragments, ",")) + data(prefix+"h", colour) +
+			data(prefix+"r", fmt.Sprint(h.remnant))
+	}
+
+	vertexColours := g.VertexColourNames()
+	for i, v := range g.Vertices {
+		colour := ""
+		if GraphIsVertexColoured(g) {
+			colour = data("vc", vertexColours[i])
+		}
+		fmt.Fprintf(&out, `    <node id="%v">%v%v</node>`+"\n", v, colour, highlightData("n", vertices[v]))
+	}
+	edgeColours := g.EdgeColourNames()
+	for i, e := range g.Edges {
+		colour := ""
+		if GraphIsEdgeColoured(g) {
+			colour = data("ec", edgeColours[i])
+		}
+		fmt.Fprintf(&out, `    <edge source="%v" target="%v">%v%v</edge>`+"\n", e[0], e[1], colour, highlightData("e", edges[i]))
+	}
+	out.WriteString("  </graph>\n</graphml>\n")
+	return out.String()
+}
+
+// xmlEscape returns s escaped for XML text and attribute values
+func xmlEscape(s string) string {
+	var escaped bytes.Buffer
+	check(xml.EscapeText(&escaped, []byte(s)))
+	return escaped.String()
+}
@@ -0,0 +1,80 @@
+package assembly
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportRoundTrip(t *testing.T) {
+	square := NewGraphOnlyFromFile("testdata/graphs/square_coloured.txt")
+	directed := NewDirectedGraph([]int{1, 2, 3}, [][2]int{{1, 2}, {2, 3}, {3, 1}}, []string{"a b", `"q"`, "<c>"}, []string{})
+	multigraph := NewMultigraph([]int{1, 2}, [][2]int{{1, 2}, {1, 2}, {2, 2}}, []string{}, []string{"x", "y", "z"})
+	for _, g := range []Graph{square, directed, multigraph} {
+		options := DefaultGraphFormatOptions
+		options.Multigraph = g.Multigraph
+		for format, exported := range map[string]string{
+			FormatDOT:     DOTString(&g, "round trip", nil, DefaultGraphFormatOptions),
+			FormatGraphML: GraphMLString(&g, "round trip", nil, DefaultGraphFormatOptions),
+		} {
+			read, name, err := NewGraphFromFormatReader(strings.NewReader(exported), format, options)
+			if err != nil || name != "round trip" || !GraphEquals(&read, &g) || read.Directed != g.Directed {
+				t.Errorf("%v export of %v read back as %v, %v:\n%v", format, GraphPrint(&g), GraphPrint(&read), err, exported)
+			}
+		}
+	}
+}
+
+func TestExportHighlight(t *testing.T) {
+	g := MolColourGraph("testdata/aspirin.mol")
+	pathway := Assembly(g, 100, 100, "shortest")[0]
+	highlight, err := NewHighlight(&pathway, &g)
+	if err != nil {
+		t.Fatalf("NewHighlight error: %v", err)
+	}
+	if len(highlight.Occurrences) == 0 {
+		t.Fatalf("aspirin pathway should have duplicates")
+	}
+
+	// every edge of the GraphML export has the attributes, so they can be read back as colours
+	exported := GraphMLString(&g, "aspirin", &highlight, DefaultGraphFormatOptions)
+	fragments, _, err := NewGraphFromFormatReader(strings.NewReader(exported), FormatGraphML, GraphFormatOptions{EdgeColour: "fragments"})
+	if err != nil {
+		t.Fatalf("GraphML export fragments read back error: %v", err)
+	}
+	remnant, _, err := NewGraphFromFormatReader(strings.NewReader(exported), FormatGraphML, GraphFormatOptions{EdgeColour: "remnant"})
+	if err != nil {
+		t.Fatalf("GraphML export remnant read back error: %v", err)
+	}
+	leftEdges, remnantEdges := 0, 0
+	for i, colour := range fragments.EdgeColourNames() {
+		if strings.Contains(colour, "0 left") {
+			leftEdges++
+		}
+		if remnant.EdgeColourNames()[i] != "-1" {
+			remnantEdges++
+		}
+	}
+	if leftEdges != len(highlight.Occurrences[0][0].Edges) || remnantEdges != len(highlight.Remnant.Edges) {
+		t.Errorf("GraphML export has %v edges in fragment 0 left and %v in the remnant, expected %v and %v",
+			leftEdges, remnantEdges, len(highlight.Occurrences[0][0].Edges), len(highlight.Remnant.Edges))
+	}
+
+	// only highlighted edges of the DOT export have the attributes
+	exported = DOTString(&g, "aspirin", &highlight, DefaultGraphFormatOptions)
+	if !strings.Contains(exported, `"fragments"="0 left`) || !strings.Contains(exported, `"remnant"="0"`) ||
+		!strings.Contains(exported, highlightColour(0)) {
+		t.Errorf("DOT export should highlight fragment 0 and the remnant:\n%v", exported)
+	}
+}
+
+func TestEdgeComponents(t *testing.T) {
+	g := NewGraphOnlyFromFile("testdata/graphs/chain16.txt")
+	components := edgeComponents(&g, []int{7, 0, 1, 5, 6})
+	expected := map[int]int{0: 0, 1: 0, 5: 1, 6: 1, 7: 1}
+	for e, component := range expected {
+		if components[e] != component {
+			t.Errorf("edgeComponents expected %v, got %v", expected, components)
+			break
+		}
+	}
+}
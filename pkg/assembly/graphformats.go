@@ -0,0 +1,750 @@
+package assembly
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Code for reading graphs in the interchange formats written by network tools such as NetworkX, Gephi and Graphviz:
+// GraphML, GML and DOT. Vertex and edge colours are read from attributes with configurable names. Vertex ids that are
+// all integers are kept as the vertex labels, otherwise vertices are numbered from 0 in the order they first appear
+
+// Graph file formats, other than mol files and the text format of NewGraphFromScanner
+const (
+	FormatGraphML = "graphml"
+	FormatGML     = "gml"
+	FormatDOT     = "dot"
+)
+
+// GraphFormatOptions are the options for reading a graph file format. VertexColour and EdgeColour are the names of the
+// attributes the colours are read from; the graph is uncoloured if no vertices (or edges) have the attribute, and it is
+// an error if only some do. The graph is directed if Directed is set or the file says so, and may have parallel edges
+// and self-loops if Multigraph is set
+type GraphFormatOptions struct {
+	VertexColour string
+	EdgeColour   string
+	Multigraph   bool
+	Directed     bool
+}
+
+// DefaultGraphFormatOptions are the options used by the command line tool unless others are given
+var DefaultGraphFormatOptions = GraphFormatOptions{VertexColour: "colour", EdgeColour: "colour"}
+
+// NewGraphFromFormatFile returns a graph and its name from a file in a graph file format (FormatGraphML, FormatGML or
+// FormatDOT), along with a ValidationError if the graph has any issues
+func NewGraphFromFormatFile(filePath string, format string, options GraphFormatOptions) (Graph, string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return Graph{}, "", err
+	}
+	defer f.Close()
+	return NewGraphFromFormatReader(f, format, options)
+}
+
+// NewGraphFromFormatReader returns a graph and its name from a reader in a graph file format, see NewGraphFromFormatFile
+func NewGraphFromFormatReader(r io.Reader, format string, options GraphFormatOptions) (Graph, string, error) {
+	var parsed formatGraph
+	var err error
+	switch format {
+	case FormatGraphML:
+		parsed, err = parseGraphML(r)
+	case FormatGML:
+		parsed, err = parseGML(r)
+	case FormatDOT:
+		parsed, err = parseDOT(r)
+	default:
+		err = fmt.Errorf("unknown graph format %v, must be %v, %v or %v", format, FormatGraphML, FormatGML, FormatDOT)
+	}
+	if err != nil {
+		return Graph{}, "", err
+	}
+	g, err := parsed.graph(options)
+	return g, parsed.name, err
+}
+
+// formatGraph is a graph as read from a graph file format, with vertices named by their ids in the file and the
+// attributes of each vertex and edge
+type formatGraph struct {
+	name      string
+	directed  bool
+	nodes     []string
+	nodeAttrs map[string]map[string]string
+	edges     [][2]string
+	edgeAttrs []map[string]string
+}
+
+// addNode adds a node if it isn't already in the graph, with a copy of defaults as its attributes, and returns its
+// attributes
+func (f *formatGraph) addNode(id string, defaults map[string]string) map[string]string {
+	if f.nodeAttrs == nil {
+		f.nodeAttrs = make(map[string]map[string]string)
+	}
+	if attrs, ok := f.nodeAttrs[id]; ok {
+		return attrs
+	}
+	attrs := make(map[string]string)
+	for key, value := range defaults {
+		attrs[key] = value
+	}
+	f.nodes = append(f.nodes, id)
+	f.nodeAttrs[id] = attrs
+	return attrs
+}
+
+// addEdge adds an edge with attributes attrs, and its nodes if they aren't in the graph
+func (f *formatGraph) addEdge(source string, target string, attrs map[string]string) {
+	f.addNode(source, nil)
+	f.addNode(target, nil)
+	f.edges = append(f.edges, [2]string{source, target})
+	f.edgeAttrs = append(f.edgeAttrs, attrs)
+}
+
+// graph returns the Graph, with colours from the attributes chosen by options
+func (f *formatGraph) graph(options GraphFormatOptions) (Graph, error) {
+	// keep integer ids if they are all integers and distinct, otherwise number the vertices in order
+	vertices := make([]int, len(f.nodes))
+	labels := make(map[string]int)
+	integerIDs := true
+	seen := make(map[int]bool)
+	for i, id := range f.nodes {
+		v, err := strconv.Atoi(id)
+		if err != nil || seen[v] {
+			integerIDs = false
+			break
+		}
+		seen[v] = true
+		vertices[i] = v
+	}
+	for i, id := range f.nodes {
+		if !integerIDs {
+			vertices[i] = i
+		}
+		labels[id] = vertices[i]
+	}
+
+	edges := make([][2]int, len(f.edges))
+	for i, e := range f.edges {
+		edges[i] = [2]int{labels[e[0]], labels[e[1]]}
+	}
+
+	nodeAttrs := make([]map[string]string, len(f.nodes))
+	for i, id := range f.nodes {
+		nodeAttrs[i] = f.nodeAttrs[id]
+	}
+	vertexColours, err := attributeColours(nodeAttrs, options.VertexColour, "vertex", "vertices")
+	if err != nil {
+		return Graph{}, err
+	}
+	edgeColours, err := attributeColours(f.edgeAttrs, options.EdgeColour, "edge", "edges")
+	if err != nil {
+		return Graph{}, err
+	}
+
+	g := NewColourGraphFromIDs(vertices, edges, Colours.InternSlice(vertexColours), Colours.InternSlice(edgeColours))
+	g.Multigraph = options.Multigraph
+	g.Directed = options.Directed || f.directed
+	return g, ValidateGraph(&g)
+}
+
+// attributeColours returns the values of the attribute name, or no colours if none have it. kind is vertex or edge,
+// and kinds its plural, for the error if only some have it
+func attributeColours(attrs []map[string]string, name string, kind string, kinds string) ([]string, error) {
+	colours := []string{}
+	for _, a := range attrs {
+		if colour, ok := a[name]; ok {
+			colours = append(colours, colour)
+		}
+	}
+	if len(colours) != 0 && len(colours) != len(attrs) {
+		return nil, fmt.Errorf("%v colour attribute %v is on %v of %v %v", kind, name, len(colours), len(attrs), kinds)
+	}
+	return colours, nil
+}
+
+// GraphML
+
+type graphMLFile struct {
+	Keys   []graphMLKey   `xml:"key"`
+	Graphs []graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID      string  `xml:"id,attr"`
+	For     string  `xml:"for,attr"`
+	Name    string  `xml:"attr.name,attr"`
+	Default *string `xml:"default"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// parseGraphML reads the first graph of a GraphML file. Attributes are named by the attr.name of their key, as written
+// by NetworkX and Gephi, or by the key id if it has none, and keys with a default give it to nodes and edges without them
+func parseGraphML(r io.Reader) (formatGraph, error) {
+	var file graphMLFile
+	if err := xml.NewDecoder(r).Decode(&file); err != nil {
+		return formatGraph{}, fmt.Errorf("bad GraphML: %v", err)
+	}
+	if len(file.Graphs) == 0 {
+		return formatGraph{}, fmt.Errorf("bad GraphML: no graph element")
+	}
+	graph := file.Graphs[0]
+
+	// the attribute names and defaults of the keys for nodes and edges
+	names := map[string]map[string]string{"node": {}, "edge": {}}
+	defaults := map[string]map[string]string{"node": {}, "edge": {}}
+	for _, key := range file.Keys {
+		name := key.Name
+		if name == "" {
+			name = key.ID
+		}
+		for _, kind := range []string{"node", "edge"} {
+			if key.For == kind || key.For == "all" || key.For == "" {
+				names[kind][key.ID] = name
+				if key.Default != nil {
+					defaults[kind][name] = strings.TrimSpace(*key.Default)
+				}
+			}
+		}
+	}
+	attributes := func(kind string, data []graphMLData) map[string]string {
+		attrs := make(map[string]string)
+		for name, value := range defaults[kind] {
+			attrs[name] = value
+		}
+		for _, d := range data {
+			name, ok := names[kind][d.Key]
+			if !ok {
+				name = d.Key
+			}
+			attrs[name] = strings.TrimSpace(d.Value)
+		}
+		return attrs
+	}
+
+	f := formatGraph{name: graph.ID, directed: graph.EdgeDefault == "directed"}
+	for _, node := range graph.Nodes {
+		attrs := f.addNode(node.ID, nil)
+		for name, value := range attributes("node", node.Data) {
+			attrs[name] = value
+		}
+	}
+	for _, edge := range graph.Edges {
+		f.addEdge(edge.Source, edge.Target, attributes("edge", edge.Data))
+	}
+	return f, nil
+}
+
+// GML
+
+// gmlToken is a token of a GML file: a key or number, a quoted string, or [ or ]
+type gmlToken struct {
+	text   string
+	quoted bool
+}
+
+// gmlItem is a key value pair of a GML file, where the value is either text or a list of items
+type gmlItem struct {
+	key   string
+	value string
+	list  []gmlItem
+}
+
+// parseGML reads the graph of a GML file, as written by NetworkX and Gephi. Nodes are named by their id, and their other
+// keys with text values are attributes, as are those of edges
+func parseGML(r io.Reader) (formatGraph, error) {
+	text, err := ioutil.ReadAll(r)
+	if err != nil {
+		return formatGraph{}, err
+	}
+	tokens, err := gmlTokens(string(text))
+	if err != nil {
+		return formatGraph{}, err
+	}
+	items, pos, err := gmlList(tokens, 0)
+	if err != nil {
+		return formatGraph{}, err
+	}
+	if pos != len(tokens) {
+		return formatGraph{}, fmt.Errorf("bad GML: unexpected ]")
+	}
+
+	var graph []gmlItem
+	found := false
+	for _, item := range items {
+		if item.key == "graph" && item.list != nil {
+			graph, found = item.list, true
+			break
+		}
+	}
+	if !found {
+		return formatGraph{}, fmt.Errorf("bad GML: no graph list")
+	}
+
+	var f formatGraph
+	for _, item := range graph {
+		switch {
+		case item.key == "directed":
+			f.directed = item.value == "1"
+		case item.key == "label" || item.key == "name":
+			if item.list == nil && f.name == "" {
+				f.name = item.value
+			}
+		case item.key == "node" && item.list != nil:
+			attrs := gmlAttributes(item.list)
+			id, ok := attrs["id"]
+			if !ok {
+				return formatGraph{}, fmt.Errorf("bad GML: node without an id")
+			}
+			nodeAttrs := f.addNode(id, nil)
+			for name, value := range attrs {
+				nodeAttrs[name] = value
+			}
+		case item.key == "edge" && item.list != nil:
+			attrs := gmlAttributes(item.list)
+			source, okSource := attrs["source"]
+			target, okTarget := attrs["target"]
+			if !okSource || !okTarget {
+				return formatGraph{}, fmt.Errorf("bad GML: edge without a source and target")
+			}
+			f.addEdge(source, target, attrs)
+		}
+	}
+	return f, nil
+}
+
+// gmlAttributes returns the keys with text values of a GML list
+func gmlAttributes(items []gmlItem) map[string]string {
+	attrs := make(map[string]string)
+	for _, item := range items {
+		if item.list == nil {
+			attrs[item.key] = item.value
+		}
+	}
+	return attrs
+}
+
+// gmlTokens splits GML text into tokens, without comments (lines starting with #)
+func gmlTokens(text string) ([]gmlToken, error) {
+	var tokens []gmlToken
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '#':
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		case c == '[' || c == ']':
+			tokens = append(tokens, gmlToken{text: string(c)})
+			i++
+		case c == '"':
+			end := strings.IndexByte(text[i+1:], '"')
+			if end == -1 {
+				return nil, fmt.Errorf("bad GML: unterminated string")
+			}
+			value := strings.NewReplacer("&quot;", `"`, "&amp;", "&").Replace(text[i+1 : i+1+end])
+			tokens = append(tokens, gmlToken{text: value, quoted: true})
+			i += end + 2
+		default:
+			start := i
+			for i < len(text) && !unicode.IsSpace(rune(text[i])) && text[i] != '[' && text[i] != ']' && text[i] != '"' {
+				i++
+			}
+			tokens = append(tokens, gmlToken{text: text[start:i]})
+		}
+	}
+	return tokens, nil
+}
+
+// gmlList reads key value pairs from pos up to the ] that ends the list, or the end of the tokens, and returns them and
+// the position after the ]
+func gmlList(tokens []gmlToken, pos int) ([]gmlItem, int, error) {
+	items := []gmlItem{}
+	for pos < len(tokens) {
+		key := tokens[pos]
+		if key.text == "]" && !key.quoted {
+			return items, pos + 1, nil
+		}
+		if key.quoted || key.text == "[" || pos+1 == len(tokens) {
+			return nil, pos, fmt.Errorf("bad GML: expected a key and value at %q", key.text)
+		}
+		value := tokens[pos+1]
+		switch {
+		case value.text == "[" && !value.quoted:
+			list, next, err := gmlList(tokens, pos+2)
+			if err != nil {
+				return nil, next, err
+			}
+			items = append(items, gmlItem{key: key.text, list: list})
+			pos = next
+		case value.text == "]" && !value.quoted:
+			return nil, pos, fmt.Errorf("bad GML: key %v has no value", key.text)
+		default:
+			items = append(items, gmlItem{key: key.text, value: value.text})
+			pos += 2
+		}
+	}
+	return items, pos, nil
+}
+
+// DOT
+
+// dotToken is a token of a DOT file: an ID, which may have been quoted, or punctuation
+type dotToken struct {
+	text   string
+	id     bool
+	quoted bool
+}
+
+// dotParser reads the statements of a DOT graph
+type dotParser struct {
+	tokens []dotToken
+	pos    int
+	graph  formatGraph
+}
+
+// parseDOT reads the first graph of a Graphviz DOT file. Attributes are those of the node and edge statements, after
+// any node and edge defaults. Edges to and from subgraphs are made for every node in them, and ports are ignored
+func parseDOT(r io.Reader) (formatGraph, error) {
+	text, err := ioutil.ReadAll(r)
+	if err != nil {
+		return formatGraph{}, err
+	}
+	tokens, err := dotTokens(string(text))
+	if err != nil {
+		return formatGraph{}, err
+	}
+	p := dotParser{tokens: tokens}
+
+	if p.keyword("strict") {
+		p.pos++
+	}
+	switch {
+	case p.keyword("graph"):
+	case p.keyword("digraph"):
+		p.graph.directed = true
+	default:
+		return formatGraph{}, p.errorf("expected graph or digraph")
+	}
+	p.pos++
+	if p.peek().id {
+		p.graph.name = p.next().text
+	}
+	if !p.punctuation("{") {
+		return formatGraph{}, p.errorf("expected {")
+	}
+	p.pos++
+	if _, err := p.statements(map[string]string{}, map[string]string{}); err != nil {
+		return formatGraph{}, err
+	}
+	return p.graph, nil
+}
+
+// peek returns the next token, or an empty one at the end
+func (p *dotParser) peek() dotToken {
+	if p.pos >= len(p.tokens) {
+		return dotToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+// next returns the next token and moves past it
+func (p *dotParser) next() dotToken {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+// keyword returns true if the next token is an unquoted keyword, which is case insensitive
+func (p *dotParser) keyword(keyword string) bool {
+	token := p.peek()
+	return token.id && !token.quoted && strings.EqualFold(token.text, keyword)
+}
+
+// punctuation returns true if the next token is the punctuation s
+func (p *dotParser) punctuation(s string) bool {
+	token := p.peek()
+	return !token.id && token.text == s
+}
+
+// errorf returns an error at the next token
+func (p *dotParser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("bad DOT: %v at token %v %q", fmt.Sprintf(format, a...), p.pos, p.peek().text)
+}
+
+// statements reads statements up to and including the } that ends the graph or subgraph, with the node and edge
+// attribute defaults of the enclosing graph, and returns the nodes in it
+func (p *dotParser) statements(nodeDefaults map[string]string, edgeDefaults map[string]string) ([]string, error) {
+	nodeDefaults, edgeDefaults = copyAttributes(nodeDefaults), copyAttributes(edgeDefaults)
+	var nodes []string
+	for {
+		switch {
+		case p.pos >= len(p.tokens):
+			return nil, p.errorf("expected }")
+		case p.punctuation("}"):
+			p.pos++
+			return nodes, nil
+		case p.punctuation(";") || p.punctuation(","):
+			p.pos++
+			continue
+		}
+
+		// attribute defaults
+		if p.keyword("node") || p.keyword("edge") || p.keyword("graph") {
+			kind := strings.ToLower(p.next().text)
+			attrs, err := p.attributes()
+			if err != nil {
+				return nil, err
+			}
+			switch kind {
+			case "node":
+				nodeDefaults = mergeAttributes(nodeDefaults, attrs)
+			case "edge":
+				edgeDefaults = mergeAttributes(edgeDefaults, attrs)
+			}
+			continue
+		}
+
+		// graph attribute
+		if p.peek().id && p.pos+1 < len(p.tokens) && !p.tokens[p.pos+1].id && p.tokens[p.pos+1].text == "=" {
+			p.pos += 2
+			if !p.next().id {
+				return nil, p.errorf("expected an attribute value")
+			}
+			continue
+		}
+
+		// node or edge statement
+		var endpoints [][]string
+		for {
+			endpoint, err := p.endpoint(nodeDefaults, edgeDefaults)
+			if err != nil {
+				return nil, err
+			}
+			endpoints = append(endpoints, endpoint)
+			nodes = append(nodes, endpoint...)
+			if !p.punctuation("->") && !p.punctuation("--") {
+				break
+			}
+			p.pos++
+		}
+		attrs, err := p.attributes()
+		if err != nil {
+			return nil, err
+		}
+		if len(endpoints) == 1 {
+			// a node statement, or a subgraph
+			for _, node := range endpoints[0] {
+				mergeAttributes(p.graph.nodeAttrs[node], attrs)
+			}
+			continue
+		}
+		for i := 1; i < len(endpoints); i++ {
+			for _, source := range endpoints[i-1] {
+				for _, target := range endpoints[i] {
+					p.graph.addEdge(source, target, mergeAttributes(copyAttributes(edgeDefaults), attrs))
+				}
+			}
+		}
+	}
+}
+
+// endpoint reads a node id, with an optional port, or a subgraph, and returns the nodes in it
+func (p *dotParser) endpoint(nodeDefaults map[string]string, edgeDefaults map[string]string) ([]string, error) {
+	if p.keyword("subgraph") {
+		p.pos++
+		if p.peek().id {
+			p.pos++
+		}
+		if !p.punctuation("{") {
+			return nil, p.errorf("expected {")
+		}
+	}
+	if p.punctuation("{") {
+		p.pos++
+		return p.statements(nodeDefaults, edgeDefaults)
+	}
+	if !p.peek().id {
+		return nil, p.errorf("expected a node id")
+	}
+	node := p.next().text
+	p.graph.addNode(node, nodeDefaults)
+	for p.punctuation(":") {
+		p.pos++
+		if !p.next().id {
+			return nil, p.errorf("expected a port")
+		}
+	}
+	return []string{node}, nil
+}
+
+// attributes reads any number of attribute lists, e.g. [colour=red, label="a b"][weight=2], and returns the attributes
+func (p *dotParser) attributes() (map[string]string, error) {
+	attrs := make(map[string]string)
+	for p.punctuation("[") {
+		p.pos++
+		for !p.punctuation("]") {
+			if p.punctuation(";") || p.punctuation(",") {
+				p.pos++
+				continue
+			}
+			name := p.next()
+			if !name.id {
+				return nil, p.errorf("expected an attribute name")
+			}
+			value := "true"
+			if p.punctuation("=") {
+				p.pos++
+				valueToken := p.next()
+				if !valueToken.id {
+					return nil, p.errorf("expected an attribute value")
+				}
+				value = valueToken.text
+			}
+			attrs[name.text] = value
+		}
+		p.pos++
+	}
+	return attrs, nil
+}
+
+// copyAttributes returns a copy of attributes
+func copyAttributes(attrs map[string]string) map[string]string {
+	copied := make(map[string]string)
+	for name, value := range attrs {
+		copied[name] = value
+	}
+	return copied
+}
+
+// mergeAttributes sets the attributes of from in to, and returns it
+func mergeAttributes(to map[string]string, from map[string]string) map[string]string {
+	if to == nil {
+		to = make(map[string]string)
+	}
+	for name, value := range from {
+		to[name] = value
+	}
+	return to
+}
+
+// dotTokens splits DOT text into tokens, without comments. Quoted strings have their escaped quotes and line
+// continuations removed, and HTML strings are kept as they are
+func dotTokens(text string) ([]dotToken, error) {
+	var tokens []dotToken
+	isIDChar := func(c byte) bool {
+		return c == '_' || c == '.' || c >= 0x80 || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+	}
+	lineStart := true
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\n':
+			lineStart = true
+			i++
+			continue
+		case unicode.IsSpace(rune(c)):
+			i++
+			continue
+		case c == '#' && lineStart:
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(text[i:], "//"):
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end == -1 {
+				return nil, fmt.Errorf("bad DOT: unterminated comment")
+			}
+			i += end + 4
+			continue
+		}
+		lineStart = false
+
+		switch {
+		case strings.HasPrefix(text[i:], "->") || strings.HasPrefix(text[i:], "--"):
+			tokens = append(tokens, dotToken{text: text[i : i+2]})
+			i += 2
+		case strings.ContainsRune("{}[]=;,:", rune(c)):
+			tokens = append(tokens, dotToken{text: string(c)})
+			i++
+		case c == '"':
+			var value strings.Builder
+			i++
+			for ; i < len(text) && text[i] != '"'; i++ {
+				if text[i] == '\\' && i+1 < len(text) && (text[i+1] == '"' || text[i+1] == '\n') {
+					i++
+					if text[i] == '\n' {
+						continue
+					}
+				}
+				value.WriteByte(text[i])
+			}
+			if i == len(text) {
+				return nil, fmt.Errorf("bad DOT: unterminated string")
+			}
+			tokens = append(tokens, dotToken{text: value.String(), id: true, quoted: true})
+			i++
+		case c == '<':
+			depth, start := 0, i
+			for ; i < len(text); i++ {
+				if text[i] == '<' {
+					depth++
+				} else if text[i] == '>' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if i == len(text) {
+				return nil, fmt.Errorf("bad DOT: unterminated HTML string")
+			}
+			tokens = append(tokens, dotToken{text: text[start : i+1], id: true, quoted: true})
+			i++
+		case c == '-' || isIDChar(c):
+			start := i
+			i++
+			for i < len(text) && isIDChar(text[i]) {
+				i++
+			}
+			tokens = append(tokens, dotToken{text: text[start:i], id: true})
+		default:
+			return nil, fmt.Errorf("bad DOT: unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,97 @@
+package assembly
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewGraphFromFormatFile(t *testing.T) {
+	expected, expectedName, err := NewGraphFromFile("testdata/graphs/square_coloured.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{FormatGraphML, FormatGML, FormatDOT} {
+		g, name, err := NewGraphFromFormatFile("testdata/graphs/square_coloured."+format, format, DefaultGraphFormatOptions)
+		if err != nil {
+			t.Errorf("NewGraphFromFormatFile %v error: %v", format, err)
+			continue
+		}
+		if name != expectedName || !GraphEquals(&g, &expected) || g.Directed || g.Multigraph {
+			t.Errorf("NewGraphFromFormatFile %v expected %v %v, got %v %v", format, expectedName, GraphPrint(&expected), name, GraphPrint(&g))
+		}
+	}
+
+	// an attribute other than the colour
+	g, _, err := NewGraphFromFormatFile("testdata/graphs/square_coloured.graphml", FormatGraphML, GraphFormatOptions{EdgeColour: "weight"})
+	if err != nil || GraphIsVertexColoured(&g) || !reflect.DeepEqual(g.EdgeColourNames(), []string{"1.0", "1.0", "1.0", "2.0"}) {
+		t.Errorf("NewGraphFromFormatFile graphml with weight edge colours gave %v, %v", GraphPrint(&g), err)
+	}
+}
+
+func TestNewGraphFromFormatReader(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		input         string
+		options       GraphFormatOptions
+		vertices      []int
+		edges         [][2]int
+		vertexColours []string
+		edgeColours   []string
+		directed      bool
+	}{
+		{"dot digraph with names", FormatDOT, `digraph { a -> b -> c; c -> a }`, DefaultGraphFormatOptions,
+			[]int{0, 1, 2}, [][2]int{{0, 1}, {1, 2}, {2, 0}}, []string{}, []string{}, true},
+		{"dot subgraph and ports", FormatDOT, `strict graph g { a:n -- {b; c} }`, DefaultGraphFormatOptions,
+			[]int{0, 1, 2}, [][2]int{{0, 1}, {0, 2}}, []string{}, []string{}, false},
+		{"dot quoted with spaces", FormatDOT, "graph {\n# a comment\n\"x y\" [kind=\"big red\"]; z [kind=small]\n\"x y\" -- z }",
+			GraphFormatOptions{VertexColour: "kind"}, []int{0, 1}, [][2]int{{0, 1}}, []string{"big red", "small"}, []string{}, false},
+		{"dot quoted keyword", FormatDOT, `graph { "node" -- "edge" }`, DefaultGraphFormatOptions,
+			[]int{0, 1}, [][2]int{{0, 1}}, []string{}, []string{}, false},
+		{"gml directed", FormatGML, `graph [ directed 1 node [ id 0 type "C" ] node [ id 5 type "O" ] edge [ source 0 target 5 ] ]`,
+			GraphFormatOptions{VertexColour: "type"}, []int{0, 5}, [][2]int{{0, 5}}, []string{"C", "O"}, []string{}, true},
+		{"graphml key ids", FormatGraphML, `<graphml><key id="c" for="all"/><graph edgedefault="directed"><node id="n0"><data key="c">x</data></node><node id="n1"><data key="c">y</data></node><edge source="n1" target="n0"><data key="c">z</data></edge></graph></graphml>`,
+			GraphFormatOptions{VertexColour: "c", EdgeColour: "c"}, []int{0, 1}, [][2]int{{1, 0}}, []string{"x", "y"}, []string{"z"}, true},
+		{"multigraph", FormatDOT, `graph { 1 -- 2; 1 -- 2; 2 -- 2 }`, GraphFormatOptions{Multigraph: true},
+			[]int{1, 2}, [][2]int{{1, 2}, {1, 2}, {2, 2}}, []string{}, []string{}, false},
+	}
+
+	for _, tt := range tests {
+		g, _, err := NewGraphFromFormatReader(strings.NewReader(tt.input), tt.format, tt.options)
+		if err != nil {
+			t.Errorf("NewGraphFromFormatReader %v error: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(g.Vertices, tt.vertices) || !reflect.DeepEqual(g.Edges, tt.edges) ||
+			!reflect.DeepEqual(g.VertexColourNames(), tt.vertexColours) || !reflect.DeepEqual(g.EdgeColourNames(), tt.edgeColours) ||
+			g.Directed != tt.directed {
+			t.Errorf("NewGraphFromFormatReader %v gave %v directed %v", tt.name, GraphPrint(&g), g.Directed)
+		}
+	}
+
+	for _, bad := range []struct {
+		name   string
+		format string
+		input  string
+	}{
+		{"unknown format", "json", `{}`},
+		{"partly coloured", FormatDOT, `graph { a [colour=red]; a -- b }`},
+		{"parallel edges", FormatDOT, `graph { a -- b; b -- a }`},
+		{"dot unterminated", FormatDOT, `graph { a -- b `},
+		{"dot not a graph", FormatDOT, `tree { a }`},
+		{"gml no graph", FormatGML, `node [ id 1 ]`},
+		{"gml edge without target", FormatGML, `graph [ node [ id 1 ] edge [ source 1 ] ]`},
+		{"graphml no graph", FormatGraphML, `<graphml></graphml>`},
+		{"graphml not xml", FormatGraphML, `graph {`},
+	} {
+		if _, _, err := NewGraphFromFormatReader(strings.NewReader(bad.input), bad.format, DefaultGraphFormatOptions); err == nil {
+			t.Errorf("NewGraphFromFormatReader %v should give an error", bad.name)
+		}
+	}
+
+	_, _, err := NewGraphFromFormatReader(strings.NewReader(`graph { a [colour=red]; a -- b }`), FormatDOT, DefaultGraphFormatOptions)
+	if err == nil || !strings.Contains(err.Error(), "is on 1 of 2 vertices") {
+		t.Errorf("NewGraphFromFormatReader partly coloured error should count the vertices, got %v", err)
+	}
+}
@@ -11,18 +11,18 @@ import (
 // BreakGraphOnEdges returns two graph, one comprising the edges specified, and the other the remaining part
 func BreakGraphOnEdges(g *Graph, edges []int) (Graph, Graph) {
 
-	breakGraph := NewColourGraph(
+	breakGraph := NewColourGraphFromIDs(
 		[]int{},
 		[][2]int{},
-		[]string{},
-		[]string{},
+		[]int{},
+		[]int{},
 	)
 
-	remnantGraph := NewColourGraph(
+	remnantGraph := NewColourGraphFromIDs(
 		[]int{},
 		[][2]int{},
-		[]string{},
-		[]string{},
+		[]int{},
+		[]int{},
 	)
 
 	// distribute the edges across the two graphs
@@ -36,6 +36,9 @@ func BreakGraphOnEdges(g *Graph, edges []int) (Graph, Graph) {
 		}
 	}
 
+	breakGraph.copyKind(g)
+	remnantGraph.copyKind(g)
+
 	return breakGraph, remnantGraph
 
 }
@@ -100,10 +103,17 @@ func CopyGraphVerticesFromEdge(oldGraph *Graph, newGraph *Graph, edgeIndex int)
 // RecombineGraphs takes a pair of input graphs and puts them into a single graph object, relabeling the vertices of graphRight
 // No edges are added between the two graphs in the new object
 func RecombineGraphs(graphLeft *Graph, graphRight *Graph) (Graph, map[int]int) {
+	maxVertexLeft := helpers.MaxIntSlice(graphLeft.Vertices)
+	maxVertexRight := helpers.MaxIntSlice(graphRight.Vertices)
+	return recombineGraphsFrom(graphLeft, graphRight, helpers.MaxIntSlice([]int{maxVertexLeft, maxVertexRight})+1)
+}
+
+// recombineGraphsFrom is RecombineGraphs, with relabelled vertices of graphRight starting from nextVertex
+func recombineGraphsFrom(graphLeft *Graph, graphRight *Graph, nextVertex int) (Graph, map[int]int) {
 	var outputEdges [][2]int
 	var outputVertices []int
-	var outputEdgeColours []string
-	var outputVertexColours []string
+	var outputEdgeColours []int
+	var outputVertexColours []int
 
 	// copy left edges
 	for i, edge := range graphLeft.Edges {
@@ -121,10 +131,6 @@ func RecombineGraphs(graphLeft *Graph, graphRight *Graph) (Graph, map[int]int) {
 		}
 	}
 
-	maxVertexLeft := helpers.MaxIntSlice(graphLeft.Vertices)
-	maxVertexRight := helpers.MaxIntSlice(graphRight.Vertices)
-	nextVertex := helpers.MaxIntSlice([]int{maxVertexLeft, maxVertexRight}) + 1
-
 	// copy right vertices
 	vertexMap := make(map[int]int)
 	for i, vertex := range graphRight.Vertices {
@@ -148,7 +154,11 @@ func RecombineGraphs(graphLeft *Graph, graphRight *Graph) (Graph, map[int]int) {
 		}
 	}
 
-	return NewColourGraph(outputVertices, outputEdges, outputVertexColours, outputEdgeColours), vertexMap
+	outputGraph := NewColourGraphFromIDs(outputVertices, outputEdges, outputVertexColours, outputEdgeColours)
+	outputGraph.copyKind(graphLeft)
+	outputGraph.Multigraph = graphLeft.Multigraph || graphRight.Multigraph
+	outputGraph.Stereo = graphLeft.Stereo.merge(graphRight.Stereo, graphRight.Vertices, vertexMap)
+	return outputGraph, vertexMap
 
 }
 
@@ -183,4 +193,67 @@ func ConnectedComponent(edge int, edgeAdj map[int][]int) []int {
 		}
 	}
 	return component
-}
\ No newline at end of file
+}
+
+// ConnectedComponentVertices finds sets of vertices corresponding to all connected components in the graph. Unlike
+// ConnectedComponentEdges, isolated vertices are included as components of their own
+func ConnectedComponentVertices(g *Graph) [][]int {
+	vertexAdj := g.VertexAdjacencies()
+
+	used := make(map[int]bool)
+	var vertexSets [][]int
+	for _, v := range g.Vertices {
+		if !used[v] {
+			component := ConnectedComponent(v, vertexAdj)
+			vertexSets = append(vertexSets, component)
+			for _, u := range component {
+				used[u] = true
+			}
+		}
+	}
+	return vertexSets
+}
+
+// BreakGraphOnVertices returns two graphs, the subgraph induced by the vertices specified, and the subgraph induced by
+// the remaining vertices. Edges between the two sets of vertices are in neither graph
+func BreakGraphOnVertices(g *Graph, vertices []int) (Graph, Graph) {
+	var rest []int
+	for _, v := range g.Vertices {
+		if !helpers.Contains(vertices, v) {
+			rest = append(rest, v)
+		}
+	}
+	return InducedColourSubgraph(g, vertices), InducedColourSubgraph(g, rest)
+}
+
+// InducedColourSubgraph returns the subgraph of g induced by subVertices, keeping vertex and edge colours. Vertices are
+// kept in the order they appear in g. Vertices that are not in g are ignored
+func InducedColourSubgraph(g *Graph, subVertices []int) Graph {
+	outGraph := NewColourGraphFromIDs([]int{}, [][2]int{}, []int{}, []int{})
+	outGraph.copyKind(g)
+
+	inSub := make(map[int]bool)
+	for _, v := range subVertices {
+		inSub[v] = true
+	}
+
+	for i, v := range g.Vertices {
+		if inSub[v] {
+			outGraph.Vertices = append(outGraph.Vertices, v)
+			if GraphIsVertexColoured(g) {
+				outGraph.VertexColours = append(outGraph.VertexColours, g.VertexColours[i])
+			}
+		}
+	}
+
+	for i, e := range g.Edges {
+		if inSub[e[0]] && inSub[e[1]] {
+			outGraph.Edges = append(outGraph.Edges, e)
+			if GraphIsEdgeColoured(g) {
+				outGraph.EdgeColours = append(outGraph.EdgeColours, g.EdgeColours[i])
+			}
+		}
+	}
+
+	return outGraph
+}
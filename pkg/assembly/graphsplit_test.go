@@ -148,3 +148,43 @@ func TestConnectedComponentEdges(t *testing.T) {
 		}
 	}
 }
+
+func TestConnectedComponentVertices(t *testing.T) {
+	tests := []struct {
+		graph      Graph
+		components [][]int
+	}{
+		{
+			NewGraphOnlyFromFile("testdata/graphs/square.txt"),
+			[][]int{{1, 2, 3, 4}},
+		},
+		{
+			// isolated vertices are components of their own
+			NewGraph([]int{0, 1, 2, 3, 4}, [][2]int{{0, 1}, {2, 3}}),
+			[][]int{{0, 1}, {2, 3}, {4}},
+		},
+	}
+
+	for _, tt := range tests {
+		components := ConnectedComponentVertices(&tt.graph)
+		for _, c := range components {
+			sort.Ints(c)
+		}
+		helpers.SortSliceOfSlices(components)
+		helpers.SortSliceOfSlices(tt.components)
+		if !reflect.DeepEqual(components, tt.components) {
+			t.Errorf("Error in ConnectedComponentVertices, Graph: %v, Expected %v, Got %v", tt.graph, tt.components, components)
+		}
+	}
+}
+
+func TestBreakGraphOnVertices(t *testing.T) {
+	g := NewColourGraph([]int{1, 2, 3, 4}, [][2]int{{1, 2}, {2, 3}, {3, 4}, {4, 1}}, []string{"A", "B", "C", "D"}, []string{"a", "b", "c", "d"})
+	sub, rest := BreakGraphOnVertices(&g, []int{2, 3})
+
+	expectedSub := NewColourGraph([]int{2, 3}, [][2]int{{2, 3}}, []string{"B", "C"}, []string{"b"})
+	expectedRest := NewColourGraph([]int{1, 4}, [][2]int{{4, 1}}, []string{"A", "D"}, []string{"d"})
+	if !GraphEquals(&sub, &expectedSub) || !GraphEquals(&rest, &expectedRest) {
+		t.Errorf("Error in BreakGraphOnVertices, Expected %v %v, Got %v %v", expectedSub, expectedRest, sub, rest)
+	}
+}
@@ -14,16 +14,48 @@ import (
 
 // Code in this file relates mainly to the Graph struct
 
+// Graph is a vertex and edge coloured graph. Colours are stored as ids interned in the Colours dictionary, use
+// VertexColourNames and EdgeColourNames to get the colour strings back
+// Multigraph graphs may have parallel edges (several edges between the same pair of vertices) and self-loops. Each
+// edge keeps its own colour, so parallel edges can have different colours
+// In Directed graphs each edge goes from Edges[i][0] to Edges[i][1], and {1, 2} and {2, 1} are different edges
+// Stereo is the stereochemistry of a molecule graph, or nil. It is shared by the graphs derived from it, so must not be
+// changed once set
 type Graph struct {
 	Vertices      []int
 	Edges         [][2]int
 	// Adjacencies   map[int][]int
-	VertexColours []string
-	EdgeColours   []string
+	VertexColours []int
+	EdgeColours   []int
+	Multigraph    bool
+	Directed      bool
+	Stereo        *Stereo
 }
 
 // NewColourGraph constructs a new Graph based on input vertices,edged, vertex and edge colours
+// The colour strings are interned in the Colours dictionary. The graph is not validated, use ValidateGraph to check it
 func NewColourGraph(vertices []int, edges [][2]int, vColours []string, eColours []string) Graph {
+	return NewColourGraphFromIDs(vertices, edges, Colours.InternSlice(vColours), Colours.InternSlice(eColours))
+}
+
+// NewMultigraph constructs a new Graph in the same way as NewColourGraph, but allows parallel edges and self-loops
+func NewMultigraph(vertices []int, edges [][2]int, vColours []string, eColours []string) Graph {
+	g := NewColourGraphFromIDs(vertices, edges, Colours.InternSlice(vColours), Colours.InternSlice(eColours))
+	g.Multigraph = true
+	return g
+}
+
+// NewDirectedGraph constructs a new directed Graph in the same way as NewColourGraph. Edges go from the first vertex
+// of each pair to the second
+func NewDirectedGraph(vertices []int, edges [][2]int, vColours []string, eColours []string) Graph {
+	g := NewColourGraphFromIDs(vertices, edges, Colours.InternSlice(vColours), Colours.InternSlice(eColours))
+	g.Directed = true
+	return g
+}
+
+// NewColourGraphFromIDs constructs a new Graph based on input vertices, edges, and vertex and edge colour ids
+// This is used inside the assembly algorithm on graphs derived from already validated graphs, so it does not validate
+func NewColourGraphFromIDs(vertices []int, edges [][2]int, vColours []int, eColours []int) Graph {
 	g := Graph{
 		Vertices:      vertices,
 		Edges:         edges,
@@ -43,6 +75,14 @@ func NewGraph(vertices []int, edges [][2]int) Graph {
 	return NewColourGraph(vertices, edges, []string{}, []string{})
 }
 
+// copyKind sets whether g is a multigraph and directed, and its stereochemistry, to match another graph. This is used
+// when deriving new graphs from an existing graph
+func (g *Graph) copyKind(from *Graph) {
+	g.Multigraph = from.Multigraph
+	g.Directed = from.Directed
+	g.Stereo = from.Stereo
+}
+
 // NewGraphOnlyFromFile returns a graph from a graph file, but without a graph name or error
 func NewGraphOnlyFromFile(filePath string) Graph {
 	g, _, _ := NewGraphFromFile(filePath)
@@ -57,7 +97,19 @@ func NewGraphOnlyFromFile(filePath string) Graph {
 // 4. A list of vertex colour as strings (length = length of vertex list), or single "!" if not coloured
 // 5. A list of edge colours as strings (length = length of edge list), or single "!" if not coloured
 // If the file only has 3 lines, graph is assumed to have no vertex or edge colours
+// The graph is validated, and a ValidationError is returned along with the graph if there are any issues
 func NewGraphFromScanner(scanner *bufio.Scanner) (Graph, string, error){
+	return NewGraphFromScannerOptions(scanner, false, false)
+}
+
+// NewMultigraphFromScanner is the same as NewGraphFromScanner, but the graph may have parallel edges and self-loops
+func NewMultigraphFromScanner(scanner *bufio.Scanner) (Graph, string, error){
+	return NewGraphFromScannerOptions(scanner, true, false)
+}
+
+// NewGraphFromScannerOptions is the same as NewGraphFromScanner, but the graph can be a multigraph (parallel edges and
+// self-loops allowed) and/or directed (each edge goes from the first vertex of the pair to the second)
+func NewGraphFromScannerOptions(scanner *bufio.Scanner, multigraph bool, directed bool) (Graph, string, error){
 	var graphName string
 	var vertices []int
 	var edges [][2]int
@@ -77,7 +129,9 @@ func NewGraphFromScanner(scanner *bufio.Scanner) (Graph, string, error){
 			splitLine := strings.Fields(scanner.Text())
 			for _, s := range splitLine {
 				n, err := strconv.Atoi(s)
-				check(err)
+				if err != nil {
+					return NewGraph([]int{}, [][2]int{}), "", fmt.Errorf("vertices line: %v", err)
+				}
 				vertices = append(vertices, n)
 			}
 		}
@@ -90,7 +144,9 @@ func NewGraphFromScanner(scanner *bufio.Scanner) (Graph, string, error){
 			var v1, v2 int
 			for i, vertex := range splitLine {
 				n, err := strconv.Atoi(vertex)
-				check(err)
+				if err != nil {
+					return NewGraph([]int{}, [][2]int{}), "", fmt.Errorf("edges line: %v", err)
+				}
 				if i%2 == 0 {
 					v1 = n
 				} else {
@@ -123,7 +179,10 @@ func NewGraphFromScanner(scanner *bufio.Scanner) (Graph, string, error){
 		i++
 	}
 
-	return NewColourGraph(vertices, edges, vertexColours, edgeColours), graphName, nil
+	graph := NewColourGraphFromIDs(vertices, edges, Colours.InternSlice(vertexColours), Colours.InternSlice(edgeColours))
+	graph.Multigraph = multigraph
+	graph.Directed = directed
+	return graph, graphName, ValidateGraph(&graph)
 }
 
 // NewGraphFromFile returns a graph from text file input. See NewGraphFromScanner comments for required graph format
@@ -139,6 +198,25 @@ func NewGraphFromFile(filePath string) (Graph, string, error) {
 	return graph, name, graphError
 }
 
+// NewMultigraphFromFile returns a multigraph from text file input, in the same format as NewGraphFromFile
+func NewMultigraphFromFile(filePath string) (Graph, string, error) {
+	return NewGraphFromFileOptions(filePath, true, false)
+}
+
+// NewGraphFromFileOptions returns a graph from text file input, which may be a multigraph and/or directed.
+// See NewGraphFromScannerOptions
+func NewGraphFromFileOptions(filePath string, multigraph bool, directed bool) (Graph, string, error) {
+	f, err := os.Open(filePath)
+	check(err)
+	scanner := bufio.NewScanner(f)
+
+	graph, name, graphError := NewGraphFromScannerOptions(scanner, multigraph, directed)
+	closeErr := f.Close()
+	check(closeErr)
+
+	return graph, name, graphError
+}
+
 // NewGraphFromString returns a graph from text file input. See NewGraphFromScanner comments for required graph format
 func NewGraphFromString(graphString string)(Graph, string, error){
 	scanner := bufio.NewScanner(strings.NewReader(graphString))
@@ -169,7 +247,8 @@ func InducedSubgraph(g *Graph, subVertices []int) (Graph, error) {
 		}
 	}
 
-	outGraph = NewGraph(subVertices, newEdges)
+	outGraph = NewColourGraphFromIDs(subVertices, newEdges, []int{}, []int{})
+	outGraph.copyKind(g)
 	return outGraph, nil
 
 }
@@ -177,6 +256,8 @@ func InducedSubgraph(g *Graph, subVertices []int) (Graph, error) {
 
 // EdgeAdjacencies returns a map of indexed edge adjacencies, i.e. which edges are related to a given edge
 // through sharing a vertex
+// For directed graphs edges are adjacent if they share a vertex in either direction (weak connectivity), since two
+// fragments can be joined at any shared vertex. Edge direction is taken into account when fragments are matched
 // TODO: doesn't have a test
 func (g *Graph) EdgeAdjacencies() map[int][]int {
 
@@ -196,6 +277,49 @@ func (g *Graph) EdgeAdjacencies() map[int][]int {
 
 
 
+// VertexAdjacencies returns a map of each vertex to the vertices it shares an edge with, in either direction.
+// Vertices with no edges are not in the map
+func (g *Graph) VertexAdjacencies() map[int][]int {
+	outMap := make(map[int][]int)
+	for _, e := range g.Edges {
+		if e[0] == e[1] {
+			continue
+		}
+		if !helpers.Contains(outMap[e[0]], e[1]) {
+			helpers.MapUpdate(e[0], e[1], outMap)
+		}
+		if !helpers.Contains(outMap[e[1]], e[0]) {
+			helpers.MapUpdate(e[1], e[0], outMap)
+		}
+	}
+	return outMap
+}
+
+// OutNeighbours returns a map of each vertex to the vertices its edges point to. For undirected graphs this is the same
+// as InNeighbours, with each edge counted in both directions
+func (g *Graph) OutNeighbours() map[int][]int {
+	outMap := make(map[int][]int)
+	for _, e := range g.Edges {
+		helpers.MapUpdate(e[0], e[1], outMap)
+		if !g.Directed {
+			helpers.MapUpdate(e[1], e[0], outMap)
+		}
+	}
+	return outMap
+}
+
+// InNeighbours returns a map of each vertex to the vertices with edges pointing to it
+func (g *Graph) InNeighbours() map[int][]int {
+	outMap := make(map[int][]int)
+	for _, e := range g.Edges {
+		helpers.MapUpdate(e[1], e[0], outMap)
+		if !g.Directed {
+			helpers.MapUpdate(e[0], e[1], outMap)
+		}
+	}
+	return outMap
+}
+
 // ListPairSort takes an [][2]int slice and sorts it, sorting the internal [2]int and then sorting the whole list
 // by the first item in the pair
 func ListPairSort(l [][2]int) [][2]int {
@@ -223,6 +347,23 @@ func ListPairSort(l [][2]int) [][2]int {
 	return outputSlice
 }
 
+// SortedEdges returns a sorted copy of the edges of a graph. For undirected graphs this is ListPairSort, and for directed
+// graphs the vertices within each edge are left in order, so that the edge direction is kept
+func SortedEdges(g *Graph) [][2]int {
+	if !g.Directed {
+		return ListPairSort(g.Edges)
+	}
+
+	outputSlice := CopyEdgeList(g.Edges)
+	sort.Slice(outputSlice, func(i, j int) bool {
+		if outputSlice[i][0] != outputSlice[j][0] {
+			return outputSlice[i][0] < outputSlice[j][0]
+		}
+		return outputSlice[i][1] < outputSlice[j][1]
+	})
+	return outputSlice
+}
+
 // GraphEquals checks for equality between two graphs, in that it contains the same list of vertices, and the
 // same edges between them. It does not care about the order of vertices or edges, but it does not test for
 // isomorphisms, only the same labels and edges between them.
@@ -242,9 +383,14 @@ func GraphEquals(g1 *Graph, g2 *Graph) bool {
 		return false
 	}
 
+	// directed graphs are never equal to undirected ones
+	if g1.Directed != g2.Directed {
+		return false
+	}
+
 	// copy edges and sort
-	edge1 := ListPairSort(g1.Edges)
-	edge2 := ListPairSort(g2.Edges)
+	edge1 := SortedEdges(g1)
+	edge2 := SortedEdges(g2)
 	eqEdge := reflect.DeepEqual(edge1, edge2)
 
 	return eqVert && eqEdge
@@ -263,58 +409,81 @@ func checkColoursEqual(g1 *Graph, g2 *Graph) bool {
 	}
 
 	// Check if vertex colours equal
-	if g1VColoured && !reflect.DeepEqual(VertexColourMap(g1), VertexColourMap(g2)) {
+	if g1VColoured && !intMapEqual(VertexColourMap(g1), VertexColourMap(g2)) {
 		return false
 	}
 
 	// check if edge colours equal
 	if g1EColoured {
-		e1ColMap := orderEdgeColourMap(EdgeColourMap(g1))
-		e2ColMap := orderEdgeColourMap(EdgeColourMap(g2))
-		if !reflect.DeepEqual(e1ColMap, e2ColMap) {
+		e1ColMap := EdgeColourMap(g1)
+		e2ColMap := EdgeColourMap(g2)
+		if len(e1ColMap) != len(e2ColMap) {
 			return false
 		}
+		for e, c := range e1ColMap {
+			if c2, ok := e2ColMap[e]; !ok || !SliceEqual(c, c2) {
+				return false
+			}
+		}
 	}
 
 	return true
 
 }
 
-// VertexColourMap returns a map of vertices to vertex colours in a graph
-func VertexColourMap(g *Graph) map[int]string {
-	outMap := make(map[int]string)
-	for i, v := range g.Vertices {
-		outMap[v] = g.VertexColours[i]
+// intMapEqual returns true if two maps of ints to ints have the same keys and values
+func intMapEqual(m1 map[int]int, m2 map[int]int) bool {
+	if len(m1) != len(m2) {
+		return false
 	}
-	return outMap
+	for k, v1 := range m1 {
+		if v2, ok := m2[k]; !ok || v1 != v2 {
+			return false
+		}
+	}
+	return true
 }
 
-// EdgeColourMap returns a map of edges to edge colours in a graph
-func EdgeColourMap(g *Graph) map[[2]int]string {
-	outMap := make(map[[2]int]string)
-	for i, e := range g.Edges {
-		outMap[e] = g.EdgeColours[i]
+// VertexColourMap returns a map of vertices to vertex colour ids in a graph
+func VertexColourMap(g *Graph) map[int]int {
+	outMap := make(map[int]int)
+	for i, v := range g.Vertices {
+		outMap[v] = g.VertexColours[i]
 	}
 	return outMap
 }
 
-// orderEdgeColourMap takes a map of edge colours and orders the keys (edges) so that the edges vertices are in order
-// e.g. keys {1, 2} and {2, 1}, which represent the same edge, both become {1, 2}
-func orderEdgeColourMap(edgeMap map[[2]int]string) map[[2]int]string {
-	outMap := make(map[[2]int]string)
-	for k, v := range edgeMap {
-		if k[0] < k[1] {
-			outMap[k] = v
-		} else {
-			outMap[[2]int{k[1], k[0]}] = v
+// EdgeColourMap returns a map of edges to edge colour ids in a graph. For undirected graphs the keys are ordered so that the
+// edge vertices are in order, e.g. {1, 2} and {2, 1}, which represent the same edge, both become {1, 2}. Each edge maps to a sorted list of
+// colours, which has more than one entry if the graph has parallel edges between the same vertices
+func EdgeColourMap(g *Graph) map[[2]int][]int {
+	outMap := make(map[[2]int][]int)
+	for i, e := range g.Edges {
+		key := e
+		if !g.Directed && e[0] > e[1] {
+			key = [2]int{e[1], e[0]}
 		}
+		outMap[key] = append(outMap[key], g.EdgeColours[i])
+	}
+	for _, colours := range outMap {
+		sort.Ints(colours)
 	}
 	return outMap
 }
 
 // GraphPrint returns a string containing some graph information
 func GraphPrint(g *Graph) string {
-	return fmt.Sprintf("Vertices %v\nEdges %v\nVertexColours %v\nEdgeColours %v ", g.Vertices, g.Edges, g.VertexColours, g.EdgeColours)
+	return fmt.Sprintf("Vertices %v\nEdges %v\nVertexColours %v\nEdgeColours %v ", g.Vertices, g.Edges, g.VertexColourNames(), g.EdgeColourNames())
+}
+
+// VertexColourNames returns the vertex colours of the graph as strings
+func (g *Graph) VertexColourNames() []string {
+	return Colours.Names(g.VertexColours)
+}
+
+// EdgeColourNames returns the edge colours of the graph as strings
+func (g *Graph) EdgeColourNames() []string {
+	return Colours.Names(g.EdgeColours)
 }
 
 // GraphIsVertexColoured returns true if the graph is vertex coloured
@@ -337,8 +506,8 @@ func GraphIsEdgeColoured(g *Graph) bool {
 func CopyGraph(g *Graph) Graph {
 	newVertices := make([]int, len(g.Vertices))
 	newEdges := make([][2]int, len(g.Edges))
-	newVertexColours := make([]string, len(g.VertexColours))
-	newEdgeColours := make([]string, len(g.EdgeColours))
+	newVertexColours := make([]int, len(g.VertexColours))
+	newEdgeColours := make([]int, len(g.EdgeColours))
 
 	copy(newVertices, g.Vertices)
 	copy(newVertexColours, g.VertexColours)
@@ -348,7 +517,9 @@ func CopyGraph(g *Graph) Graph {
 		newEdges[i] = e
 	}
 
-	return NewColourGraph(newVertices, newEdges, newVertexColours, newEdgeColours)
+	newGraph := NewColourGraphFromIDs(newVertices, newEdges, newVertexColours, newEdgeColours)
+	newGraph.copyKind(g)
+	return newGraph
 
 
 }
\ No newline at end of file
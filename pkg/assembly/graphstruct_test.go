@@ -1,6 +1,7 @@
 package assembly
 
 import (
+	"GoAssembly/pkg/helpers"
 	"fmt"
 	"io/ioutil"
 	"reflect"
@@ -89,15 +90,15 @@ func TestNewGraphFromFile(t *testing.T) {
 		eq1 := name == tt.name
 		eq2 := reflect.DeepEqual(g.Vertices, tt.vertices)
 		eq3 := reflect.DeepEqual(g.Edges, tt.edges)
-		eq4 := reflect.DeepEqual(g.VertexColours, tt.vertexColours)
-		eq5 := reflect.DeepEqual(g.EdgeColours, tt.edgeColours)
+		eq4 := reflect.DeepEqual(g.VertexColourNames(), tt.vertexColours)
+		eq5 := reflect.DeepEqual(g.EdgeColourNames(), tt.edgeColours)
 		if !(eq1 && eq2 && eq3 && eq4 && eq5) {
 			errString := "NewGraphFromFile error:\n"
 			errString += fmt.Sprintf("Description expected %v, got %v - %v\n", tt.name, name, eq1)
 			errString += fmt.Sprintf("Vertices expected %v, got %v - %v\n", tt.vertices, g.Vertices, eq2)
 			errString += fmt.Sprintf("Edges expected %v, got %v - %v\n", tt.edges, g.Edges, eq3)
-			errString += fmt.Sprintf("Vertex Colours expected %v, got %v - %v\n", tt.vertexColours, g.VertexColours, eq4)
-			errString += fmt.Sprintf("Edge Colours expected %v, got %v - %v\n", tt.edgeColours, g.EdgeColours, eq5)
+			errString += fmt.Sprintf("Vertex Colours expected %v, got %v - %v\n", tt.vertexColours, g.VertexColourNames(), eq4)
+			errString += fmt.Sprintf("Edge Colours expected %v, got %v - %v\n", tt.edgeColours, g.EdgeColourNames(), eq5)
 			t.Error(errString)
 		}
 	}
@@ -141,15 +142,15 @@ func TestNewGraphFromString(t *testing.T) {
 		eq1 := name == tt.name
 		eq2 := reflect.DeepEqual(g.Vertices, tt.vertices)
 		eq3 := reflect.DeepEqual(g.Edges, tt.edges)
-		eq4 := reflect.DeepEqual(g.VertexColours, tt.vertexColours)
-		eq5 := reflect.DeepEqual(g.EdgeColours, tt.edgeColours)
+		eq4 := reflect.DeepEqual(g.VertexColourNames(), tt.vertexColours)
+		eq5 := reflect.DeepEqual(g.EdgeColourNames(), tt.edgeColours)
 		if !(eq1 && eq2 && eq3 && eq4 && eq5) {
 			errString := "NewGraphFromFile error:\n"
 			errString += fmt.Sprintf("Description expected %v, got %v - %v\n", tt.name, name, eq1)
 			errString += fmt.Sprintf("Vertices expected %v, got %v - %v\n", tt.vertices, g.Vertices, eq2)
 			errString += fmt.Sprintf("Edges expected %v, got %v - %v\n", tt.edges, g.Edges, eq3)
-			errString += fmt.Sprintf("Vertex Colours expected %v, got %v - %v\n", tt.vertexColours, g.VertexColours, eq4)
-			errString += fmt.Sprintf("Edge Colours expected %v, got %v - %v\n", tt.edgeColours, g.EdgeColours, eq5)
+			errString += fmt.Sprintf("Vertex Colours expected %v, got %v - %v\n", tt.vertexColours, g.VertexColourNames(), eq4)
+			errString += fmt.Sprintf("Edge Colours expected %v, got %v - %v\n", tt.edgeColours, g.EdgeColourNames(), eq5)
 			t.Error(errString)
 		}
 	}
@@ -189,14 +190,14 @@ func TestNewGraphOnlyFromString(t *testing.T) {
 
 		eq1 := reflect.DeepEqual(g.Vertices, tt.vertices)
 		eq2 := reflect.DeepEqual(g.Edges, tt.edges)
-		eq3 := reflect.DeepEqual(g.VertexColours, tt.vertexColours)
-		eq4 := reflect.DeepEqual(g.EdgeColours, tt.edgeColours)
+		eq3 := reflect.DeepEqual(g.VertexColourNames(), tt.vertexColours)
+		eq4 := reflect.DeepEqual(g.EdgeColourNames(), tt.edgeColours)
 		if !(eq1 && eq2 && eq3 && eq4) {
 			errString := "NewGraphFromFile error:\n"
 			errString += fmt.Sprintf("Vertices expected %v, got %v - %v\n", tt.vertices, g.Vertices, eq1)
 			errString += fmt.Sprintf("Edges expected %v, got %v - %v\n", tt.edges, g.Edges, eq2)
-			errString += fmt.Sprintf("Vertex Colours expected %v, got %v - %v\n", tt.vertexColours, g.VertexColours, eq3)
-			errString += fmt.Sprintf("Edge Colours expected %v, got %v - %v\n", tt.edgeColours, g.EdgeColours, eq4)
+			errString += fmt.Sprintf("Vertex Colours expected %v, got %v - %v\n", tt.vertexColours, g.VertexColourNames(), eq3)
+			errString += fmt.Sprintf("Edge Colours expected %v, got %v - %v\n", tt.edgeColours, g.EdgeColourNames(), eq4)
 			t.Error(errString)
 		}
 	}
@@ -337,17 +338,17 @@ func TestGraphEquals(t *testing.T) {
 func TestVertexColourMap(t *testing.T) {
 	tests := []struct {
 		g Graph
-		m map[int]string
+		m map[int]int
 	}{
 		{NewColourGraph([]int{1, 2, 3, 4},
 			[][2]int{{1, 2}, {2, 3}, {3, 4}},
 			[]string{"A", "A", "A", "B"},
 			[]string{}),
-			map[int]string{
-				1: "A",
-				2: "A",
-				3: "A",
-				4: "B",
+			map[int]int{
+				1: Colours.Intern("A"),
+				2: Colours.Intern("A"),
+				3: Colours.Intern("A"),
+				4: Colours.Intern("B"),
 			},
 		},
 	}
@@ -365,16 +366,26 @@ func TestVertexColourMap(t *testing.T) {
 func TestEdgeColourMap(t *testing.T) {
 	tests := []struct {
 		g Graph
-		m map[[2]int]string
+		m map[[2]int][]int
 	}{
 		{NewColourGraph([]int{1, 2, 3, 4},
-			[][2]int{{1, 2}, {2, 3}, {3, 4}},
+			[][2]int{{1, 2}, {2, 3}, {4, 3}},
 			[]string{},
 			[]string{"A", "A", "B"}),
-			map[[2]int]string{
-				[2]int{1, 2}: "A",
-				[2]int{2, 3}: "A",
-				[2]int{3, 4}: "B",
+			map[[2]int][]int{
+				[2]int{1, 2}: {Colours.Intern("A")},
+				[2]int{2, 3}: {Colours.Intern("A")},
+				[2]int{3, 4}: {Colours.Intern("B")},
+			},
+		},
+		{NewMultigraph([]int{1, 2, 3},
+			[][2]int{{1, 2}, {2, 1}, {2, 3}, {3, 3}},
+			[]string{},
+			[]string{"A", "B", "A", "C"}),
+			map[[2]int][]int{
+				[2]int{1, 2}: helpers.SortedCopy([]int{Colours.Intern("A"), Colours.Intern("B")}),
+				[2]int{2, 3}: {Colours.Intern("A")},
+				[2]int{3, 3}: {Colours.Intern("C")},
 			},
 		},
 	}
@@ -389,6 +400,39 @@ func TestEdgeColourMap(t *testing.T) {
 	}
 }
 
+func TestMultigraphEquals(t *testing.T) {
+	tests := []struct {
+		g1 Graph
+		g2 Graph
+		eq bool
+	}{
+		{
+			NewMultigraph([]int{1, 2}, [][2]int{{1, 2}, {1, 2}}, []string{}, []string{"A", "B"}),
+			NewMultigraph([]int{1, 2}, [][2]int{{2, 1}, {1, 2}}, []string{}, []string{"B", "A"}),
+			true,
+		},
+		{
+			// parallel edge colours must not be merged
+			NewMultigraph([]int{1, 2}, [][2]int{{1, 2}, {1, 2}}, []string{}, []string{"A", "B"}),
+			NewMultigraph([]int{1, 2}, [][2]int{{1, 2}, {1, 2}}, []string{}, []string{"A", "A"}),
+			false,
+		},
+		{
+			// edge multiplicity matters
+			NewMultigraph([]int{1, 2}, [][2]int{{1, 2}, {1, 2}}, []string{}, []string{}),
+			NewMultigraph([]int{1, 2}, [][2]int{{1, 2}}, []string{}, []string{}),
+			false,
+		},
+	}
+
+	for i, tt := range tests {
+		if eq := GraphEquals(&tt.g1, &tt.g2); eq != tt.eq {
+			t.Errorf("GraphEquals multigraph error on test %v\n%v\n%v\nExpected %v got %v",
+				i, GraphPrint(&tt.g1), GraphPrint(&tt.g2), tt.eq, eq)
+		}
+	}
+}
+
 func TestCopyGraph(t *testing.T) {
 	tests := []Graph{
 		NewGraphOnlyFromFile("testdata/graphs/fish_graph.txt"),
@@ -403,3 +447,35 @@ func TestCopyGraph(t *testing.T) {
 		}
 	}
 }
+
+func TestSortedEdges(t *testing.T) {
+	tests := []struct {
+		g     Graph
+		edges [][2]int
+	}{
+		{
+			NewColourGraph([]int{1, 2, 3}, [][2]int{{3, 2}, {2, 1}}, []string{}, []string{}),
+			[][2]int{{1, 2}, {2, 3}},
+		},
+		{
+			NewDirectedGraph([]int{1, 2, 3}, [][2]int{{3, 2}, {2, 1}, {1, 3}}, []string{}, []string{}),
+			[][2]int{{1, 3}, {2, 1}, {3, 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		edges := SortedEdges(&tt.g)
+		if !reflect.DeepEqual(edges, tt.edges) {
+			t.Errorf("SortedEdges error, graph %v, expected %v got %v", tt.g, tt.edges, edges)
+		}
+	}
+}
+
+func TestOutInNeighbours(t *testing.T) {
+	g := NewDirectedGraph([]int{1, 2, 3}, [][2]int{{1, 2}, {1, 3}, {3, 2}}, []string{}, []string{})
+	out := map[int][]int{1: {2, 3}, 3: {2}}
+	in := map[int][]int{2: {1, 3}, 3: {1}}
+	if !reflect.DeepEqual(g.OutNeighbours(), out) || !reflect.DeepEqual(g.InNeighbours(), in) {
+		t.Errorf("Out/InNeighbours error, expected %v %v, got %v %v", out, in, g.OutNeighbours(), g.InNeighbours())
+	}
+}
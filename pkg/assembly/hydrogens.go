@@ -0,0 +1,112 @@
+package assembly
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Code for choosing what happens to hydrogen atoms when molecule graphs are made from mol files. By default they are
+// removed, but they can be kept as written, or every atom can be given its hydrogens from valence rules, so hydrogen
+// inclusive assembly indices can be compared whether or not the mol file had explicit hydrogens
+
+// Hydrogens is what happens to the hydrogen atoms of a mol file, see the HydrogensStrip, HydrogensKeep and HydrogensAdd
+// options. The zero value is HydrogensStrip
+type Hydrogens int
+
+const (
+	// HydrogensStrip removes all hydrogen atoms
+	HydrogensStrip Hydrogens = iota
+	// HydrogensKeep keeps the hydrogen atoms in the mol file, and adds none
+	HydrogensKeep
+	// HydrogensAdd keeps the hydrogen atoms in the mol file, and adds the implicit hydrogens of each atom, see
+	// implicitHydrogens
+	HydrogensAdd
+)
+
+var hydrogensNames = []string{"strip", "keep", "add"}
+
+// ParseHydrogens returns the Hydrogens option for its name: strip, keep or add
+func ParseHydrogens(s string) (Hydrogens, error) {
+	for i, name := range hydrogensNames {
+		if strings.TrimSpace(s) == name {
+			return Hydrogens(i), nil
+		}
+	}
+	return HydrogensStrip, fmt.Errorf("unknown hydrogens option %v, must be %v", s, strings.Join(hydrogensNames, ", "))
+}
+
+// String returns the name of the Hydrogens option, in the form read by ParseHydrogens
+func (hydrogens Hydrogens) String() string {
+	if hydrogens < 0 || int(hydrogens) >= len(hydrogensNames) {
+		return fmt.Sprintf("Hydrogens(%d)", int(hydrogens))
+	}
+	return hydrogensNames[hydrogens]
+}
+
+// implicitHydrogens returns the number of hydrogen atoms each atom has that aren't in the mol file: its default valence
+// (see defaultValence) less its bond orders and one for each unpaired electron (two for singlet and triplet radicals).
+// Aromatic bonds are kekulised first to find their orders, and an error is returned if they can't be. Atoms without a
+// default valence, e.g. metals, have none
+func implicitHydrogens(atoms []MolAtom, bonds [][2]int, bondTypes []int) ([]int, error) {
+	kekule, err := kekuliseAromatic(atoms, bonds, bondTypes)
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]int, len(atoms))
+	for i, atom := range atoms {
+		counts[i] = defaultValence(atom)
+		switch atom.Radical {
+		case 1, 3:
+			counts[i] -= 2
+		case 2:
+			counts[i]--
+		}
+	}
+	for i, b := range bonds {
+		counts[b[0]] -= kekule[i]
+		counts[b[1]] -= kekule[i]
+	}
+	for i, atom := range atoms {
+		if counts[i] < 0 || defaultValence(atom) == 0 {
+			counts[i] = 0
+		}
+	}
+	return counts, nil
+}
+
+// addHAtoms returns the atoms and bonds of a molecule with hydrogens added as atoms after the others, counts[i] of them
+// on atom i, each with a single bond to its atom, in the order of the atoms they are on. It also returns the added
+// hydrogens on each atom
+func addHAtoms(atoms []MolAtom, bonds [][2]int, bondTypes []int, atomIndices []int, counts []int) ([]MolAtom, [][2]int, []int, []int, map[int][]int) {
+	newAtoms := append([]MolAtom{}, atoms...)
+	newBonds := CopyEdgeList(bonds)
+	newBondTypes := append([]int{}, bondTypes...)
+	newAtomIndices := append([]int{}, atomIndices...)
+	added := make(map[int][]int)
+	for i, count := range counts {
+		for j := 0; j < count; j++ {
+			h := len(newAtoms)
+			newAtoms = append(newAtoms, MolAtom{Element: "H"})
+			newAtomIndices = append(newAtomIndices, h)
+			newBonds = append(newBonds, [2]int{atomIndices[i], h})
+			newBondTypes = append(newBondTypes, 1)
+			added[atomIndices[i]] = append(added[atomIndices[i]], h)
+		}
+	}
+	return newAtoms, newBonds, newBondTypes, newAtomIndices, added
+}
+
+// addHStereo returns stereochemistry perceived without implicit hydrogens for the graph with them added (see
+// addHAtoms): the implicit hydrogen of each stereo centre becomes the hydrogen added to it
+func addHStereo(stereo *Stereo, added map[int][]int) *Stereo {
+	withH := Stereo{Centres: make(map[int]StereoCentre), DoubleBonds: stereo.DoubleBonds}
+	for v, centre := range stereo.Centres {
+		for i, u := range centre.Neighbours {
+			if u == -1 && len(added[v]) > 0 {
+				centre.Neighbours[i] = added[v][0]
+			}
+		}
+		withH.Centres[v] = centre
+	}
+	return &withH
+}
@@ -0,0 +1,111 @@
+package assembly
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHydrogens(t *testing.T) {
+	for _, hydrogens := range []Hydrogens{HydrogensStrip, HydrogensKeep, HydrogensAdd} {
+		parsed, err := ParseHydrogens(hydrogens.String())
+		if err != nil || parsed != hydrogens {
+			t.Errorf("ParseHydrogens(%v) gave %v, %v", hydrogens.String(), parsed, err)
+		}
+	}
+	if _, err := ParseHydrogens("explicit"); err == nil {
+		t.Errorf("ParseHydrogens should give an error for an unknown option")
+	}
+}
+
+func TestImplicitHydrogens(t *testing.T) {
+	ring5 := [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 0}}
+	tests := []struct {
+		name      string
+		atoms     []MolAtom
+		bonds     [][2]int
+		bondTypes []int
+		expected  []int // nil for an error
+	}{
+		{"methane", molAtoms([]string{"C"}), [][2]int{}, []int{}, []int{4}},
+		{"ethene", molAtoms([]string{"C", "C"}), [][2]int{{0, 1}}, []int{2}, []int{2, 2}},
+		{"formic acid", molAtoms([]string{"C", "O", "O"}), [][2]int{{0, 1}, {0, 2}}, []int{2, 1}, []int{1, 0, 1}},
+		{"pyrrole aromatic", molAtoms(strings.Fields("N C C C C")), ring5, []int{4, 4, 4, 4, 4}, []int{1, 1, 1, 1, 1}},
+		{"ammonium", []MolAtom{{Element: "N", Charge: 1}}, [][2]int{}, []int{}, []int{4}},
+		{"methyl radical", []MolAtom{{Element: "C", Radical: 2}}, [][2]int{}, []int{}, []int{3}},
+		{"sodium chloride", molAtoms([]string{"Na", "Cl"}), [][2]int{{0, 1}}, []int{1}, []int{0, 0}},
+		{"cyclopentadiene aromatic", molAtoms(strings.Fields("C C C C C")), ring5, []int{4, 4, 4, 4, 4}, nil},
+	}
+
+	for _, tt := range tests {
+		counts, err := implicitHydrogens(tt.atoms, tt.bonds, tt.bondTypes)
+		if tt.expected == nil {
+			if err == nil {
+				t.Errorf("implicitHydrogens %v should give an error", tt.name)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(counts, tt.expected) {
+			t.Errorf("implicitHydrogens %v gave %v, %v, expected %v", tt.name, counts, err, tt.expected)
+		}
+	}
+}
+
+func TestMolGraphHydrogens(t *testing.T) {
+	tests := []struct {
+		molFile   string
+		hydrogens Hydrogens
+		vertices  int
+		edges     int
+	}{
+		{"testdata/aspirin.mol", HydrogensStrip, 13, 13},
+		{"testdata/aspirin.mol", HydrogensKeep, 13, 13},
+		{"testdata/aspirin.mol", HydrogensAdd, 21, 21},
+		{"testdata/aspirin_with_H.mol", HydrogensStrip, 13, 13},
+		{"testdata/aspirin_with_H.mol", HydrogensKeep, 21, 21},
+		{"testdata/aspirin_with_H.mol", HydrogensAdd, 21, 21},
+		{"testdata/glycine_zwitterion.mol", HydrogensAdd, 10, 9},
+	}
+
+	for _, tt := range tests {
+		g, err := MolGraphFromFileColouring(tt.molFile, Colouring{Hydrogens: tt.hydrogens})
+		if err != nil {
+			t.Errorf("MolGraphFromFileColouring %v %v error: %v", tt.molFile, tt.hydrogens, err)
+			continue
+		}
+		if len(g.Vertices) != tt.vertices || len(g.Edges) != tt.edges {
+			t.Errorf("MolGraphFromFileColouring %v %v has %v vertices and %v edges, expected %v and %v",
+				tt.molFile, tt.hydrogens, len(g.Vertices), len(g.Edges), tt.vertices, tt.edges)
+		}
+
+		numbering, err := MolNumberingHydrogens(tt.molFile, tt.hydrogens)
+		if err != nil || len(numbering.Atoms) != len(g.Vertices) || len(numbering.Bonds) != len(g.Edges) {
+			t.Errorf("MolNumberingHydrogens %v %v has %v atoms and %v bonds, %v, graph has %v vertices and %v edges",
+				tt.molFile, tt.hydrogens, len(numbering.Atoms), len(numbering.Bonds), err, len(g.Vertices), len(g.Edges))
+		}
+	}
+
+	// hydrogens added to a mol file without them give the same graph as a mol file with them
+	added, _ := MolGraphFromFileColouring("testdata/aspirin.mol", Colouring{Hydrogens: HydrogensAdd})
+	kept, _ := MolGraphFromFileColouring("testdata/aspirin_with_H.mol", Colouring{Hydrogens: HydrogensKeep})
+	if !GraphsIsomorphic(&added, &kept) {
+		t.Errorf("aspirin with added hydrogens should be isomorphic to aspirin with hydrogens kept")
+	}
+}
+
+func TestParseMultiMolStringKeepH(t *testing.T) {
+	fileBytes, err := ioutil.ReadFile("testdata/aspirin_with_H.mol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		stripH   bool
+		vertices int
+	}{{true, 13}, {false, 21}} {
+		graphs := ParseMultiMolString(string(fileBytes), tt.stripH)
+		if len(graphs) != 1 || len(graphs[0].Vertices) != tt.vertices {
+			t.Errorf("ParseMultiMolString stripH %v should give one graph with %v vertices", tt.stripH, tt.vertices)
+		}
+	}
+}
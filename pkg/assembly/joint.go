@@ -0,0 +1,103 @@
+package assembly
+
+import (
+	"GoAssembly/pkg/helpers"
+	"fmt"
+	"sort"
+)
+
+// Code for joint assembly, which finds the shortest pathway that builds a whole set of target graphs (e.g. molecules),
+// sharing intermediates between them. The targets are combined into a single disconnected graph and assembled as usual,
+// so duplicates may be found within one target or across several. The only difference is the assembly index: the
+// targets are built separately, so they are not joined together at the end
+
+// CombineGraphs combines graphs into one disconnected graph, relabelling vertices where needed so they are unique.
+// It returns the combined graph and a map of each vertex in it to the position of the graph it came from
+func CombineGraphs(graphs []Graph) (Graph, map[int]int) {
+	targets := make(map[int]int)
+	if len(graphs) == 0 {
+		return NewColourGraphFromIDs([]int{}, [][2]int{}, []int{}, []int{}), targets
+	}
+
+	combined := CopyGraph(&graphs[0])
+	for _, v := range combined.Vertices {
+		targets[v] = 0
+	}
+	for i := 1; i < len(graphs); i++ {
+		var vertexMap map[int]int
+		combined, vertexMap = RecombineGraphs(&combined, &graphs[i])
+		for _, v := range vertexMap {
+			targets[v] = i
+		}
+	}
+	return combined, targets
+}
+
+// JointAssembly finds a shortest pathway that builds all the graphs. It returns the combined graph (see CombineGraphs),
+// which is the original graph for the pathways, the map of its vertices to the graphs they came from, and the pathways
+func JointAssembly(graphs []Graph, numWorkers int, chanBufferSize int, variant string) (Graph, map[int]int, []Pathway) {
+	combined, targets := CombineGraphs(graphs)
+	pathways := Assembly(combined, numWorkers, chanBufferSize, variant)
+	return combined, targets, pathways
+}
+
+// JointAssemblyIndex returns the joint assembly index of a pathway on a combined graph. This is the AssemblyIndex less
+// one step for each extra connected component, as separate targets are not joined together
+func JointAssemblyIndex(pathway *Pathway, combined *Graph) int {
+	components := len(ConnectedComponentEdges(combined))
+	if components == 0 {
+		return 0
+	}
+	return AssemblyIndex(pathway, combined) - (components - 1)
+}
+
+// FragmentTargets returns, for each graph in the pathway, the sorted positions of the targets that its two occurrences
+// (the left and right of its Duplicates) are in. A fragment with more than one target is shared between targets
+func FragmentTargets(pathway *Pathway, targets map[int]int) [][]int {
+	fragmentTargets := make([][]int, len(pathway.duplicates))
+	for i, d := range pathway.duplicates {
+		var found []int
+		for _, edges := range [][][2]int{d.left, d.right} {
+			for _, e := range edges {
+				for _, v := range e {
+					target, ok := targets[originalVertex(pathway, v)]
+					if ok && !helpers.Contains(found, target) {
+						found = append(found, target)
+					}
+				}
+			}
+		}
+		sort.Ints(found)
+		fragmentTargets[i] = found
+	}
+	return fragmentTargets
+}
+
+// JointAssemblyString returns the details of a joint assembly pathway, as AssemblyString, followed by the targets of
+// each fragment and the joint assembly index
+func JointAssemblyString(pathways []Pathway, combined *Graph, targets map[int]int) string {
+	outString := AssemblyString(pathways, combined)
+	outString += "Fragment Targets\n"
+	for i, t := range FragmentTargets(&pathways[0], targets) {
+		shared := ""
+		if len(t) > 1 {
+			shared = " shared"
+		}
+		outString += fmt.Sprintf("%v %v%v\n", i, t, shared)
+	}
+	outString += "+++++++++++++++\n"
+	outString += fmt.Sprintf("Joint Assembly Index: %v\n", JointAssemblyIndex(&pathways[0], combined))
+	return outString
+}
+
+// originalVertex returns the vertex of the original graph that a vertex in the pathway came from. Vertices are relabelled
+// when duplicates are broken off from the remnant, and the relabelled vertices are recorded in atomEquivalents, with the
+// original vertex first
+func originalVertex(pathway *Pathway, v int) int {
+	for _, equivalents := range pathway.atomEquivalents {
+		if helpers.Contains(equivalents, v) {
+			return equivalents[0]
+		}
+	}
+	return v
+}
@@ -0,0 +1,72 @@
+package assembly
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCombineGraphs(t *testing.T) {
+	g1 := NewColourGraph([]int{0, 1, 2}, [][2]int{{0, 1}, {1, 2}}, []string{"C", "C", "O"}, []string{"single", "double"})
+	g2 := NewColourGraph([]int{0, 1}, [][2]int{{0, 1}}, []string{"N", "N"}, []string{"triple"})
+	combined, targets := CombineGraphs([]Graph{g1, g2})
+
+	expected := NewColourGraph([]int{0, 1, 2, 3, 4}, [][2]int{{0, 1}, {1, 2}, {3, 4}}, []string{"C", "C", "O", "N", "N"},
+		[]string{"single", "double", "triple"})
+	expectedTargets := map[int]int{0: 0, 1: 0, 2: 0, 3: 1, 4: 1}
+	if !GraphEquals(&combined, &expected) || !reflect.DeepEqual(targets, expectedTargets) {
+		t.Errorf("CombineGraphs error, expected %v %v, got %v %v", expected, expectedTargets, combined, targets)
+	}
+}
+
+func TestJointAssembly(t *testing.T) {
+	square := NewGraphOnlyFromFile("testdata/graphs/square.txt")
+	triangle := NewGraphOnlyFromFile("testdata/graphs/triangle.txt")
+
+	tests := []struct {
+		graphs     []Graph
+		jointIndex int
+		shared     bool
+	}{
+		// a single target is the same as Assembly
+		{[]Graph{square}, 2, false},
+		// a copy of a target is free
+		{[]Graph{square, square}, 2, true},
+		// the 2 edge path is shared: build it (1), then the square (1) and the triangle (1)
+		{[]Graph{square, triangle}, 3, true},
+	}
+
+	for _, tt := range tests {
+		combined, targets, pathways := JointAssembly(tt.graphs, 100, 100, "shortest")
+		jointIndex := JointAssemblyIndex(&pathways[0], &combined)
+		if jointIndex != tt.jointIndex {
+			t.Errorf("JointAssembly error, graphs %v, expected %v, got %v\n%v",
+				tt.graphs, tt.jointIndex, jointIndex, JointAssemblyString(pathways, &combined, targets))
+		}
+
+		shared := false
+		for _, fragmentTargets := range FragmentTargets(&pathways[0], targets) {
+			if len(fragmentTargets) > 1 {
+				shared = true
+			}
+			for _, target := range fragmentTargets {
+				if target < 0 || target >= len(tt.graphs) {
+					t.Errorf("FragmentTargets error, target %v out of range", target)
+				}
+			}
+		}
+		if shared != tt.shared {
+			t.Errorf("FragmentTargets error, graphs %v, expected shared %v, got %v\n%v",
+				tt.graphs, tt.shared, shared, JointAssemblyString(pathways, &combined, targets))
+		}
+	}
+}
+
+func TestOriginalVertex(t *testing.T) {
+	pathway := NewPathway([]Graph{}, Graph{}, []Duplicates{}, [][]int{{1, 5, 7}, {2, 6}})
+	tests := [][2]int{{5, 1}, {7, 1}, {6, 2}, {1, 1}, {3, 3}}
+	for _, tt := range tests {
+		if v := originalVertex(&pathway, tt[0]); v != tt[1] {
+			t.Errorf("originalVertex error, vertex %v, expected %v, got %v", tt[0], tt[1], v)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package assembly
 import (
 	"GoAssembly/pkg/helpers"
 	"bufio"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"strconv"
@@ -12,59 +13,124 @@ import (
 // Code relating specifically to parsing molecules from mol files / mol blocks
 
 
+// MolColourGraph returns a Graph from a mol file, with atom types as vertex colours and bond types as edge colours
+// Hydrogen atoms are removed. Invalid graphs are fatal
 func MolColourGraph(molFile string) Graph {
-	atomTypes, bonds, bondTypes, atomIndices := ParseMolFile(molFile, true)
-	bondTypeString := make([]string, len(bondTypes))
-	for i, bondType := range bondTypes{
-		switch bondType {
-		case 1:
-			bondTypeString[i] = "single"
-		case 2:
-			bondTypeString[i] = "double"
-		case 3:
-			bondTypeString[i] = "triple"
-		case 4:
-			bondTypeString[i] = "aromatic"
-		default:
-			bondTypeString[i] = "error"
-		}
+	g, err := MolGraphFromFile(molFile)
+	check(err)
+	return g
+}
+
+// MolBlockColourGraph returns a Graph from a mol block string, in the same way as MolColourGraph
+func MolBlockColourGraph(molBlock string) Graph {
+	g, err := MolGraphFromString(molBlock)
+	check(err)
+	return g
+}
+
+// MolGraphFromFile returns a Graph from a mol file, along with a ValidationError if the graph has any issues
+func MolGraphFromFile(molFile string) (Graph, error) {
+	return MolGraphFromFileColouring(molFile, Colouring{})
+}
+
+// MolGraphFromString returns a Graph from a mol block, along with a ValidationError if the graph has any issues
+func MolGraphFromString(molBlock string) (Graph, error) {
+	return MolGraphFromStringColouring(molBlock, Colouring{})
+}
+
+// MolGraphFromFileColouring is the same as MolGraphFromFile, with the colours chosen by colouring. An error is also
+// returned if the mol block can't be read
+func MolGraphFromFileColouring(molFile string, colouring Colouring) (Graph, error) {
+	f, err := os.Open(molFile)
+	check(err)
+	block, err := parseMolBlock(bufio.NewScanner(f))
+	check(f.Close())
+	if err != nil {
+		return Graph{}, err
+	}
+	return molBlockGraph(block, colouring)
+}
 
+// MolGraphFromStringColouring is the same as MolGraphFromString, with the colours chosen by colouring
+func MolGraphFromStringColouring(molBlock string, colouring Colouring) (Graph, error) {
+	block, err := parseMolBlock(bufio.NewScanner(strings.NewReader(molBlock)))
+	if err != nil {
+		return Graph{}, err
 	}
-	outGraph := NewColourGraph(atomIndices, bonds, atomTypes, bondTypeString)
-	return outGraph
+	return molBlockGraph(block, colouring)
 }
 
+// molBlockGraph builds and validates a colour graph from a mol block, with the hydrogen atoms stripped, kept or added as
+// chosen by colouring.Hydrogens. If colouring.Stereo is set, the stereochemistry is perceived with the hydrogen atoms of
+// the mol block, and added to the graph. The bonds are normalised (see Colouring) with the hydrogen atoms of the mol
+// block, and an error is returned if they can't be, or if the implicit hydrogens can't be found
+func molBlockGraph(block molBlock, colouring Colouring) (Graph, error) {
+	molBondTypes := block.bondTypes
+	bondTypes, err := colouring.normaliseBonds(block.atoms, block.bonds, block.bondTypes)
+	if err != nil {
+		return Graph{}, err
+	}
+	block.bondTypes = bondTypes
+	var stereo *Stereo
+	if colouring.Stereo {
+		stereo = perceiveStereo(&block)
+	}
 
-func MolBlockColourGraph(molBlock string) Graph {
-	// TODO: refactor code reuse with MolColourGraph
-	// TODO: ParseMolString should not always be true
-	atomTypes, bonds, bondTypes, atomIndices := ParseMolString(molBlock, true)
-	bondTypeString := make([]string, len(bondTypes))
-	for i, bondType := range bondTypes{
-		switch bondType {
-		case 1:
-			bondTypeString[i] = "single"
-		case 2:
-			bondTypeString[i] = "double"
-		case 3:
-			bondTypeString[i] = "triple"
-		case 4:
-			bondTypeString[i] = "aromatic"
-		default:
-			bondTypeString[i] = "error"
-		}
-
-	}
-	outGraph := NewColourGraph(atomIndices, bonds, atomTypes, bondTypeString)
-	return outGraph
-}
-// ParseMultiMolString parses string input that is in the form of an sdfile, i.e. a sequence of mol blocks with $$$$ as delimiter
+	atoms, bonds, bondTypes, atomIndices := block.atoms, block.bonds, block.bondTypes, block.atomIndices
+	switch colouring.Hydrogens {
+	case HydrogensStrip:
+		atoms, bonds, bondTypes, atomIndices = stripHAtoms(atoms, bonds, bondTypes, atomIndices)
+		if stereo != nil {
+			stereo = stripHStereo(stereo, block.atoms)
+		}
+	case HydrogensAdd:
+		// counted from the mol file bonds, so they are the same as in MolNumberingHydrogens
+		counts, err := implicitHydrogens(block.atoms, block.bonds, molBondTypes)
+		if err != nil {
+			return Graph{}, err
+		}
+		var added map[int][]int
+		atoms, bonds, bondTypes, atomIndices, added = addHAtoms(atoms, bonds, bondTypes, atomIndices, counts)
+		if stereo != nil {
+			stereo = addHStereo(stereo, added)
+		}
+	}
+	g, err := molGraphColouring(atoms, bonds, bondTypes, atomIndices, colouring)
+	g.Stereo = stereo
+	return g, err
+}
+
+// molGraph builds and validates a colour graph from parsed mol file data
+func molGraph(atomTypes []string, bonds [][2]int, bondTypes []int, atomIndices []int) (Graph, error) {
+	return molGraphColouring(molAtoms(atomTypes), bonds, bondTypes, atomIndices, Colouring{})
+}
+
+// molGraphColouring builds and validates a colour graph from parsed mol file data, with the colours chosen by colouring
+func molGraphColouring(atoms []MolAtom, bonds [][2]int, bondTypes []int, atomIndices []int, colouring Colouring) (Graph, error) {
+	atomColours := colouring.atomColours(atoms, bonds, bondTypes)
+	bondColours := colouring.bondColours(bondTypes)
+	outGraph := NewColourGraphFromIDs(atomIndices, bonds, Colours.InternSlice(atomColours), Colours.InternSlice(bondColours))
+	return outGraph, ValidateGraph(&outGraph)
+}
+
+// ParseMultiMolString parses string input that is in the form of an sdfile, i.e. a sequence of mol blocks with $$$$ as delimiter.
+// Hydrogen atoms are removed if stripH is true, and kept if not
 func ParseMultiMolString(multiMolString string, stripH bool) []Graph {
+	return ParseMultiMolStringColouring(multiMolString, stripH, Colouring{})
+}
+
+// ParseMultiMolStringColouring is the same as ParseMultiMolString, with the colours chosen by colouring. If stripH is
+// false and colouring.Hydrogens is HydrogensStrip, the hydrogen atoms are kept
+func ParseMultiMolStringColouring(multiMolString string, stripH bool, colouring Colouring) []Graph {
+	if !stripH && colouring.Hydrogens == HydrogensStrip {
+		colouring.Hydrogens = HydrogensKeep
+	}
 	multiMolString = strings.ReplaceAll(multiMolString, "\r\n", "\n")  // deal with windows insertion of carriage return
 	mols := strings.Split(multiMolString, "$$$$\n")
 	var molGraphs []Graph
 	for _, mol := range mols{
-		molGraph := MolBlockColourGraph(mol)
+		molGraph, err := MolGraphFromStringColouring(mol, colouring)
+		check(err)
 		if len(molGraph.Vertices) != 0 {
 			molGraphs = append(molGraphs, molGraph)
 		}
@@ -73,6 +139,26 @@ func ParseMultiMolString(multiMolString string, stripH bool) []Graph {
 }
 
 
+// ValidateMultiMolString parses sdfile style input in the same way as ParseMultiMolString, but returns the validation
+// error for each mol block (nil if valid) rather than stopping at the first invalid one
+func ValidateMultiMolString(multiMolString string) ([]Graph, []error) {
+	multiMolString = strings.ReplaceAll(multiMolString, "\r\n", "\n")
+	mols := strings.Split(multiMolString, "$$$$\n")
+	var molGraphs []Graph
+	var errs []error
+	for _, mol := range mols{
+		molGraph, err := MolGraphFromString(mol)
+		if len(molGraph.Vertices) != 0 || err != nil {
+			molGraphs = append(molGraphs, molGraph)
+			errs = append(errs, err)
+		}
+	}
+	return molGraphs, errs
+}
+
+// MolListToPathway makes a starting pathway from a list of graphs, e.g. from an sdf file. The first graph is the original
+// graph, the last the remnant, and the others the duplicated graphs. duplicates gives the occurrences of each duplicated
+// graph (see NewDuplicates), or use PathwayWithDuplicates to find the remnant from the original graph
 func MolListToPathway(mols []Graph, duplicates []Duplicates) (Graph, Pathway){
 	// TODO: validate inputs
 	originalGraph := mols[0]
@@ -87,17 +173,55 @@ func MolListToPathway(mols []Graph, duplicates []Duplicates) (Graph, Pathway){
 
 }
 
+// ParseSDFile returns the graphs of the mol blocks in an sdfile, see ParseMultiMolString
 func ParseSDFile(filePath string, stripH bool) []Graph {
+	return ParseSDFileColouring(filePath, stripH, Colouring{})
+}
+
+// ParseSDFileColouring is the same as ParseSDFile, with the colours chosen by colouring
+func ParseSDFileColouring(filePath string, stripH bool, colouring Colouring) []Graph {
 	fileBytes, _ := ioutil.ReadFile(filePath)
 	fileString := string(fileBytes)
-	return ParseMultiMolString(fileString, stripH)
+	return ParseMultiMolStringColouring(fileString, stripH, colouring)
 }
 
 func ParseMolScanner(scanner *bufio.Scanner, stripH bool)([]string, [][2]int, []int, []int){
-	var atoms []string
-	var atomIndices []int
-	var bonds [][2]int
-	var bondTypes []int
+	atoms, bonds, bondTypes, atomIndices := ParseMolScannerAtoms(scanner, stripH)
+	return molElements(atoms), bonds, bondTypes, atomIndices
+}
+
+// ParseMolScannerAtoms is the same as ParseMolScanner, but gives the attributes of each atom rather than just the element.
+// Charges, radicals and isotopes are read from the atom block (the old style charge and mass difference columns), unless
+// the properties block has M  CHG or M  RAD lines, which replace all the charges and radicals from the atom block, or
+// M  ISO lines, which replace all the isotopes
+func ParseMolScannerAtoms(scanner *bufio.Scanner, stripH bool)([]MolAtom, [][2]int, []int, []int){
+	block, err := parseMolBlock(scanner)
+	check(err)
+	if stripH{
+		return stripHAtoms(block.atoms, block.bonds, block.bondTypes, block.atomIndices)
+	} else {
+		return block.atoms, block.bonds, block.bondTypes, block.atomIndices
+	}
+}
+
+// molBlock is everything read from a mol block, including the hydrogen atoms and what is needed for stereochemistry:
+// the atom coordinates, the atom parities (0 none, 1 odd, 2 even, 3 either) and the bond stereo flags (0 none, 1 wedge,
+// 4 either, 6 hash for single bonds, 3 either for double bonds)
+type molBlock struct {
+	atoms       []MolAtom
+	coordinates [][3]float64
+	parities    []int
+	bonds       [][2]int
+	bondTypes   []int
+	bondStereo  []int
+	atomIndices []int
+}
+
+// parseMolBlock reads a mol block from a scanner, see ParseMolScannerAtoms. An error is returned if the counts line,
+// atom block, bond block or properties block can't be read
+func parseMolBlock(scanner *bufio.Scanner) (molBlock, error) {
+	var block molBlock
+	var properties []string
 
 	i := 0
 	atNum := 0
@@ -105,14 +229,21 @@ func ParseMolScanner(scanner *bufio.Scanner, stripH bool)([]string, [][2]int, []
 	for scanner.Scan() {
 		if i == 3 {
 			line3 := scanner.Text()
+			if len(line3) < 6 {
+				return block, fmt.Errorf("bad counts line %q", line3)
+			}
 
 			atomString := strings.ReplaceAll(line3[:3], " ", "")
 			bondString := strings.ReplaceAll(line3[3:6], " ", "")
 
 			atoms, err := strconv.Atoi(atomString)
-			check(err)
+			if err != nil {
+				return block, fmt.Errorf("bad counts line %q", line3)
+			}
 			bonds, err := strconv.Atoi(bondString)
-			check(err)
+			if err != nil {
+				return block, fmt.Errorf("bad counts line %q", line3)
+			}
 
 			atomEnd = 4 + atoms
 			bondEnd = atomEnd + bonds
@@ -121,10 +252,17 @@ func ParseMolScanner(scanner *bufio.Scanner, stripH bool)([]string, [][2]int, []
 
 		// atom block
 		if i >= 4 && i < atomEnd {
-			line := strings.Fields(scanner.Text())
-			atoms = append(atoms, line[3])
+			atomLine := scanner.Text()
+			atom, err := parseAtomLine(atomLine)
+			if err != nil {
+				return block, err
+			}
+			block.atoms = append(block.atoms, atom)
+			coordinates, parity := parseAtomStereo(atomLine)
+			block.coordinates = append(block.coordinates, coordinates)
+			block.parities = append(block.parities, parity)
 
-			atomIndices = append(atomIndices, atNum)
+			block.atomIndices = append(block.atomIndices, atNum)
 			atNum++
 		}
 
@@ -132,39 +270,189 @@ func ParseMolScanner(scanner *bufio.Scanner, stripH bool)([]string, [][2]int, []
 		if i >= atomEnd && i < bondEnd {
 
 			bondLine := scanner.Text()
+			if len(bondLine) < 9 {
+				return block, fmt.Errorf("bad bond line %q", bondLine)
+			}
 			at1String := strings.ReplaceAll(bondLine[:3], " ", "")
 			at2String := strings.ReplaceAll(bondLine[3:6], " ", "")
 			typeString := strings.ReplaceAll(bondLine[6:9], " ", "")
 
 			// line := strings.Fields(scanner.Text())
-			at1, err := strconv.Atoi(at1String)
-			check(err)
-			at2, err := strconv.Atoi(at2String)
-			check(err)
-			bondType, err := strconv.Atoi(typeString)
-			check(err)
-			bonds = append(bonds, [2]int{at1 - 1, at2 - 1}) // -1 as changing to zero indexing
-			bondTypes = append(bondTypes, bondType)
+			at1, err1 := strconv.Atoi(at1String)
+			at2, err2 := strconv.Atoi(at2String)
+			bondType, err3 := strconv.Atoi(typeString)
+			if err1 != nil || err2 != nil || err3 != nil {
+				return block, fmt.Errorf("bad bond line %q", bondLine)
+			}
+			if at1 < 1 || at1 > len(block.atoms) || at2 < 1 || at2 > len(block.atoms) {
+				return block, fmt.Errorf("bad atom number in bond line %q", bondLine)
+			}
+			block.bonds = append(block.bonds, [2]int{at1 - 1, at2 - 1}) // -1 as changing to zero indexing
+			block.bondTypes = append(block.bondTypes, bondType)
 
+			bondStereo := 0
+			if len(bondLine) >= 12 {
+				bondStereo, _ = strconv.Atoi(strings.TrimSpace(bondLine[9:12]))
+			}
+			block.bondStereo = append(block.bondStereo, bondStereo)
+		}
+
+		// properties block, up to the end of the mol block (an sdf file may have data items after it)
+		if i >= 4 && i >= bondEnd {
+			line := scanner.Text()
+			if strings.HasPrefix(line, "M  END") {
+				break
+			}
+			properties = append(properties, line)
 		}
 
 		i++
 	}
 
+	if i > 3 && i < bondEnd {
+		return block, fmt.Errorf("mol block ends before its %v atoms and %v bonds", atomEnd-4, bondEnd-atomEnd)
+	}
+	return block, applyMolProperties(block.atoms, properties)
+}
 
-	if stripH{
-		return stripHAtoms(atoms, bonds, bondTypes, atomIndices)
-	} else {
-		return atoms, bonds, bondTypes, atomIndices
+// parseAtomLine reads an atom block line. The element is the fourth field, and the mass difference and charge are the
+// fixed width columns after it: the mass difference (-3 to 4) is from the mass number in standardMassNumbers, and the
+// charge is 1, 2, 3 for +3, +2, +1, 4 for a doublet radical, and 5, 6, 7 for -1, -2, -3. An error is returned if there
+// is no element, or a mass difference for an element with no standard mass number
+func parseAtomLine(line string) (MolAtom, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return MolAtom{}, fmt.Errorf("bad atom line %q", line)
+	}
+	atom := MolAtom{Element: fields[3]}
+	column := func(start int, end int) int {
+		if len(line) < end {
+			return 0
+		}
+		value, err := strconv.Atoi(strings.TrimSpace(line[start:end]))
+		if err != nil {
+			return 0
+		}
+		return value
+	}
+
+	if massDifference := column(34, 36); massDifference != 0 {
+		mass, ok := standardMassNumbers[atom.Element]
+		if !ok {
+			return atom, fmt.Errorf("mass difference for %v, which has no standard mass number", atom.Element)
+		}
+		atom.Isotope = mass + massDifference
+	}
+
+	switch charge := column(36, 39); {
+	case charge >= 1 && charge <= 3:
+		atom.Charge = 4 - charge
+	case charge == 4:
+		atom.Radical = 2
+	case charge >= 5 && charge <= 7:
+		atom.Charge = 4 - charge
+	}
+	return atom, nil
+}
+
+// parseAtomStereo reads the coordinates (the first three fields) and the atom parity (the fixed width column after the
+// charge) of an atom block line. Values that can't be read are 0
+func parseAtomStereo(line string) ([3]float64, int) {
+	var coordinates [3]float64
+	fields := strings.Fields(line)
+	for j := 0; j < 3 && j < len(fields); j++ {
+		coordinates[j], _ = strconv.ParseFloat(fields[j], 64)
+	}
+	parity := 0
+	if len(line) >= 42 {
+		parity, _ = strconv.Atoi(strings.TrimSpace(line[39:42]))
 	}
+	return coordinates, parity
+}
+
+// applyMolProperties sets the charges, radicals and isotopes of atoms from the M  CHG, M  RAD and M  ISO lines of a
+// properties block. Each line is M  XXX followed by the number of entries, then an atom number (counting from 1) and a
+// value for each entry
+func applyMolProperties(atoms []MolAtom, properties []string) error {
+	values := make(map[string][][2]int)
+	for _, line := range properties {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "M" || (fields[1] != "CHG" && fields[1] != "RAD" && fields[1] != "ISO") {
+			continue
+		}
+		count, err := strconv.Atoi(fields[2])
+		if err != nil || len(fields) != 3+2*count {
+			return fmt.Errorf("bad properties line %q", line)
+		}
+		for j := 3; j < len(fields); j += 2 {
+			atom, err := strconv.Atoi(fields[j])
+			if err != nil || atom < 1 || atom > len(atoms) {
+				return fmt.Errorf("bad atom number %v in properties line %q", fields[j], line)
+			}
+			value, err := strconv.Atoi(fields[j+1])
+			if err != nil {
+				return fmt.Errorf("bad value %v in properties line %q", fields[j+1], line)
+			}
+			values[fields[1]] = append(values[fields[1]], [2]int{atom - 1, value})
+		}
+	}
+
+	// any charge or radical line replaces all the charges and radicals from the atom block
+	if len(values["CHG"]) > 0 || len(values["RAD"]) > 0 {
+		for i := range atoms {
+			atoms[i].Charge = 0
+			atoms[i].Radical = 0
+		}
+	}
+	if len(values["ISO"]) > 0 {
+		for i := range atoms {
+			atoms[i].Isotope = 0
+		}
+	}
+	for _, v := range values["CHG"] {
+		atoms[v[0]].Charge = v[1]
+	}
+	for _, v := range values["RAD"] {
+		atoms[v[0]].Radical = v[1]
+	}
+	for _, v := range values["ISO"] {
+		atoms[v[0]].Isotope = v[1]
+	}
+	return nil
+}
+
+// standardMassNumbers are the mass numbers that atom block mass differences are from, i.e. the standard atomic weights
+// rounded to whole numbers
+var standardMassNumbers = map[string]int{
+	"H": 1, "He": 4, "Li": 7, "Be": 9, "B": 11, "C": 12, "N": 14, "O": 16, "F": 19, "Ne": 20,
+	"Na": 23, "Mg": 24, "Al": 27, "Si": 28, "P": 31, "S": 32, "Cl": 35, "Ar": 40, "K": 39, "Ca": 40,
+	"Sc": 45, "Ti": 48, "V": 51, "Cr": 52, "Mn": 55, "Fe": 56, "Co": 59, "Ni": 59, "Cu": 64, "Zn": 65,
+	"Ga": 70, "Ge": 73, "As": 75, "Se": 79, "Br": 80, "Kr": 84, "Rb": 85, "Sr": 88, "Y": 89, "Zr": 91,
+	"Nb": 93, "Mo": 96, "Ru": 101, "Rh": 103, "Pd": 106, "Ag": 108, "Cd": 112, "In": 115, "Sn": 119, "Sb": 122,
+	"Te": 128, "I": 127, "Xe": 131, "Cs": 133, "Ba": 137, "Pt": 195, "Au": 197, "Hg": 201, "Pb": 207, "Bi": 209,
+}
+
+// molElements returns the element of each atom
+func molElements(atoms []MolAtom) []string {
+	elements := make([]string, len(atoms))
+	for i, atom := range atoms {
+		elements[i] = atom.Element
+	}
+	return elements
 }
 
 // ParseMolFile extracts lists of atoms, bonds, bond types from a mol file
 func ParseMolFile(filePath string, stripH bool) ([]string, [][2]int, []int, []int) {
+	atoms, bonds, bondTypes, atomIndices := ParseMolFileAtoms(filePath, stripH)
+	return molElements(atoms), bonds, bondTypes, atomIndices
+}
+
+// ParseMolFileAtoms extracts lists of atom attributes, bonds, bond types from a mol file, see ParseMolScannerAtoms
+func ParseMolFileAtoms(filePath string, stripH bool) ([]MolAtom, [][2]int, []int, []int) {
 	f, err := os.Open(filePath)
 	check(err)
 	scanner := bufio.NewScanner(f)
-	atoms, bonds, bondTypes, atomIndices := ParseMolScanner(scanner, stripH)
+	atoms, bonds, bondTypes, atomIndices := ParseMolScannerAtoms(scanner, stripH)
 
 	cErr := f.Close()
 	check(cErr)
@@ -175,15 +463,21 @@ func ParseMolFile(filePath string, stripH bool) ([]string, [][2]int, []int, []in
 
 // ParseMolString extracts lists of atoms, bonds, bond types from a string of a mol block
 func ParseMolString(molString string, stripH bool)([]string, [][2]int, []int, []int){
+	atoms, bonds, bondTypes, atomIndices := ParseMolStringAtoms(molString, stripH)
+	return molElements(atoms), bonds, bondTypes, atomIndices
+}
+
+// ParseMolStringAtoms extracts lists of atom attributes, bonds, bond types from a string of a mol block, see
+// ParseMolScannerAtoms
+func ParseMolStringAtoms(molString string, stripH bool)([]MolAtom, [][2]int, []int, []int){
 	scanner := bufio.NewScanner(strings.NewReader(molString))
-	atoms, bonds, bondTypes, atomIndices := ParseMolScanner(scanner, stripH)
-	return atoms, bonds, bondTypes, atomIndices
+	return ParseMolScannerAtoms(scanner, stripH)
 }
 
 // stripHAtoms takes out all the H atoms, while maintaining the correct connectivity etc
-func stripHAtoms(atoms []string, bonds [][2] int, bondTypes []int, atomIndices []int)([]string, [][2]int, []int, []int){
+func stripHAtoms(atoms []MolAtom, bonds [][2] int, bondTypes []int, atomIndices []int)([]MolAtom, [][2]int, []int, []int){
 		atomMap := make(map[int]int)
-		var newAtoms []string
+		var newAtoms []MolAtom
 		var newBonds [][2]int
 		var newBondTypes []int
 		var newAtomIndices []int
@@ -192,7 +486,7 @@ func stripHAtoms(atoms []string, bonds [][2] int, bondTypes []int, atomIndices [
 		// update atoms
 		newInd := 0
 		for i := 0; i < len(atoms); i++{
-			if atoms[i] != "H" {
+			if atoms[i].Element != "H" {
 				atomMap[atomIndices[i]] = newInd
 				newAtomIndices = append(newAtomIndices, newInd)
 				newAtoms = append(newAtoms, atoms[i])
@@ -156,6 +156,89 @@ func TestParseMolString(t *testing.T) {
 
 }
 
+func TestParseMolFileAtoms(t *testing.T) {
+	glycine := []MolAtom{{"C", 0, 0, 0}, {"C", 0, 0, 0}, {"N", 0, 0, 0}, {"O", 0, 0, 0}, {"O", 0, 0, 0}}
+	zwitterion := []MolAtom{{"C", 0, 0, 0}, {"C", 0, 0, 0}, {"N", 1, 0, 0}, {"O", 0, 0, 0}, {"O", -1, 0, 0}}
+	var tests = []struct {
+		fileName string
+		atoms    []MolAtom
+	}{
+		{"testdata/glycine_with_H.mol", glycine},
+		{"testdata/glycine_zwitterion.mol", zwitterion},
+		{"testdata/glycine_zwitterion_atom_block.mol", zwitterion},
+		{"testdata/glycine_13C_atom_block.mol", []MolAtom{{"C", 0, 0, 0}, {"C", 0, 13, 0}, {"N", 0, 0, 0}, {"O", 0, 0, 0}, {"O", 0, 0, 0}}},
+		{"testdata/glycine_13C.mol", []MolAtom{{"C", 0, 0, 0}, {"C", 0, 13, 0}, {"N", 0, 15, 0}, {"O", 0, 0, 0}, {"O", 0, 0, 0}}},
+	}
+
+	for _, tt := range tests {
+		atoms, _, _, _ := ParseMolFileAtoms(tt.fileName, true)
+		if !reflect.DeepEqual(atoms, tt.atoms) {
+			t.Errorf("ParseMolFileAtoms %v expected %v, got %v", tt.fileName, tt.atoms, atoms)
+		}
+	}
+}
+
+func TestApplyMolProperties(t *testing.T) {
+	atoms := []MolAtom{{"C", 1, 14, 2}, {"C", 0, 0, 0}, {"O", 0, 0, 0}}
+
+	// the radical line replaces the atom block charges too, but not the isotopes
+	err := applyMolProperties(atoms, []string{"M  RAD  1   2   3", "M  ALS   1  2 F O   S"})
+	expected := []MolAtom{{"C", 0, 14, 0}, {"C", 0, 0, 3}, {"O", 0, 0, 0}}
+	if err != nil || !reflect.DeepEqual(atoms, expected) {
+		t.Errorf("applyMolProperties expected %v, got %v %v", expected, atoms, err)
+	}
+
+	errorTests := []string{"M  CHG  2   1   1", "M  CHG  1   4   1", "M  ISO  1   1  x"}
+	for _, line := range errorTests {
+		if err := applyMolProperties(atoms, []string{line}); err == nil {
+			t.Errorf("applyMolProperties should give an error for %v", line)
+		}
+	}
+}
+
+func TestMolGraphFromStringErrors(t *testing.T) {
+	header := "bad\n\n\n"
+	atom := "    0.0000    0.0000    0.0000 C   0  0  0  0  0  0  0  0  0  0  0  0\n"
+	errorTests := map[string]string{
+		"counts line":  header + "  x  1  0  0  0  0  0  0  0  0999 V2000\n",
+		"short counts": header + "  2\n",
+		"atom line":    header + "  1  0  0  0  0  0  0  0  0  0999 V2000\n    0.0000\nM  END\n",
+		"bond line":    header + "  2  1  0  0  0  0  0  0  0  0999 V2000\n" + atom + atom + "  1  x  1\nM  END\n",
+		"bond atom":    header + "  2  1  0  0  0  0  0  0  0  0999 V2000\n" + atom + atom + "  1  3  1\nM  END\n",
+		"short bond":   header + "  2  1  0  0  0  0  0  0  0  0999 V2000\n" + atom + atom + "  1  2\nM  END\n",
+		"truncated":    header + "  2  1  0  0  0  0  0  0  0  0999 V2000\n" + atom,
+		"properties":   header + "  1  0  0  0  0  0  0  0  0  0999 V2000\n" + atom + "M  CHG  1   2   1\nM  END\n",
+	}
+	for name, molBlock := range errorTests {
+		if _, err := MolGraphFromString(molBlock); err == nil {
+			t.Errorf("MolGraphFromString should give an error for a bad %v", name)
+		}
+	}
+}
+
+func TestMolGraphCharges(t *testing.T) {
+	neutral := MolColourGraph("testdata/glycine_with_H.mol")
+	zwitterion := MolColourGraph("testdata/glycine_zwitterion.mol")
+	if !GraphsIsomorphic(&neutral, &zwitterion) {
+		t.Errorf("glycine and its zwitterion should be the same coloured by element")
+	}
+
+	for _, colouring := range []Colouring{{Charge: true}, {Isotope: true}} {
+		var graphs []Graph
+		for _, molFile := range []string{"testdata/glycine_with_H.mol", "testdata/glycine_zwitterion.mol", "testdata/glycine_13C.mol"} {
+			g, err := MolGraphFromFileColouring(molFile, colouring)
+			check(err)
+			graphs = append(graphs, g)
+		}
+		if GraphsIsomorphic(&graphs[0], &graphs[1]) == colouring.Charge {
+			t.Errorf("glycine and its zwitterion coloured by %v should only be different coloured by charge", colouring)
+		}
+		if GraphsIsomorphic(&graphs[0], &graphs[2]) == colouring.Isotope {
+			t.Errorf("glycine and 13C glycine coloured by %v should only be different coloured by isotope", colouring)
+		}
+	}
+}
+
 func TestParseMultiMolString(t *testing.T) {
 	fileName := "testdata/dual_ring_test.sdf"
 	molBytes, _ := ioutil.ReadFile(fileName)
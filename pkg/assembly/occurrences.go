@@ -0,0 +1,169 @@
+package assembly
+
+import (
+	"GoAssembly/pkg/helpers"
+	"fmt"
+	"sort"
+)
+
+// Code for mapping a pathway back to the original graph. The duplicates in a pathway are given in the vertex labels of
+// the remnant at the step they were found, which are relabelled as duplicates are broken off (see atomEquivalents). Here
+// each occurrence of a duplicated fragment, and the remnant, is given as vertices and edge positions of the original graph,
+// and for molecules as the atom and bond numbers of the mol file, e.g. to highlight repeated substructures
+
+// FragmentOccurrence is where a fragment occurs in the original graph. Vertices are original vertex labels and Edges are
+// positions in the original Edges. Atoms and Bonds are the mol file atom and bond numbers (counting from 1, as in the
+// mol file), and are only set for molecules (see MolNumbering)
+type FragmentOccurrence struct {
+	Vertices []int
+	Edges    []int
+	Atoms    []int
+	Bonds    []int
+}
+
+// MolNumbering maps a graph made from a mol file back to the mol file. Atoms[v] is the mol file atom number of vertex v,
+// and Bonds[e] is the mol file bond number of edge e. These differ from the graph when hydrogen atoms are removed, and
+// hydrogen atoms and bonds added to the graph (see HydrogensAdd) have number 0, as they aren't in the mol file
+type MolNumbering struct {
+	Atoms []int
+	Bonds []int
+}
+
+// MolNumberingFromFile returns the MolNumbering for the graph from MolGraphFromFile
+func MolNumberingFromFile(molFile string) MolNumbering {
+	atoms, bonds, _, _ := ParseMolFile(molFile, false)
+	return molNumbering(atoms, bonds, true)
+}
+
+// MolNumberingFromString returns the MolNumbering for the graph from MolGraphFromString
+func MolNumberingFromString(molBlock string) MolNumbering {
+	atoms, bonds, _, _ := ParseMolString(molBlock, false)
+	return molNumbering(atoms, bonds, true)
+}
+
+// MolNumberingHydrogens returns the MolNumbering for the graph from MolGraphFromFileColouring with hydrogens as the
+// Hydrogens option. An error is returned if the implicit hydrogens can't be found
+func MolNumberingHydrogens(molFile string, hydrogens Hydrogens) (MolNumbering, error) {
+	atoms, bonds, bondTypes, _ := ParseMolFileAtoms(molFile, false)
+	return molNumberingHydrogens(atoms, bonds, bondTypes, hydrogens)
+}
+
+// MolNumberingHydrogensString is the same as MolNumberingHydrogens, for the graph from MolGraphFromStringColouring
+func MolNumberingHydrogensString(molBlock string, hydrogens Hydrogens) (MolNumbering, error) {
+	atoms, bonds, bondTypes, _ := ParseMolStringAtoms(molBlock, false)
+	return molNumberingHydrogens(atoms, bonds, bondTypes, hydrogens)
+}
+
+// molNumberingHydrogens finds the mol file numbers of the atoms and bonds of the graph with hydrogens as the Hydrogens
+// option. Added hydrogen atoms and their bonds come after the others (see addHAtoms), so they are numbered 0 at the end
+func molNumberingHydrogens(atoms []MolAtom, bonds [][2]int, bondTypes []int, hydrogens Hydrogens) (MolNumbering, error) {
+	numbering := molNumbering(molElements(atoms), bonds, hydrogens == HydrogensStrip)
+	if hydrogens != HydrogensAdd {
+		return numbering, nil
+	}
+	counts, err := implicitHydrogens(atoms, bonds, bondTypes)
+	if err != nil {
+		return MolNumbering{}, err
+	}
+	for _, count := range counts {
+		for i := 0; i < count; i++ {
+			numbering.Atoms = append(numbering.Atoms, 0)
+			numbering.Bonds = append(numbering.Bonds, 0)
+		}
+	}
+	return numbering, nil
+}
+
+// molNumbering finds the mol file numbers of the atoms and bonds that are kept in the graph. The graph keeps them in
+// mol file order, so vertex and edge positions are the positions of the kept atoms and bonds
+func molNumbering(atoms []string, bonds [][2]int, stripH bool) MolNumbering {
+	numbering := MolNumbering{[]int{}, []int{}}
+	for i, atom := range atoms {
+		if !stripH || atom != "H" {
+			numbering.Atoms = append(numbering.Atoms, i+1)
+		}
+	}
+	for i, b := range bonds {
+		if !stripH || (atoms[b[0]] != "H" && atoms[b[1]] != "H") {
+			numbering.Bonds = append(numbering.Bonds, i+1)
+		}
+	}
+	return numbering
+}
+
+// PathwayOccurrences returns the two occurrences in the original graph of each duplicated fragment of the pathway, the
+// left (which is broken off) then the right (which stays in the remnant), and the occurrence of the final remnant.
+// If numbering is not nil, the occurrences also have mol file atom and bond numbers. An error is returned if the
+// pathway is not consistent with the original graph (see VerifyPathway)
+func PathwayOccurrences(pathway *Pathway, originalGraph *Graph, numbering *MolNumbering) ([][2]FragmentOccurrence, FragmentOccurrence, error) {
+	occurrences := make([][2]FragmentOccurrence, len(pathway.duplicates))
+
+	// the lefts and the remnant split up the original edges, but the rights overlap them
+	partFinder := newOriginalEdgeFinder(originalGraph)
+	for i, d := range pathway.duplicates {
+		left, err := partFinder.find(pathway, d.left)
+		if err != nil {
+			return nil, FragmentOccurrence{}, fmt.Errorf("duplicate %v left: %v", i, err)
+		}
+		rightFinder := newOriginalEdgeFinder(originalGraph)
+		right, err := rightFinder.find(pathway, d.right)
+		if err != nil {
+			return nil, FragmentOccurrence{}, fmt.Errorf("duplicate %v right: %v", i, err)
+		}
+		occurrences[i] = [2]FragmentOccurrence{
+			edgeOccurrence(originalGraph, left, numbering),
+			edgeOccurrence(originalGraph, right, numbering),
+		}
+	}
+
+	remnantEdges, err := partFinder.find(pathway, pathway.remnant.Edges)
+	if err != nil {
+		return nil, FragmentOccurrence{}, fmt.Errorf("remnant: %v", err)
+	}
+	return occurrences, edgeOccurrence(originalGraph, remnantEdges, numbering), nil
+}
+
+// edgeOccurrence makes the occurrence of original edge positions, with the vertices they join
+func edgeOccurrence(originalGraph *Graph, edges []int, numbering *MolNumbering) FragmentOccurrence {
+	sort.Ints(edges)
+	occurrence := FragmentOccurrence{Edges: edges}
+	for _, e := range edges {
+		for _, v := range originalGraph.Edges[e] {
+			if !helpers.Contains(occurrence.Vertices, v) {
+				occurrence.Vertices = append(occurrence.Vertices, v)
+			}
+		}
+	}
+	sort.Ints(occurrence.Vertices)
+
+	if numbering != nil {
+		for _, v := range occurrence.Vertices {
+			occurrence.Atoms = append(occurrence.Atoms, numbering.Atoms[v])
+		}
+		for _, e := range occurrence.Edges {
+			occurrence.Bonds = append(occurrence.Bonds, numbering.Bonds[e])
+		}
+	}
+	return occurrence
+}
+
+// OccurrencesString returns the occurrences from PathwayOccurrences, one per line, as atom and bond numbers if they are
+// set, or original vertices and edge positions if not
+func OccurrencesString(occurrences [][2]FragmentOccurrence, remnant FragmentOccurrence) string {
+	outString := "Fragment Occurrences\n"
+	for i, o := range occurrences {
+		outString += fmt.Sprintf("%v left %v\n", i, o[0])
+		outString += fmt.Sprintf("%v right %v\n", i, o[1])
+	}
+	outString += fmt.Sprintf("remnant %v\n", remnant)
+	outString += "+++++++++++++++\n"
+	return outString
+}
+
+// String returns the atoms and bonds of the occurrence, or the vertices and edges for graphs that are not molecules
+func (o FragmentOccurrence) String() string {
+	if o.Atoms != nil {
+		return fmt.Sprintf("atoms %v bonds %v", o.Atoms, o.Bonds)
+	}
+	return fmt.Sprintf("vertices %v edges %v", o.Vertices, o.Edges)
+}
@@ -0,0 +1,91 @@
+package assembly
+
+import (
+	"GoAssembly/pkg/helpers"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestMolNumbering(t *testing.T) {
+	numbering := MolNumberingFromFile("testdata/formic_acid_with_H.mol")
+	g := MolColourGraph("testdata/formic_acid_with_H.mol")
+	if len(numbering.Atoms) != len(g.Vertices) || len(numbering.Bonds) != len(g.Edges) {
+		t.Errorf("MolNumbering has %v atoms and %v bonds, graph has %v vertices and %v edges",
+			len(numbering.Atoms), len(numbering.Bonds), len(g.Vertices), len(g.Edges))
+	}
+
+	atoms, bonds, _, _ := ParseMolFile("testdata/formic_acid_with_H.mol", false)
+	for i, v := range g.Vertices {
+		if atoms[numbering.Atoms[v]-1] != g.VertexColourNames()[i] {
+			t.Errorf("MolNumbering vertex %v is atom %v, which is %v not %v", v, numbering.Atoms[v], atoms[numbering.Atoms[v]-1], g.VertexColourNames()[i])
+		}
+	}
+	for e, edge := range g.Edges {
+		bond := bonds[numbering.Bonds[e]-1]
+		if atoms[bond[0]] == "H" || atoms[bond[1]] == "H" {
+			t.Errorf("MolNumbering edge %v %v is bond %v to a hydrogen", e, edge, numbering.Bonds[e])
+		}
+	}
+}
+
+func TestPathwayOccurrences(t *testing.T) {
+	molFile := "testdata/aspirin_with_H.mol"
+	g := MolColourGraph(molFile)
+	numbering := MolNumberingFromFile(molFile)
+	atoms, bonds, _, _ := ParseMolFile(molFile, false)
+	pathway := Assembly(g, 100, 100, "shortest")[0]
+
+	occurrences, remnant, err := PathwayOccurrences(&pathway, &g, &numbering)
+	if err != nil {
+		t.Fatalf("PathwayOccurrences error: %v", err)
+	}
+	if len(occurrences) != len(pathway.duplicates) {
+		t.Fatalf("PathwayOccurrences gave %v occurrences for %v duplicates", len(occurrences), len(pathway.duplicates))
+	}
+
+	// the bonds of each occurrence are between its atoms, and both occurrences have the same elements
+	var partBonds []int
+	for i, o := range occurrences {
+		var elements [2][]string
+		for side, occurrence := range o {
+			for _, b := range occurrence.Bonds {
+				if !helpers.Contains(occurrence.Atoms, bonds[b-1][0]+1) || !helpers.Contains(occurrence.Atoms, bonds[b-1][1]+1) {
+					t.Errorf("PathwayOccurrences duplicate %v bond %v is not between atoms %v", i, b, occurrence.Atoms)
+				}
+			}
+			for _, a := range occurrence.Atoms {
+				elements[side] = append(elements[side], atoms[a-1])
+			}
+			sort.Strings(elements[side])
+		}
+		if !reflect.DeepEqual(elements[0], elements[1]) {
+			t.Errorf("PathwayOccurrences duplicate %v has atoms %v and %v", i, elements[0], elements[1])
+		}
+		partBonds = append(partBonds, o[0].Bonds...)
+	}
+
+	// the lefts and the remnant are all the bonds not to hydrogen
+	partBonds = append(partBonds, remnant.Bonds...)
+	sort.Ints(partBonds)
+	if !reflect.DeepEqual(partBonds, numbering.Bonds) {
+		t.Errorf("PathwayOccurrences lefts and remnant have bonds %v, expected %v", partBonds, numbering.Bonds)
+	}
+}
+
+func TestPathwayOccurrencesGraph(t *testing.T) {
+	g := NewGraphOnlyFromFile("testdata/graphs/square.txt")
+	pathway := Assembly(g, 100, 100, "shortest")[0]
+	occurrences, remnant, err := PathwayOccurrences(&pathway, &g, nil)
+	if err != nil {
+		t.Fatalf("PathwayOccurrences error: %v", err)
+	}
+	if len(occurrences) != 1 || len(occurrences[0][0].Edges) != 2 || occurrences[0][0].Atoms != nil {
+		t.Errorf("PathwayOccurrences on a square should give one pair of occurrences of two edges, got %v", occurrences)
+	}
+	parts := append(append([]int{}, occurrences[0][0].Edges...), remnant.Edges...)
+	sort.Ints(parts)
+	if !reflect.DeepEqual(parts, []int{0, 1, 2, 3}) {
+		t.Errorf("PathwayOccurrences left and remnant should be all the edges, got %v", parts)
+	}
+}
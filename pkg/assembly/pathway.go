@@ -0,0 +1,139 @@
+package assembly
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Code for using pathways outside of this package: read only accessors for the parts of a Pathway, and builders for
+// starting pathways that already have some duplicates, e.g. to pass to AssemblyPathway. The accessors return copies, so
+// changing them doesn't change the pathway
+
+// Fragments returns the duplicated graphs of the pathway, in the order they were found. Each is the left of the
+// Duplicates at the same position
+func (pathway *Pathway) Fragments() []Graph {
+	fragments := make([]Graph, len(pathway.pathway))
+	for i := range pathway.pathway {
+		fragments[i] = CopyGraph(&pathway.pathway[i])
+	}
+	return fragments
+}
+
+// Duplicates returns the edges of both occurrences of each fragment, in the vertex labels of the remnant at the step the
+// fragment was found. Use PathwayOccurrences for the occurrences in the original graph
+func (pathway *Pathway) Duplicates() []Duplicates {
+	return CopyDuplicates(pathway.duplicates)
+}
+
+// Remnant returns the remnant graph, the part of the original graph left once the fragments are broken off
+func (pathway *Pathway) Remnant() Graph {
+	return CopyGraph(&pathway.remnant)
+}
+
+// RemnantComponents returns the connected components of the remnant, each of which is built separately
+func (pathway *Pathway) RemnantComponents() []Graph {
+	var components []Graph
+	for _, vertices := range ConnectedComponentVertices(&pathway.remnant) {
+		components = append(components, InducedColourSubgraph(&pathway.remnant, vertices))
+	}
+	return components
+}
+
+// StepsSaved returns the steps saved by the fragments of an edge based pathway, see PathwayStepsSaved
+func (pathway *Pathway) StepsSaved() int {
+	return PathwayStepsSaved(pathway, true)
+}
+
+// AtomEquivalents returns the classes of vertex labels that are the same vertex of the original graph. The original
+// label is first, followed by the labels it was given as fragments were broken off
+func (pathway *Pathway) AtomEquivalents() [][]int {
+	equivalents := make([][]int, len(pathway.atomEquivalents))
+	for i, e := range pathway.atomEquivalents {
+		equivalents[i] = append([]int{}, e...)
+	}
+	return equivalents
+}
+
+// NewDuplicates returns the Duplicates for two occurrences of a fragment, left is broken off and right stays in the remnant
+func NewDuplicates(left [][2]int, right [][2]int) Duplicates {
+	return Duplicates{CopyEdgeList(left), CopyEdgeList(right)}
+}
+
+// Left returns the edges of the occurrence of the fragment that is broken off
+func (d Duplicates) Left() [][2]int {
+	return CopyEdgeList(d.left)
+}
+
+// Right returns the edges of the occurrence of the fragment that stays in the remnant
+func (d Duplicates) Right() [][2]int {
+	return CopyEdgeList(d.right)
+}
+
+// PathwayWithDuplicates returns a starting pathway for originalGraph with the duplicates already found, in order.
+// The edges of the duplicates are in the vertex labels of originalGraph. An error is returned if an occurrence is not in
+// the remnant, if the two occurrences of a duplicate share edges, or if they are not isomorphic
+func PathwayWithDuplicates(originalGraph Graph, duplicates []Duplicates) (Pathway, error) {
+	pathway := NewStartingPathway(CopyGraph(&originalGraph))
+	for i, d := range duplicates {
+		if err := AddPathwayDuplicate(&pathway, d); err != nil {
+			return pathway, fmt.Errorf("duplicate %v: %v", i, err)
+		}
+	}
+	return pathway, nil
+}
+
+// AddPathwayDuplicate breaks the left of a duplicate off the remnant of the pathway, and separates the right from the rest
+// of the remnant, in the same way as the assembly algorithm. The edges of the duplicate are in the vertex labels of the
+// original graph, and are found in the remnant through the atom equivalents
+func AddPathwayDuplicate(pathway *Pathway, duplicate Duplicates) error {
+	used := make(map[int]bool)
+	left, err := remnantEdgePositions(pathway, duplicate.left, used)
+	if err != nil {
+		return fmt.Errorf("left: %v", err)
+	}
+	right, err := remnantEdgePositions(pathway, duplicate.right, used)
+	if err != nil {
+		return fmt.Errorf("right: %v", err)
+	}
+
+	// the positions of right in the remnant once left is broken off, which keeps the order of the other edges
+	sort.Ints(left)
+	rightInRest := make([]int, len(right))
+	for i, e := range right {
+		rightInRest[i] = e - sort.SearchInts(left, e)
+	}
+
+	subgraph, rest := BreakGraphOnEdges(&pathway.remnant, left)
+	possibleDuplicate, newRemnant := BreakGraphOnEdges(&rest, rightInRest)
+	if !GraphsIsomorphic(&subgraph, &possibleDuplicate) {
+		return fmt.Errorf("left %v is not the same as right %v", duplicate.left, duplicate.right)
+	}
+
+	pathway.pathway = append(pathway.pathway, CopyGraph(&subgraph))
+	pathway.duplicates = append(pathway.duplicates, Duplicates{CopyEdgeList(subgraph.Edges), CopyEdgeList(possibleDuplicate.Edges)})
+	RecombineRemnant(pathway, &newRemnant, &possibleDuplicate)
+	return nil
+}
+
+// remnantEdgePositions finds the positions in the remnant of edges given in original vertex labels, using each remnant
+// edge once
+func remnantEdgePositions(pathway *Pathway, edges [][2]int, used map[int]bool) ([]int, error) {
+	var positions []int
+	for _, e := range edges {
+		key := edgeKey(&pathway.remnant, e)
+		found := false
+		for i, remnantEdge := range pathway.remnant.Edges {
+			original := edgeKey(&pathway.remnant, [2]int{originalVertex(pathway, remnantEdge[0]), originalVertex(pathway, remnantEdge[1])})
+			if original == key && !used[i] {
+				used[i] = true
+				positions = append(positions, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("edge %v is not in the remnant, or is used more than once", e)
+		}
+	}
+	return positions, nil
+}
@@ -0,0 +1,89 @@
+package assembly
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathwayWithDuplicates(t *testing.T) {
+	// a path of six edges, with the first two duplicated in the middle
+	path := NewColourGraph([]int{1, 2, 3, 4, 5, 6, 7}, [][2]int{{1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 7}}, []string{}, []string{})
+	pathway, err := PathwayWithDuplicates(path, []Duplicates{NewDuplicates([][2]int{{1, 2}, {2, 3}}, [][2]int{{4, 5}, {5, 6}})})
+	if err != nil {
+		t.Fatalf("PathwayWithDuplicates error: %v", err)
+	}
+	if err := VerifyPathway(&path, &pathway); err != nil {
+		t.Errorf("PathwayWithDuplicates pathway is not valid: %v", err)
+	}
+
+	if pathway.StepsSaved() != 1 {
+		t.Errorf("StepsSaved should be 1, got %v", pathway.StepsSaved())
+	}
+	if fragments := pathway.Fragments(); len(fragments) != 1 || !reflect.DeepEqual(fragments[0].Edges, [][2]int{{1, 2}, {2, 3}}) {
+		t.Errorf("Fragments should be the path 1 2 3, got %v", fragments)
+	}
+	if duplicates := pathway.Duplicates(); len(duplicates) != 1 || !reflect.DeepEqual(duplicates[0].Left(), [][2]int{{1, 2}, {2, 3}}) {
+		t.Errorf("Duplicates left should be the path 1 2 3, got %v", duplicates)
+	}
+
+	// the middle is separated from the edges either side of it, with 4 and 6 relabelled
+	components := pathway.RemnantComponents()
+	if len(components) != 3 {
+		t.Errorf("RemnantComponents should give 3 components, got %v", components)
+	}
+	if equivalents := pathway.AtomEquivalents(); len(equivalents) != 2 {
+		t.Errorf("AtomEquivalents should have 2 classes, for 4 and 6, got %v", equivalents)
+	}
+
+	// the accessors return copies
+	pathway.Fragments()[0].Edges[0] = [2]int{0, 0}
+	pathway.AtomEquivalents()[0][0] = 0
+	if pathway.pathway[0].Edges[0] != [2]int{1, 2} || pathway.atomEquivalents[0][0] == 0 {
+		t.Errorf("changing the result of an accessor should not change the pathway")
+	}
+
+	errorTests := [][]Duplicates{
+		{NewDuplicates([][2]int{{1, 2}, {2, 3}}, [][2]int{{2, 3}, {3, 4}})},
+		{NewDuplicates([][2]int{{1, 2}, {2, 3}}, [][2]int{{4, 5}})},
+		{NewDuplicates([][2]int{{1, 2}, {2, 3}}, [][2]int{{4, 5}, {5, 7}})},
+		{NewDuplicates([][2]int{{1, 2}}, [][2]int{{2, 3}}), NewDuplicates([][2]int{{1, 2}}, [][2]int{{3, 4}})},
+	}
+	for _, duplicates := range errorTests {
+		if _, err := PathwayWithDuplicates(path, duplicates); err == nil {
+			t.Errorf("PathwayWithDuplicates should give an error for %v", duplicates)
+		}
+	}
+}
+
+func TestPathwayWithDuplicatesAssembly(t *testing.T) {
+	g := MolColourGraph("testdata/aspirin.mol")
+	assembled := Assembly(g, 100, 100, "shortest")[0]
+
+	// rebuild the assembled pathway from its occurrences in the original graph
+	occurrences, _, err := PathwayOccurrences(&assembled, &g, nil)
+	check(err)
+	var duplicates []Duplicates
+	for _, o := range occurrences {
+		var sides [2][][2]int
+		for side := range o {
+			for _, e := range o[side].Edges {
+				sides[side] = append(sides[side], g.Edges[e])
+			}
+		}
+		duplicates = append(duplicates, NewDuplicates(sides[0], sides[1]))
+	}
+	pathway, err := PathwayWithDuplicates(g, duplicates)
+	if err != nil {
+		t.Fatalf("PathwayWithDuplicates error: %v", err)
+	}
+	if err := VerifyPathwayIndex(&g, &pathway, AssemblyIndex(&assembled, &g)); err != nil {
+		t.Errorf("PathwayWithDuplicates did not rebuild the assembled pathway: %v", err)
+	}
+
+	// starting from the first duplicate still finds the assembly index
+	start, err := PathwayWithDuplicates(g, duplicates[:1])
+	check(err)
+	if index := AssemblyIndex(&AssemblyPathway(g, start, 100, 100, "shortest")[0], &g); index != 8 {
+		t.Errorf("AssemblyPathway from PathwayWithDuplicates should give 8, got %v", index)
+	}
+}
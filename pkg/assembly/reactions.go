@@ -0,0 +1,159 @@
+package assembly
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Code for reading reactions from MDL RXN files, and RD files of many reactions, and finding how the assembly index
+// changes across a reaction. Each reactant, product and agent is a mol block, which is read in the same way as a mol file
+
+// Reaction is a reaction from an RXN file, with the graph of each reactant, product and agent. Name is the reaction name
+// line after $RXN
+type Reaction struct {
+	Name      string
+	Reactants []Graph
+	Products  []Graph
+	Agents    []Graph
+}
+
+// ReactionIndices are the assembly indices of the reactants and products of a reaction. Difference is the sum of the
+// product indices less the sum of the reactant indices, and JointReactants is the joint assembly index of the reactants
+// (see JointAssembly), i.e. with fragments shared between them
+type ReactionIndices struct {
+	Reactants      []int
+	Products       []int
+	Difference     int
+	JointReactants int
+}
+
+// ParseReactionsFile reads the reactions in an RXN file, or an RD file of many reactions, see ParseReactionsString
+func ParseReactionsFile(filePath string, colouring Colouring) ([]Reaction, error) {
+	fileBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReactionsString(string(fileBytes), colouring)
+}
+
+// ParseReactionsString reads the V2000 reactions in a string, each starting with a $RXN line, so it can be an RXN file
+// or an RD file. The mol blocks are read as by MolGraphFromStringColouring, with the colours chosen by colouring. An
+// error is returned if there are no reactions, or a reaction doesn't have the mol blocks in its counts line
+func ParseReactionsString(rxn string, colouring Colouring) ([]Reaction, error) {
+	lines := strings.Split(strings.ReplaceAll(rxn, "\r\n", "\n"), "\n")
+	var reactions []Reaction
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "$RXN") {
+			continue
+		}
+		if strings.Contains(lines[i], "V3000") {
+			return nil, fmt.Errorf("reaction %v: V3000 RXN files are not supported", len(reactions)+1)
+		}
+		if i+4 >= len(lines) {
+			return nil, fmt.Errorf("reaction %v: RXN header has no counts line", len(reactions)+1)
+		}
+		reaction := Reaction{Name: strings.TrimSpace(lines[i+1])}
+		counts, err := parseRXNCounts(lines[i+4])
+		if err != nil {
+			return nil, fmt.Errorf("reaction %v: %v", len(reactions)+1, err)
+		}
+
+		// the mol blocks follow the header, each after a $MOL line and ending with M  END
+		var graphs []Graph
+		i += 5
+		for len(graphs) < counts[0]+counts[1]+counts[2] {
+			if i >= len(lines) || !strings.HasPrefix(lines[i], "$MOL") {
+				return nil, fmt.Errorf("reaction %v: found %v mol blocks, the counts line gives %v", len(reactions)+1,
+					len(graphs), counts[0]+counts[1]+counts[2])
+			}
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(lines[end], "M  END") {
+				end++
+			}
+			if end == len(lines) {
+				return nil, fmt.Errorf("reaction %v: mol block %v has no M  END line", len(reactions)+1, len(graphs)+1)
+			}
+			g, err := MolGraphFromStringColouring(strings.Join(lines[i+1:end+1], "\n"), colouring)
+			if err != nil {
+				return nil, fmt.Errorf("reaction %v: mol block %v: %v", len(reactions)+1, len(graphs)+1, err)
+			}
+			graphs = append(graphs, g)
+			i = end + 1
+		}
+		reaction.Reactants = graphs[:counts[0]]
+		reaction.Products = graphs[counts[0] : counts[0]+counts[1]]
+		reaction.Agents = graphs[counts[0]+counts[1]:]
+		reactions = append(reactions, reaction)
+		i--
+	}
+	if len(reactions) == 0 {
+		return nil, fmt.Errorf("no $RXN line, so no reactions")
+	}
+	return reactions, nil
+}
+
+// parseRXNCounts returns the numbers of reactants, products and agents in the counts line of an RXN file, which has
+// three characters for each. Agents are optional
+func parseRXNCounts(line string) ([3]int, error) {
+	var counts [3]int
+	for i := range counts {
+		if len(line) < 3*i+3 {
+			if i < 2 {
+				return counts, fmt.Errorf("bad RXN counts line %q", line)
+			}
+			break
+		}
+		field := strings.TrimSpace(line[3*i : 3*i+3])
+		if field == "" && i == 2 {
+			break
+		}
+		count, err := strconv.Atoi(field)
+		if err != nil || count < 0 {
+			return counts, fmt.Errorf("bad RXN counts line %q", line)
+		}
+		counts[i] = count
+	}
+	return counts, nil
+}
+
+// ReactionAssembly returns the assembly index of each reactant and product of a reaction, the difference and the joint
+// assembly index of the reactants. Agents aren't assembled
+func ReactionAssembly(reaction *Reaction, numWorkers int, chanBufferSize int, variant string) ReactionIndices {
+	indices := ReactionIndices{Reactants: []int{}, Products: []int{}}
+	for _, side := range []struct {
+		graphs  []Graph
+		indices *[]int
+		sign    int
+	}{
+		{reaction.Reactants, &indices.Reactants, -1},
+		{reaction.Products, &indices.Products, 1},
+	} {
+		for _, g := range side.graphs {
+			index := 0
+			if len(g.Edges) > 0 {
+				pathways := Assembly(g, numWorkers, chanBufferSize, variant)
+				index = AssemblyIndex(&pathways[0], &g)
+			}
+			*side.indices = append(*side.indices, index)
+			indices.Difference += side.sign * index
+		}
+	}
+
+	reactantEdges := 0
+	for _, g := range reaction.Reactants {
+		reactantEdges += len(g.Edges)
+	}
+	if reactantEdges > 0 {
+		combined, _, pathways := JointAssembly(reaction.Reactants, numWorkers, chanBufferSize, variant)
+		indices.JointReactants = JointAssemblyIndex(&pathways[0], &combined)
+	}
+	return indices
+}
+
+// ReactionIndicesString returns the indices of a reaction on one line, for batch output
+func ReactionIndicesString(name string, indices ReactionIndices) string {
+	return fmt.Sprintf("%v reactants %v products %v difference %v joint reactants %v", name, indices.Reactants,
+		indices.Products, indices.Difference, indices.JointReactants)
+}
@@ -0,0 +1,86 @@
+package assembly
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseReactions(t *testing.T) {
+	reactions, err := ParseReactionsFile("testdata/esterification.rxn", Colouring{})
+	if err != nil {
+		t.Fatalf("ParseReactionsFile error: %v", err)
+	}
+	if len(reactions) != 1 || reactions[0].Name != "esterification" {
+		t.Fatalf("ParseReactionsFile expected one reaction, esterification, got %v", reactions)
+	}
+	var sizes [][]int
+	for _, graphs := range [][]Graph{reactions[0].Reactants, reactions[0].Products, reactions[0].Agents} {
+		var graphSizes []int
+		for _, g := range graphs {
+			graphSizes = append(graphSizes, len(g.Vertices))
+		}
+		sizes = append(sizes, graphSizes)
+	}
+	if expected := [][]int{{4, 3}, {6, 1}, {5}}; !reflect.DeepEqual(sizes, expected) {
+		t.Errorf("ParseReactionsFile expected reactant, product and agent sizes %v, got %v", expected, sizes)
+	}
+
+	reactions, err = ParseReactionsFile("testdata/reactions.rdf", Colouring{})
+	if err != nil || len(reactions) != 2 || reactions[0].Name != "hydrogenation" || reactions[1].Name != "hydrolysis" {
+		t.Errorf("ParseReactionsFile of an RD file expected hydrogenation and hydrolysis, got %v, %v", reactions, err)
+	}
+
+	for _, bad := range []string{
+		"",
+		"$RXN\nname\n\n\n  x  1\n",
+		"$RXN\nname\n\n\n  1  1\n$MOL\n",
+		strings.Replace(strings.Split(esterificationString(t), "$MOL\nwater")[0], "  2  2  1", "  2  2  0", 1),
+		"$RXN V3000\n",
+	} {
+		if _, err := ParseReactionsString(bad, Colouring{}); err == nil {
+			t.Errorf("ParseReactionsString should give an error for %q", bad)
+		}
+	}
+}
+
+// esterificationString returns the contents of the esterification RXN file
+func esterificationString(t *testing.T) string {
+	reactionBytes, err := ioutil.ReadFile("testdata/esterification.rxn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(reactionBytes)
+}
+
+func TestReactionAssembly(t *testing.T) {
+	reactions, err := ParseReactionsFile("testdata/reactions.rdf", Colouring{})
+	if err != nil {
+		t.Fatalf("ParseReactionsFile error: %v", err)
+	}
+	for i, expected := range []ReactionIndices{
+		{[]int{0}, []int{0}, 0, 0},
+		{[]int{3, 0}, []int{2, 1}, 0, 3},
+	} {
+		indices := ReactionAssembly(&reactions[i], 10, 10, "shortest")
+		if !reflect.DeepEqual(indices, expected) {
+			t.Errorf("ReactionAssembly %v expected %+v, got %+v", reactions[i].Name, expected, indices)
+		}
+	}
+
+	// acetic acid and ethanol share C-C and C-O, so the joint index is less than the sum
+	reactions, err = ParseReactionsFile("testdata/esterification.rxn", Colouring{})
+	if err != nil {
+		t.Fatalf("ParseReactionsFile error: %v", err)
+	}
+	indices := ReactionAssembly(&reactions[0], 10, 10, "shortest")
+	if expected := (ReactionIndices{[]int{2, 1}, []int{3, 0}, 0, 2}); !reflect.DeepEqual(indices, expected) {
+		t.Errorf("ReactionAssembly esterification expected %+v, got %+v", expected, indices)
+	}
+
+	expected := "esterification reactants [2 1] products [3 0] difference 0 joint reactants 2"
+	if s := ReactionIndicesString("esterification", indices); s != expected {
+		t.Errorf("ReactionIndicesString expected %v, got %v", expected, s)
+	}
+}
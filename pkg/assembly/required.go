@@ -0,0 +1,367 @@
+package assembly
+
+import (
+	"GoAssembly/pkg/helpers"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Code for pathways that must use given duplicates, e.g. to test whether a molecule could have been built from two
+// copies of a substructure. Each required duplicate is either a fragment, in which case all the ways of breaking off two
+// occurrences of it are tried, or the vertices of two occurrences. The resulting starting pathways seed the search
+
+// RequiredDuplicate is a duplicated fragment that a pathway must use. If Fragment is set, two occurrences of it are found
+// in the graph. Otherwise Left and Right are the vertices of the two occurrences, and the fragment is the subgraph of the
+// original graph induced by each
+type RequiredDuplicate struct {
+	Fragment *Graph
+	Left     []int
+	Right    []int
+}
+
+// RequiredFragment returns a RequiredDuplicate for two occurrences of fragment, wherever they are in the graph
+func RequiredFragment(fragment Graph) RequiredDuplicate {
+	return RequiredDuplicate{&fragment, nil, nil}
+}
+
+// RequiredVertices returns a RequiredDuplicate for the occurrences of a fragment on the left and right vertices
+func RequiredVertices(left []int, right []int) RequiredDuplicate {
+	return RequiredDuplicate{nil, append([]int{}, left...), append([]int{}, right...)}
+}
+
+// RequiredAtoms returns a RequiredDuplicate for the occurrences of a fragment on the left and right mol file atom numbers
+// (counting from 1), using the MolNumbering of the molecule graph
+func RequiredAtoms(numbering MolNumbering, leftAtoms []int, rightAtoms []int) (RequiredDuplicate, error) {
+	left, err := atomVertices(&numbering, leftAtoms)
+	if err != nil {
+		return RequiredDuplicate{}, err
+	}
+	right, err := atomVertices(&numbering, rightAtoms)
+	if err != nil {
+		return RequiredDuplicate{}, err
+	}
+	return RequiredVertices(left, right), nil
+}
+
+// RequiredStartingPathways returns the starting pathways that use all the required duplicates, in order. A required
+// fragment may be found in several places, so there can be many. Pathways with the same remnant (up to isomorphism) would
+// be extended in the same way, so only one of them is kept. An error is returned if no pathway uses all of them
+func RequiredStartingPathways(g Graph, required []RequiredDuplicate) ([]Pathway, error) {
+	pathways := []Pathway{NewStartingPathway(CopyGraph(&g))}
+	for i, r := range required {
+		var next []Pathway
+		var lastErr error
+		for p := range pathways {
+			extended, err := addRequiredDuplicate(&g, &pathways[p], r)
+			if err != nil {
+				lastErr = err
+			}
+			for _, e := range extended {
+				if !remnantSeen(next, &e) {
+					next = append(next, e)
+				}
+			}
+		}
+		if len(next) == 0 {
+			if lastErr == nil {
+				lastErr = fmt.Errorf("there are not two separate occurrences of the fragment")
+			}
+			return nil, fmt.Errorf("required duplicate %v: %v", i, lastErr)
+		}
+		pathways = next
+	}
+	return pathways, nil
+}
+
+// addRequiredDuplicate returns the pathways that add the required duplicate to pathway
+func addRequiredDuplicate(g *Graph, pathway *Pathway, r RequiredDuplicate) ([]Pathway, error) {
+	if r.Fragment == nil {
+		left, _ := BreakGraphOnEdges(g, inducedEdges(g, r.Left))
+		right, _ := BreakGraphOnEdges(g, inducedEdges(g, r.Right))
+		newPathway := CopyPathway(pathway)
+		if err := AddPathwayDuplicate(&newPathway, NewDuplicates(left.Edges, right.Edges)); err != nil {
+			return nil, err
+		}
+		return []Pathway{newPathway}, nil
+	}
+
+	// every pair of occurrences in the remnant that don't share edges, in original vertex labels
+	occurrences := FindFragmentOccurrences(&pathway.remnant, r.Fragment)
+	var pathways []Pathway
+	for i := range occurrences {
+		for j := i + 1; j < len(occurrences); j++ {
+			if sharesEdge(occurrences[i], occurrences[j]) {
+				continue
+			}
+			var sides [2][][2]int
+			for side, occurrence := range [][]int{occurrences[i], occurrences[j]} {
+				for _, e := range occurrence {
+					edge := pathway.remnant.Edges[e]
+					sides[side] = append(sides[side], [2]int{originalVertex(pathway, edge[0]), originalVertex(pathway, edge[1])})
+				}
+			}
+			newPathway := CopyPathway(pathway)
+			if err := AddPathwayDuplicate(&newPathway, NewDuplicates(sides[0], sides[1])); err != nil {
+				return nil, err
+			}
+			pathways = append(pathways, newPathway)
+		}
+	}
+	return pathways, nil
+}
+
+// remnantSeen returns true if one of the pathways has a remnant isomorphic to the remnant of pathway
+func remnantSeen(pathways []Pathway, pathway *Pathway) bool {
+	for i := range pathways {
+		if GraphsIsomorphic(&pathways[i].remnant, &pathway.remnant) {
+			return true
+		}
+	}
+	return false
+}
+
+// inducedEdges returns the positions of the edges of g between the vertices
+func inducedEdges(g *Graph, vertices []int) []int {
+	var edges []int
+	for i, e := range g.Edges {
+		if helpers.Contains(vertices, e[0]) && helpers.Contains(vertices, e[1]) {
+			edges = append(edges, i)
+		}
+	}
+	return edges
+}
+
+// sharesEdge returns true if two sorted edge position lists have a position in common
+func sharesEdge(edges1 []int, edges2 []int) bool {
+	for _, e := range edges1 {
+		if i := sort.SearchInts(edges2, e); i < len(edges2) && edges2[i] == e {
+			return true
+		}
+	}
+	return false
+}
+
+// FindFragmentOccurrences returns the sorted edge positions of each subgraph of g that is isomorphic to fragment. The
+// subgraphs are found in the same way as in CheckSubgraphMatches, so fragment must be connected
+func FindFragmentOccurrences(g *Graph, fragment *Graph) [][]int {
+	k := len(fragment.Edges)
+	var occurrences [][]int
+	if k == 0 {
+		return occurrences
+	}
+
+	edgeAdjacencies := g.EdgeAdjacencies()
+	forbidden := make(map[int]bool)
+	forbiddenSize := make(map[int]int)
+	var sub []int
+	for i := 0; i < len(g.Edges); i++ {
+		sub = []int{i}
+		for {
+			if len(sub) == k {
+				edges := append([]int{}, sub...)
+				sort.Ints(edges)
+				possible, _ := BreakGraphOnEdges(g, edges)
+				if GraphsIsomorphic(fragment, &possible) {
+					occurrences = append(occurrences, edges)
+				}
+			} else if neighbour, found := nonForbiddenNeighbour(sub, edgeAdjacencies, forbidden); found {
+				sub = append(sub, neighbour)
+				continue
+			}
+
+			// backtracking steps
+			thisForbidSize := len(sub)
+			thisForbid := sub[len(sub)-1]
+			sub = sub[:len(sub)-1]
+			forbidUpdate(thisForbid, thisForbidSize, forbidden, forbiddenSize)
+			if len(sub) == 0 {
+				break
+			}
+		}
+	}
+	return occurrences
+}
+
+// AssemblyRequired finds the shortest pathways that use all the required duplicates (see RequiredStartingPathways),
+// for edge based assembly. The search is run from each starting pathway, and the best pathways found are returned
+func AssemblyRequired(g Graph, required []RequiredDuplicate, numWorkers int, chanBufferSize int, variant string) ([]Pathway, error) {
+	starts, err := RequiredStartingPathways(g, required)
+	if err != nil {
+		return nil, err
+	}
+	return AssemblyStartingPathways(g, starts, numWorkers, chanBufferSize, variant), nil
+}
+
+// AssemblyStartingPathways runs AssemblyPathway from each starting pathway, and returns the best pathways found
+func AssemblyStartingPathways(g Graph, starts []Pathway, numWorkers int, chanBufferSize int, variant string) []Pathway {
+	var best []Pathway
+	for _, start := range starts {
+		pathways := AssemblyPathway(g, start, numWorkers, chanBufferSize, variant)
+		switch {
+		case len(best) == 0 || AssemblyIndex(&pathways[0], &g) < AssemblyIndex(&best[0], &g):
+			best = pathways
+		case variant == "all_shortest" && AssemblyIndex(&pathways[0], &g) == AssemblyIndex(&best[0], &g):
+			best = append(best, pathways...)
+		}
+	}
+	return best
+}
+
+// MolListStartingPathways makes starting pathways from a list of graphs, e.g. from an sdf file, as MolListToPathway.
+// The occurrences of each duplicated graph are found in the original graph, and only the starting pathways with a
+// remnant isomorphic to the last graph are returned
+func MolListStartingPathways(mols []Graph) (Graph, []Pathway, error) {
+	if len(mols) < 2 {
+		return Graph{}, nil, fmt.Errorf("a pathway needs the original graph and the remnant, got %v graphs", len(mols))
+	}
+	originalGraph := mols[0]
+	var required []RequiredDuplicate
+	for _, fragment := range mols[1 : len(mols)-1] {
+		required = append(required, RequiredFragment(fragment))
+	}
+
+	starts, err := RequiredStartingPathways(originalGraph, required)
+	if err != nil {
+		return originalGraph, nil, err
+	}
+	var pathways []Pathway
+	for _, start := range starts {
+		if GraphsIsomorphic(&start.remnant, &mols[len(mols)-1]) {
+			pathways = append(pathways, start)
+		}
+	}
+	if len(pathways) == 0 {
+		return originalGraph, nil, fmt.Errorf("the remnant is not what is left once the duplicates are broken off")
+	}
+	return originalGraph, pathways, nil
+}
+
+// ParseFragment parses a fragment from a SMILES-like string, e.g. C(=O)O, into a graph coloured in the same way as mol
+// file graphs. Atoms are element symbols, optionally in brackets (anything after the symbol in brackets is ignored), and
+// lower case atoms are aromatic. Bonds are -, =, # and : for single, double, triple and aromatic, and default to single,
+// or aromatic between aromatic atoms. Branches are in parentheses and rings are closed with digits (or %nn)
+func ParseFragment(s string) (Graph, error) {
+	var atoms []string
+	var aromatic []bool
+	var bonds [][2]int
+	var bondTypes []string
+
+	previous := -1
+	var branches []int
+	rings := make(map[int]int)
+	ringBonds := make(map[int]string)
+	bond := ""
+	addBond := func(from int, to int, bondType string) {
+		if bondType == "" {
+			bondType = "single"
+			if aromatic[from] && aromatic[to] {
+				bondType = "aromatic"
+			}
+		}
+		bonds = append(bonds, [2]int{from, to})
+		bondTypes = append(bondTypes, bondType)
+	}
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '-' || r == '=' || r == '#' || r == ':':
+			bond = map[rune]string{'-': "single", '=': "double", '#': "triple", ':': "aromatic"}[r]
+		case r == '(':
+			branches = append(branches, previous)
+		case r == ')':
+			if len(branches) == 0 {
+				return Graph{}, fmt.Errorf("unmatched ) at %v in %v", i, s)
+			}
+			previous = branches[len(branches)-1]
+			branches = branches[:len(branches)-1]
+		case unicode.IsDigit(r) || r == '%':
+			ring := int(r - '0')
+			if r == '%' {
+				if i+2 >= len(runes) {
+					return Graph{}, fmt.Errorf("bad ring number at %v in %v", i, s)
+				}
+				n, err := strconv.Atoi(string(runes[i+1 : i+3]))
+				if err != nil {
+					return Graph{}, fmt.Errorf("bad ring number at %v in %v", i, s)
+				}
+				ring = n
+				i += 2
+			}
+			if previous == -1 {
+				return Graph{}, fmt.Errorf("ring number before any atom in %v", s)
+			}
+			if open, ok := rings[ring]; ok {
+				bondType := ringBonds[ring]
+				if bond != "" {
+					bondType = bond
+				}
+				addBond(open, previous, bondType)
+				delete(rings, ring)
+			} else {
+				rings[ring] = previous
+				ringBonds[ring] = bond
+			}
+			bond = ""
+		case r == '[' || unicode.IsLetter(r):
+			symbol := string(r)
+			if r == '[' {
+				end := strings.IndexRune(string(runes[i:]), ']')
+				if end == -1 {
+					return Graph{}, fmt.Errorf("unmatched [ at %v in %v", i, s)
+				}
+				symbol = leadingSymbol(string(runes[i+1 : i+end]))
+				i += end
+			} else if i+1 < len(runes) && (r == 'C' && runes[i+1] == 'l' || r == 'B' && runes[i+1] == 'r') {
+				symbol = string(runes[i : i+2])
+				i++
+			}
+			if symbol == "" {
+				return Graph{}, fmt.Errorf("bad atom at %v in %v", i, s)
+			}
+
+			atoms = append(atoms, strings.ToUpper(symbol[:1])+symbol[1:])
+			aromatic = append(aromatic, unicode.IsLower(rune(symbol[0])))
+			atom := len(atoms) - 1
+			if previous != -1 {
+				addBond(previous, atom, bond)
+			}
+			previous = atom
+			bond = ""
+		default:
+			return Graph{}, fmt.Errorf("unsupported character %q at %v in %v", r, i, s)
+		}
+	}
+	if len(branches) != 0 || len(rings) != 0 {
+		return Graph{}, fmt.Errorf("unclosed branch or ring in %v", s)
+	}
+
+	vertices := make([]int, len(atoms))
+	for i := range vertices {
+		vertices[i] = i
+	}
+	fragment := NewColourGraphFromIDs(vertices, bonds, Colours.InternSlice(atoms), Colours.InternSlice(bondTypes))
+	if err := ValidateGraph(&fragment); err != nil {
+		return fragment, err
+	}
+	if len(ConnectedComponentVertices(&fragment)) != 1 {
+		return fragment, fmt.Errorf("fragment %v is not connected", s)
+	}
+	return fragment, nil
+}
+
+// leadingSymbol returns the element symbol at the start of a bracket atom, e.g. Cl from Cl-, or C from CH3
+func leadingSymbol(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 || !unicode.IsLetter(runes[0]) {
+		return ""
+	}
+	if unicode.IsUpper(runes[0]) && len(runes) > 1 && unicode.IsLower(runes[1]) {
+		return string(runes[:2])
+	}
+	return string(runes[:1])
+}
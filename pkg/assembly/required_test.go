@@ -0,0 +1,117 @@
+package assembly
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFragment(t *testing.T) {
+	tests := []struct {
+		s        string
+		vColours []string
+		edges    [][2]int
+		eColours []string
+	}{
+		{"C(=O)O", []string{"C", "O", "O"}, [][2]int{{0, 1}, {0, 2}}, []string{"double", "single"}},
+		{"C1CC1", []string{"C", "C", "C"}, [][2]int{{0, 1}, {1, 2}, {0, 2}}, []string{"single", "single", "single"}},
+		{"c1ccccc1", []string{"C", "C", "C", "C", "C", "C"}, [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 5}, {0, 5}},
+			[]string{"aromatic", "aromatic", "aromatic", "aromatic", "aromatic", "aromatic"}},
+		{"ClC#[NH+]", []string{"Cl", "C", "N"}, [][2]int{{0, 1}, {1, 2}}, []string{"single", "triple"}},
+		{"C=1CC%101.C%10", nil, nil, nil},
+		{"C(C", nil, nil, nil},
+		{"C1CC", nil, nil, nil},
+		{"C$C", nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		g, err := ParseFragment(tt.s)
+		if tt.vColours == nil {
+			if err == nil {
+				t.Errorf("ParseFragment %v should give an error", tt.s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseFragment %v error: %v", tt.s, err)
+			continue
+		}
+		if !reflect.DeepEqual(g.VertexColourNames(), tt.vColours) || !reflect.DeepEqual(g.Edges, tt.edges) ||
+			!reflect.DeepEqual(g.EdgeColourNames(), tt.eColours) {
+			t.Errorf("ParseFragment %v expected %v %v %v, got %v %v %v", tt.s, tt.vColours, tt.edges, tt.eColours,
+				g.VertexColourNames(), g.Edges, g.EdgeColourNames())
+		}
+	}
+}
+
+func TestFindFragmentOccurrences(t *testing.T) {
+	square := NewGraphOnlyFromFile("testdata/graphs/square.txt")
+	path := NewGraph([]int{1, 2, 3}, [][2]int{{1, 2}, {2, 3}})
+	if occurrences := FindFragmentOccurrences(&square, &path); len(occurrences) != 4 {
+		t.Errorf("FindFragmentOccurrences should find 4 paths of two edges in a square, got %v", occurrences)
+	}
+
+	dualRing := ParseSDFile("testdata/dual_ring_test.sdf", true)
+	if occurrences := FindFragmentOccurrences(&dualRing[0], &dualRing[1]); len(occurrences) != 2 {
+		t.Errorf("FindFragmentOccurrences should find 2 rings in two joined rings, got %v", occurrences)
+	}
+}
+
+func TestAssemblyRequired(t *testing.T) {
+	aspirin := MolColourGraph("testdata/aspirin.mol")
+	acid, err := ParseFragment("C(=O)O")
+	check(err)
+	diene, err := ParseFragment("C=CC=C")
+	check(err)
+	acetyl, err := RequiredAtoms(MolNumberingFromFile("testdata/aspirin.mol"), []int{3, 7, 9, 10}, []int{4, 11, 12, 13})
+	check(err)
+
+	tests := []struct {
+		required []RequiredDuplicate
+		index    int
+	}{
+		{[]RequiredDuplicate{RequiredFragment(acid)}, 9},
+		{[]RequiredDuplicate{acetyl}, 8},
+		{[]RequiredDuplicate{RequiredFragment(diene)}, -1},
+		{[]RequiredDuplicate{acetyl, RequiredVertices([]int{2, 6}, []int{3, 10})}, -1},
+	}
+
+	for _, tt := range tests {
+		pathways, err := AssemblyRequired(aspirin, tt.required, 100, 100, "shortest")
+		if tt.index == -1 {
+			if err == nil {
+				t.Errorf("AssemblyRequired %v should give an error", tt.required)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("AssemblyRequired %v error: %v", tt.required, err)
+			continue
+		}
+		if index := AssemblyIndex(&pathways[0], &aspirin); index != tt.index {
+			t.Errorf("AssemblyRequired %v expected index %v, got %v", tt.required, tt.index, index)
+		}
+		if err := VerifyPathway(&aspirin, &pathways[0]); err != nil {
+			t.Errorf("AssemblyRequired %v gave an invalid pathway: %v", tt.required, err)
+		}
+	}
+}
+
+func TestMolListStartingPathways(t *testing.T) {
+	graphs := ParseSDFile("testdata/dual_ring_test.sdf", true)
+	originalGraph, pathways, err := MolListStartingPathways(graphs)
+	if err != nil {
+		t.Fatalf("MolListStartingPathways error: %v", err)
+	}
+	if len(pathways) != 1 || len(pathways[0].Duplicates()) != 1 {
+		t.Errorf("MolListStartingPathways should give one pathway with one duplicate, got %v", pathways)
+	}
+	if err := VerifyPathway(&originalGraph, &pathways[0]); err != nil {
+		t.Errorf("MolListStartingPathways gave an invalid pathway: %v", err)
+	}
+
+	// the remnant is wrong if it is the ring
+	wrongRemnant := []Graph{graphs[0], graphs[1], graphs[1]}
+	if _, _, err := MolListStartingPathways(wrongRemnant); err == nil {
+		t.Errorf("MolListStartingPathways should give an error if the remnant is not what is left")
+	}
+}
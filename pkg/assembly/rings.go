@@ -0,0 +1,484 @@
+package assembly
+
+import (
+	"GoAssembly/pkg/helpers"
+	"fmt"
+	"sort"
+)
+
+// Code for finding the rings (cycles) of a graph. Rings are given as the positions of their edges in g.Edges, in order
+// around the ring, so parallel edges and self-loops of multigraphs are rings too. Edge directions are ignored
+
+// SSSR returns the smallest set of smallest rings of g, i.e. a minimum cycle basis: as many rings as the cycle rank of
+// g (edges - vertices + connected components), with the smallest total size, such that no ring is the symmetric
+// difference of others. The rings are found with Horton's algorithm, and are in order of size. Where there is a choice
+// of rings of the same size (e.g. the faces of a cube) the one found first is kept
+func SSSR(g *Graph) [][]int {
+	rank := len(g.Edges) - len(g.Vertices) + len(ConnectedComponentVertices(g))
+	var rings [][]int
+	if rank <= 0 {
+		return rings
+	}
+
+	candidates := hortonCycles(g)
+	basis := newCycleBasis(len(g.Edges))
+	for _, candidate := range candidates {
+		if basis.add(candidate) {
+			rings = append(rings, candidate)
+			if len(rings) == rank {
+				break
+			}
+		}
+	}
+	return rings
+}
+
+// RelevantCycles returns the relevant cycles of g, i.e. the union of all its minimum cycle bases: the rings that are not
+// the symmetric difference of smaller rings. Unlike SSSR these don't depend on a choice between rings of the same size,
+// e.g. all six faces of a cube. The rings are found with Vismara's method, and are in order of size. There may be
+// exponentially many in the size of g, though not for molecules
+func RelevantCycles(g *Graph) [][]int {
+	var relevant [][]int
+	candidates := shortestPathCycles(g, true)
+	basis := newCycleBasis(len(g.Edges))
+	for start := 0; start < len(candidates); {
+		// a ring is relevant if it is independent of all the smaller rings
+		end := start
+		for end < len(candidates) && len(candidates[end]) == len(candidates[start]) {
+			if basis.independent(candidates[end]) {
+				relevant = append(relevant, candidates[end])
+			}
+			end++
+		}
+		for _, candidate := range candidates[start:end] {
+			basis.add(candidate)
+		}
+		start = end
+	}
+	return relevant
+}
+
+// CycleBasis returns a fundamental cycle basis of g: for each edge not in a breadth first spanning forest, the ring made
+// of it and the path between its ends in the forest. This is quicker to find than SSSR, but the rings may not be the
+// smallest
+func CycleBasis(g *Graph) [][]int {
+	incident := incidentEdges(g)
+	parentEdge := make(map[int]int)
+	for _, root := range g.Vertices {
+		if _, ok := parentEdge[root]; ok {
+			continue
+		}
+		parentEdge[root] = -1
+		queue := []int{root}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			for _, e := range incident[v] {
+				if u := otherVertex(g, e, v); u != v {
+					if _, ok := parentEdge[u]; !ok {
+						parentEdge[u] = e
+						queue = append(queue, u)
+					}
+				}
+			}
+		}
+	}
+
+	var rings [][]int
+	for i, e := range g.Edges {
+		if e[0] == e[1] {
+			rings = append(rings, []int{i})
+			continue
+		}
+		if parentEdge[e[0]] == i || parentEdge[e[1]] == i {
+			continue
+		}
+		// the path from u up to the root, then from v up to where it meets that path
+		upU := make(map[int]int) // position of each vertex on the path from u
+		var pathU []int
+		for v := e[0]; ; v = otherVertex(g, parentEdge[v], v) {
+			upU[v] = len(pathU)
+			if parentEdge[v] == -1 {
+				break
+			}
+			pathU = append(pathU, parentEdge[v])
+		}
+		ring := []int{i}
+		v := e[1]
+		for ; ; v = otherVertex(g, parentEdge[v], v) {
+			if _, ok := upU[v]; ok {
+				break
+			}
+			ring = append(ring, parentEdge[v])
+		}
+		// u to v, v up to the meeting vertex, then down to u
+		for j := upU[v] - 1; j >= 0; j-- {
+			ring = append(ring, pathU[j])
+		}
+		rings = append(rings, ring)
+	}
+	return rings
+}
+
+// RingEdges returns whether each edge of g is on a ring, i.e. is not a bridge
+func RingEdges(g *Graph) []bool {
+	inRing := make([]bool, len(g.Edges))
+	for _, block := range ringBlocks(g) {
+		for _, e := range block {
+			inRing[e] = true
+		}
+	}
+	return inRing
+}
+
+// RingMembership returns the positions in rings (e.g. from SSSR or RelevantCycles) of the rings each edge of g is in
+func RingMembership(g *Graph, rings [][]int) [][]int {
+	membership := make([][]int, len(g.Edges))
+	for i, ring := range rings {
+		for _, e := range ring {
+			membership[e] = append(membership[e], i)
+		}
+	}
+	return membership
+}
+
+// FusedRingSystems returns the vertices of each fused ring system of g, i.e. the rings joined by sharing edges. Unlike
+// RingSystems, spiro rings, which share only a vertex, are separate systems. The systems are the biconnected components
+// of g with rings, and are in order of their lowest vertex
+func FusedRingSystems(g *Graph) [][]int {
+	var systems [][]int
+	for _, block := range ringBlocks(g) {
+		var vertices []int
+		for _, e := range block {
+			for _, v := range g.Edges[e] {
+				if !helpers.Contains(vertices, v) {
+					vertices = append(vertices, v)
+				}
+			}
+		}
+		sort.Ints(vertices)
+		systems = append(systems, vertices)
+	}
+	sort.Slice(systems, func(i, j int) bool { return systems[i][0] < systems[j][0] })
+	return systems
+}
+
+// ringBlocks returns the edge positions of the biconnected components of g that have rings, i.e. more than one edge
+// or a self-loop, found with Tarjan's depth first search
+func ringBlocks(g *Graph) [][]int {
+	incident := incidentEdges(g)
+	order := make(map[int]int) // the order each vertex is reached in
+	low := make(map[int]int)   // the earliest vertex reachable from each vertex's subtree by one back edge
+	var stack []int
+	var blocks [][]int
+
+	var visit func(v int, parentEdge int)
+	visit = func(v int, parentEdge int) {
+		order[v] = len(order)
+		low[v] = order[v]
+		for _, e := range incident[v] {
+			u := otherVertex(g, e, v)
+			switch _, reached := order[u]; {
+			case e == parentEdge:
+			case u == v:
+				blocks = append(blocks, []int{e})
+			case !reached:
+				stack = append(stack, e)
+				visit(u, e)
+				if low[u] < low[v] {
+					low[v] = low[u]
+				}
+				if low[u] >= order[v] {
+					// v separates u's subtree, so the edges from e on are a block
+					var block []int
+					for {
+						last := stack[len(stack)-1]
+						stack = stack[:len(stack)-1]
+						block = append(block, last)
+						if last == e {
+							break
+						}
+					}
+					if len(block) > 1 {
+						sort.Ints(block)
+						blocks = append(blocks, block)
+					}
+				}
+			case order[u] < order[v]:
+				stack = append(stack, e)
+				if order[u] < low[v] {
+					low[v] = order[u]
+				}
+			}
+		}
+	}
+	for _, v := range g.Vertices {
+		if _, reached := order[v]; !reached {
+			visit(v, -1)
+		}
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i][0] < blocks[j][0] })
+	return blocks
+}
+
+// RingStatistics summarises the rings of a graph, see RingStats
+type RingStatistics struct {
+	CycleRank      int   // the number of independent rings, edges - vertices + connected components
+	RingSizes      []int // the sizes of the SSSR rings, smallest first
+	RelevantCycles int   // the number of relevant cycles
+	RingEdges      int   // the number of edges on rings
+	RingVertices   int   // the number of vertices on rings
+	RingSystems    int   // the number of ring systems, with spiro rings joined (see RingSystems)
+	FusedSystems   int   // the number of fused ring systems (see FusedRingSystems)
+}
+
+// RingStats returns the RingStatistics of g
+func RingStats(g *Graph) RingStatistics {
+	stats := RingStatistics{
+		CycleRank:      len(g.Edges) - len(g.Vertices) + len(ConnectedComponentVertices(g)),
+		RingSizes:      []int{},
+		RelevantCycles: len(RelevantCycles(g)),
+		RingSystems:    len(RingSystems(g)),
+		FusedSystems:   len(FusedRingSystems(g)),
+	}
+	for _, ring := range SSSR(g) {
+		stats.RingSizes = append(stats.RingSizes, len(ring))
+	}
+	vertices := make(map[int]bool)
+	for e, inRing := range RingEdges(g) {
+		if inRing {
+			stats.RingEdges++
+			vertices[g.Edges[e][0]] = true
+			vertices[g.Edges[e][1]] = true
+		}
+	}
+	stats.RingVertices = len(vertices)
+	return stats
+}
+
+// RingStatisticsString returns the ring statistics, for output alongside the assembly index
+func RingStatisticsString(stats RingStatistics) string {
+	outString := "Ring Statistics\n"
+	outString += fmt.Sprintf("cycle rank %v\n", stats.CycleRank)
+	outString += fmt.Sprintf("SSSR ring sizes %v\n", stats.RingSizes)
+	outString += fmt.Sprintf("relevant cycles %v\n", stats.RelevantCycles)
+	outString += fmt.Sprintf("ring edges %v ring vertices %v\n", stats.RingEdges, stats.RingVertices)
+	outString += fmt.Sprintf("ring systems %v fused ring systems %v\n", stats.RingSystems, stats.FusedSystems)
+	outString += "+++++++++++++++\n"
+	return outString
+}
+
+// hortonCycles returns the candidate rings of Horton's algorithm, sorted by size: for each vertex x and edge {u, v}, the
+// ring made of the edge and the shortest paths from x to u and v, if the paths only meet at x
+func hortonCycles(g *Graph) [][]int {
+	return shortestPathCycles(g, false)
+}
+
+// shortestPathCycles returns the rings made of an edge {u, v} and shortest paths from a vertex x to u and v that only
+// meet at x, and the self-loops, sorted by size. If allPaths is false only one shortest path to each vertex is used, as
+// in Horton's algorithm, and if true every combination of shortest paths is, which includes every relevant cycle (see
+// RelevantCycles) but may be exponential in the size of g
+func shortestPathCycles(g *Graph, allPaths bool) [][]int {
+	incident := incidentEdges(g)
+
+	var candidates [][]int
+	seen := make(map[string]bool)
+	addCandidate := func(ring []int) {
+		key := append([]int{}, ring...)
+		sort.Ints(key)
+		if k := fmt.Sprint(key); !seen[k] {
+			seen[k] = true
+			candidates = append(candidates, ring)
+		}
+	}
+	for i, e := range g.Edges {
+		if e[0] == e[1] {
+			addCandidate([]int{i})
+		}
+	}
+
+	for _, x := range g.Vertices {
+		// the last edges of the shortest paths from x to each vertex, only the first found unless allPaths is set
+		distance := map[int]int{x: 0}
+		parentEdges := make(map[int][]int)
+		queue := []int{x}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			for _, e := range incident[v] {
+				u := otherVertex(g, e, v)
+				if u == v {
+					continue
+				}
+				if _, ok := distance[u]; !ok {
+					distance[u] = distance[v] + 1
+					parentEdges[u] = []int{e}
+					queue = append(queue, u)
+				} else if allPaths && distance[u] == distance[v]+1 {
+					parentEdges[u] = append(parentEdges[u], e)
+				}
+			}
+		}
+		// paths returns the edges of the shortest paths from v back to x, and their vertices other than x
+		var paths func(v int) ([][]int, []map[int]bool)
+		paths = func(v int) ([][]int, []map[int]bool) {
+			if v == x {
+				return [][]int{{}}, []map[int]bool{{}}
+			}
+			var edgePaths [][]int
+			var vertexSets []map[int]bool
+			for _, e := range parentEdges[v] {
+				rest, restVertices := paths(otherVertex(g, e, v))
+				for i := range rest {
+					vertices := map[int]bool{v: true}
+					for u := range restVertices[i] {
+						vertices[u] = true
+					}
+					edgePaths = append(edgePaths, append([]int{e}, rest[i]...))
+					vertexSets = append(vertexSets, vertices)
+				}
+			}
+			return edgePaths, vertexSets
+		}
+
+		for i, e := range g.Edges {
+			_, reachableU := distance[e[0]]
+			_, reachableV := distance[e[1]]
+			if e[0] == e[1] || !reachableU || !reachableV {
+				continue
+			}
+			pathsU, verticesU := paths(e[0])
+			pathsV, verticesV := paths(e[1])
+			for j, pathU := range pathsU {
+				for k, pathV := range pathsV {
+					disjoint := true
+					for v := range verticesU[j] {
+						disjoint = disjoint && !verticesV[k][v]
+					}
+					if !disjoint || helpers.Contains(pathU, i) || helpers.Contains(pathV, i) {
+						continue
+					}
+					// x to u, the edge, then v back to x
+					var ring []int
+					for l := len(pathU) - 1; l >= 0; l-- {
+						ring = append(ring, pathU[l])
+					}
+					ring = append(ring, i)
+					ring = append(ring, pathV...)
+					addCandidate(ring)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i int, j int) bool {
+		return len(candidates[i]) < len(candidates[j])
+	})
+	return candidates
+}
+
+// incidentEdges returns the positions of the edges at each vertex of g, in order, with self-loops once
+func incidentEdges(g *Graph) map[int][]int {
+	incident := make(map[int][]int)
+	for i, e := range g.Edges {
+		incident[e[0]] = append(incident[e[0]], i)
+		if e[1] != e[0] {
+			incident[e[1]] = append(incident[e[1]], i)
+		}
+	}
+	return incident
+}
+
+// otherVertex returns the vertex at the other end of edge e from v
+func otherVertex(g *Graph, e int, v int) int {
+	if g.Edges[e][0] == v {
+		return g.Edges[e][1]
+	}
+	return g.Edges[e][0]
+}
+
+// cycleBasis is a set of rings that are independent, i.e. none is the symmetric difference of others, kept as edge
+// sets in row echelon form for Gaussian elimination over GF(2)
+type cycleBasis struct {
+	words int
+	rows  map[int][]uint64 // rows by their lowest set edge (the pivot)
+}
+
+// newCycleBasis returns an empty basis for rings of a graph with numEdges edges
+func newCycleBasis(numEdges int) *cycleBasis {
+	return &cycleBasis{words: (numEdges + 63) / 64, rows: make(map[int][]uint64)}
+}
+
+// add adds ring to the basis and returns true if it is independent of the rings already in it
+func (basis *cycleBasis) add(ring []int) bool {
+	row, pivot := basis.reduce(ring)
+	if pivot != -1 {
+		basis.rows[pivot] = row
+	}
+	return pivot != -1
+}
+
+// independent returns true if ring is independent of the rings in the basis, without adding it
+func (basis *cycleBasis) independent(ring []int) bool {
+	_, pivot := basis.reduce(ring)
+	return pivot != -1
+}
+
+// reduce returns the edge set of ring less the rings in the basis, and its lowest set edge, or -1 if it is empty, i.e.
+// ring is the symmetric difference of rings in the basis
+func (basis *cycleBasis) reduce(ring []int) ([]uint64, int) {
+	row := make([]uint64, basis.words)
+	for _, e := range ring {
+		row[e/64] ^= 1 << uint(e%64)
+	}
+	for {
+		pivot := lowestBit(row)
+		if pivot == -1 {
+			return row, -1
+		}
+		existing, ok := basis.rows[pivot]
+		if !ok {
+			return row, pivot
+		}
+		for i := range row {
+			row[i] ^= existing[i]
+		}
+	}
+}
+
+// lowestBit returns the position of the lowest set bit, or -1 if none are set
+func lowestBit(row []uint64) int {
+	for i, word := range row {
+		if word != 0 {
+			for j := 0; j < 64; j++ {
+				if word&(1<<uint(j)) != 0 {
+					return 64*i + j
+				}
+			}
+		}
+	}
+	return -1
+}
+
+// ringVertices returns the vertices of a ring, in order around it starting from the first vertex of its first edge
+// that isn't in the second
+func ringVertices(g *Graph, ring []int) []int {
+	if len(ring) == 1 {
+		return []int{g.Edges[ring[0]][0]}
+	}
+	first := g.Edges[ring[0]]
+	v := first[0]
+	if second := g.Edges[ring[1]]; v == second[0] || v == second[1] {
+		v = first[1]
+	}
+	vertices := make([]int, 0, len(ring))
+	for _, e := range ring {
+		vertices = append(vertices, v)
+		if g.Edges[e][0] == v {
+			v = g.Edges[e][1]
+		} else {
+			v = g.Edges[e][0]
+		}
+	}
+	return vertices
+}
@@ -0,0 +1,145 @@
+package assembly
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSSSR(t *testing.T) {
+	cube := NewGraph([]int{0, 1, 2, 3, 4, 5, 6, 7}, [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 0}, {4, 5}, {5, 6}, {6, 7}, {7, 4},
+		{0, 4}, {1, 5}, {2, 6}, {3, 7}})
+	multigraph := NewMultigraph([]int{1, 2, 3}, [][2]int{{1, 2}, {1, 2}, {2, 3}, {3, 3}}, []string{}, []string{})
+	tests := []struct {
+		name  string
+		g     Graph
+		sizes []int
+	}{
+		{"square", NewGraphOnlyFromFile("testdata/graphs/square.txt"), []int{4}},
+		{"nine grid", NewGraphOnlyFromFile("testdata/graphs/nine_grid.txt"), []int{4, 4, 4, 4}},
+		{"two joined squares", NewGraphOnlyFromFile("testdata/graphs/two_joined_squares.txt"), []int{4, 4}},
+		{"cube", cube, []int{4, 4, 4, 4, 4}},
+		{"multigraph", multigraph, []int{1, 2}},
+		{"chain", NewGraphOnlyFromFile("testdata/graphs/chain16.txt"), nil},
+		{"aspirin", MolColourGraph("testdata/aspirin.mol"), []int{6}},
+	}
+
+	for _, tt := range tests {
+		rings := SSSR(&tt.g)
+		var sizes []int
+		for _, ring := range rings {
+			sizes = append(sizes, len(ring))
+
+			// each edge of the ring goes from the vertex before it to the one after it
+			vertices := ringVertices(&tt.g, ring)
+			for i, e := range ring {
+				v, u := vertices[i], vertices[(i+1)%len(vertices)]
+				if edge := tt.g.Edges[e]; edge != [2]int{v, u} && edge != [2]int{u, v} {
+					t.Errorf("SSSR %v ring %v is not a cycle, vertices %v", tt.name, ring, vertices)
+					break
+				}
+			}
+		}
+		if !reflect.DeepEqual(sizes, tt.sizes) {
+			t.Errorf("SSSR %v expected ring sizes %v, got %v", tt.name, tt.sizes, rings)
+		}
+	}
+}
+
+// isCycle returns true if the edges of ring go around a cycle of g in order
+func isCycle(g *Graph, ring []int) bool {
+	vertices := ringVertices(g, ring)
+	for i, e := range ring {
+		v, u := vertices[i], vertices[(i+1)%len(vertices)]
+		if edge := g.Edges[e]; edge != [2]int{v, u} && edge != [2]int{u, v} {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRelevantCycles(t *testing.T) {
+	cube := NewGraph([]int{0, 1, 2, 3, 4, 5, 6, 7}, [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 0}, {4, 5}, {5, 6}, {6, 7}, {7, 4},
+		{0, 4}, {1, 5}, {2, 6}, {3, 7}})
+	k4 := NewGraph([]int{0, 1, 2, 3}, [][2]int{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}, {2, 3}})
+	multigraph := NewMultigraph([]int{1, 2, 3}, [][2]int{{1, 2}, {1, 2}, {2, 3}, {3, 3}}, []string{}, []string{})
+	tests := []struct {
+		name  string
+		g     Graph
+		sizes []int
+	}{
+		{"square", NewGraphOnlyFromFile("testdata/graphs/square.txt"), []int{4}},
+		{"nine grid", NewGraphOnlyFromFile("testdata/graphs/nine_grid.txt"), []int{4, 4, 4, 4}},
+		{"cube", cube, []int{4, 4, 4, 4, 4, 4}},
+		{"K4", k4, []int{3, 3, 3, 3}},
+		{"multigraph", multigraph, []int{1, 2}},
+		{"chain", NewGraphOnlyFromFile("testdata/graphs/chain16.txt"), nil},
+	}
+
+	for _, tt := range tests {
+		rings := RelevantCycles(&tt.g)
+		var sizes []int
+		for _, ring := range rings {
+			sizes = append(sizes, len(ring))
+			if !isCycle(&tt.g, ring) {
+				t.Errorf("RelevantCycles %v ring %v is not a cycle", tt.name, ring)
+			}
+		}
+		if !reflect.DeepEqual(sizes, tt.sizes) {
+			t.Errorf("RelevantCycles %v expected ring sizes %v, got %v", tt.name, tt.sizes, rings)
+		}
+	}
+}
+
+func TestCycleBasis(t *testing.T) {
+	cube := NewGraph([]int{0, 1, 2, 3, 4, 5, 6, 7}, [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 0}, {4, 5}, {5, 6}, {6, 7}, {7, 4},
+		{0, 4}, {1, 5}, {2, 6}, {3, 7}})
+	multigraph := NewMultigraph([]int{1, 2, 3}, [][2]int{{1, 2}, {1, 2}, {2, 3}, {3, 3}}, []string{}, []string{})
+	for _, g := range []Graph{
+		cube,
+		multigraph,
+		NewGraphOnlyFromFile("testdata/graphs/nine_grid.txt"),
+		NewGraphOnlyFromFile("testdata/graphs/two_joined_squares.txt"),
+		MolColourGraph("testdata/aspirin.mol"),
+	} {
+		rings := CycleBasis(&g)
+		rank := len(g.Edges) - len(g.Vertices) + len(ConnectedComponentVertices(&g))
+		if len(rings) != rank {
+			t.Errorf("CycleBasis of %v gave %v rings, expected %v", g.Edges, len(rings), rank)
+		}
+		basis := newCycleBasis(len(g.Edges))
+		for _, ring := range rings {
+			if !isCycle(&g, ring) || !basis.add(ring) {
+				t.Errorf("CycleBasis of %v ring %v is not an independent cycle", g.Edges, ring)
+			}
+		}
+	}
+}
+
+func TestRingEdgesAndSystems(t *testing.T) {
+	// two triangles sharing vertex 2, and two squares sharing edge {5, 6}, joined by a bridge
+	g := NewGraph([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, [][2]int{{0, 1}, {1, 2}, {2, 0}, {2, 3}, {3, 4}, {4, 2},
+		{4, 5}, {5, 6}, {6, 7}, {7, 8}, {8, 5}, {6, 9}, {9, 10}, {10, 5}})
+	expectedRingEdges := []bool{true, true, true, true, true, true, false, true, true, true, true, true, true, true}
+	if ringEdges := RingEdges(&g); !reflect.DeepEqual(ringEdges, expectedRingEdges) {
+		t.Errorf("RingEdges expected %v, got %v", expectedRingEdges, ringEdges)
+	}
+	if systems := RingSystems(&g); !reflect.DeepEqual(systems, [][]int{{0, 1, 2, 3, 4}, {5, 6, 7, 8, 9, 10}}) {
+		t.Errorf("RingSystems expected two systems, got %v", systems)
+	}
+	if systems := FusedRingSystems(&g); !reflect.DeepEqual(systems, [][]int{{0, 1, 2}, {2, 3, 4}, {5, 6, 7, 8, 9, 10}}) {
+		t.Errorf("FusedRingSystems expected three systems, got %v", systems)
+	}
+
+	rings := SSSR(&g)
+	membership := RingMembership(&g, rings)
+	if len(membership[6]) != 0 || len(membership[7]) != 2 || len(membership[0]) != 1 {
+		t.Errorf("RingMembership expected the bridge in no rings and the shared edge in two, got %v", membership)
+	}
+
+	stats := RingStats(&g)
+	expected := RingStatistics{CycleRank: 4, RingSizes: []int{3, 3, 4, 4}, RelevantCycles: 4, RingEdges: 13,
+		RingVertices: 11, RingSystems: 2, FusedSystems: 3}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("RingStats expected %+v, got %+v", expected, stats)
+	}
+}
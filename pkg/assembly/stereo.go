@@ -0,0 +1,593 @@
+package assembly
+
+import (
+	"math"
+	"sort"
+)
+
+// Code for stereochemistry. Stereo centres and double bonds are perceived from mol file coordinates, wedge and hash bonds
+// and atom parities, and kept with the graph. The canonical forms used by GraphsIsomorphic only see the colours, so when
+// graphs have stereochemistry GraphsIsomorphic also looks for an isomorphism that keeps the configuration of every stereo
+// centre and double bond, and two fragments are only duplicates if their configurations match
+
+// Stereo is the stereochemistry of a graph, with stereo centres keyed by vertex and stereo double bonds keyed by the
+// edge, smallest vertex first. A stereo element only counts in a graph that has all of the edges that define it, so the
+// same Stereo is shared by a graph and all the fragments derived from it
+type Stereo struct {
+	Centres     map[int]StereoCentre
+	DoubleBonds map[[2]int]StereoBond
+}
+
+// StereoCentre is a tetrahedral stereo centre. Neighbours are the four neighbours of the centre, with -1 for an
+// implicit hydrogen. Clockwise is true if, looking with Neighbours[3] pointing away, Neighbours[0], Neighbours[1] and
+// Neighbours[2] go clockwise. This is MDL atom parity 1 if the neighbours are in order of atom number, hydrogen last
+type StereoCentre struct {
+	Neighbours [4]int
+	Clockwise  bool
+}
+
+// StereoBond is a double bond with E/Z stereochemistry. Substituents are the one or two neighbours of each end of the
+// bond other than the other end, in the order of the key, and Cis is true if the first substituents of each end are on
+// the same side of the bond
+type StereoBond struct {
+	Substituents [2][]int
+	Cis          bool
+}
+
+// maxStereoRing is the size of the smallest ring that a stereo double bond can be in. Double bonds in smaller rings can
+// only be cis, so they aren't stereo double bonds
+const maxStereoRing = 8
+
+// perceiveStereo finds the stereo centres and double bonds of a mol block, with all its atoms including hydrogens.
+// Stereo centres are atoms with three or four neighbours, all by single bonds and at most one hydrogen, whose
+// configuration is given by the 3D coordinates, or in 2D by a wedge or hash bond from the centre, or else by the atom
+// parity. Double bonds in chains or large rings with a substituent at each end are stereo double bonds, with the
+// configuration given by the coordinates. Atoms and double bonds marked as either are not stereo
+func perceiveStereo(block *molBlock) *Stereo {
+	stereo := Stereo{Centres: make(map[int]StereoCentre), DoubleBonds: make(map[[2]int]StereoBond)}
+	neighbours := make([][]int, len(block.atoms))
+	singleBonds := make([]int, len(block.atoms))
+	lift := make(map[[2]int]float64) // the height of the end of each wedge (1) or hash (-1) bond above its start
+	either := make(map[int]bool)
+	for i, b := range block.bonds {
+		neighbours[b[0]] = append(neighbours[b[0]], b[1])
+		neighbours[b[1]] = append(neighbours[b[1]], b[0])
+		if block.bondTypes[i] == 1 {
+			singleBonds[b[0]]++
+			singleBonds[b[1]]++
+		}
+		switch block.bondStereo[i] {
+		case 1:
+			lift[b] = 1
+		case 6:
+			lift[b] = -1
+		case 4:
+			either[b[0]] = true
+		}
+	}
+	threeD := false
+	for _, c := range block.coordinates {
+		threeD = threeD || c[2] != 0
+	}
+
+	for v, atom := range block.atoms {
+		n := len(neighbours[v])
+		if (n != 3 && n != 4) || singleBonds[v] != n || either[v] || block.parities[v] == 3 ||
+			(n == 3 && atom.Element == "N") { // amines invert, so aren't stereo centres
+			continue
+		}
+
+		// order the neighbours by atom number with hydrogen last, as for the atom parity
+		var heavy, hydrogens []int
+		for _, u := range neighbours[v] {
+			if block.atoms[u].Element == "H" {
+				hydrogens = append(hydrogens, u)
+			} else {
+				heavy = append(heavy, u)
+			}
+		}
+		if len(hydrogens) > 1 || (n == 3 && len(hydrogens) > 0) {
+			continue
+		}
+		sort.Ints(heavy)
+		ordered := append(heavy, hydrogens...)
+		centre := StereoCentre{Neighbours: [4]int{-1, -1, -1, -1}}
+		copy(centre.Neighbours[:], ordered)
+
+		wedged := false
+		for _, u := range ordered {
+			wedged = wedged || lift[[2]int{v, u}] != 0
+		}
+		var found bool
+		switch {
+		case threeD || wedged:
+			points := make([][3]float64, n)
+			for i, u := range ordered {
+				points[i] = block.coordinates[u]
+				if !threeD {
+					points[i][2] = lift[[2]int{v, u}]
+				}
+			}
+			centre.Clockwise, found = chirality(block.coordinates[v], points, !threeD)
+		case block.parities[v] == 1 || block.parities[v] == 2:
+			centre.Clockwise, found = block.parities[v] == 1, true
+		}
+		if found {
+			stereo.Centres[v] = centre
+		}
+	}
+
+	for i, b := range block.bonds {
+		if block.bondTypes[i] != 2 || block.bondStereo[i] == 3 || either[b[0]] || either[b[1]] {
+			continue
+		}
+		key := b
+		if key[0] > key[1] {
+			key = [2]int{key[1], key[0]}
+		}
+		var bond StereoBond
+		for end, v := range key {
+			for _, u := range neighbours[v] {
+				if u != key[1-end] {
+					bond.Substituents[end] = append(bond.Substituents[end], u)
+				}
+			}
+			sort.Ints(bond.Substituents[end])
+		}
+		if len(bond.Substituents[0]) == 0 || len(bond.Substituents[1]) == 0 || len(bond.Substituents[0]) > 2 ||
+			len(bond.Substituents[1]) > 2 || ringSize(neighbours, key) < maxStereoRing {
+			continue
+		}
+		var found bool
+		bond.Cis, found = doubleBondCis(block.coordinates[key[0]], block.coordinates[key[1]],
+			block.coordinates[bond.Substituents[0][0]], block.coordinates[bond.Substituents[1][0]])
+		if found {
+			stereo.DoubleBonds[key] = bond
+		}
+	}
+
+	return &stereo
+}
+
+// chirality returns whether the neighbours of a stereo centre, in order, are clockwise (see StereoCentre), from their
+// coordinates. If there are three, the fourth is an implicit hydrogen opposite them. In 2D the heights of the neighbours
+// are from the wedge and hash bonds, so the bond vectors are scaled to the same length first. The result is not found
+// if the neighbours are flat
+func chirality(centre [3]float64, points [][3]float64, scale bool) (bool, bool) {
+	vectors := make([][3]float64, 0, 4)
+	var sum [3]float64
+	for _, p := range points {
+		var v [3]float64
+		for k := range v {
+			v[k] = p[k] - centre[k]
+		}
+		if scale {
+			length := math.Hypot(v[0], v[1])
+			if length == 0 {
+				return false, false
+			}
+			v[0], v[1] = v[0]/length, v[1]/length
+		}
+		vectors = append(vectors, v)
+		for k := range sum {
+			sum[k] += v[k]
+		}
+	}
+	if len(vectors) == 3 {
+		vectors = append(vectors, [3]float64{-sum[0], -sum[1], -sum[2]})
+	}
+
+	// the sign of the volume of the tetrahedron of the neighbours
+	var edges [3][3]float64
+	for i := range edges {
+		for k := range edges[i] {
+			edges[i][k] = vectors[i+1][k] - vectors[0][k]
+		}
+	}
+	volume := edges[0][0]*(edges[1][1]*edges[2][2]-edges[1][2]*edges[2][1]) -
+		edges[0][1]*(edges[1][0]*edges[2][2]-edges[1][2]*edges[2][0]) +
+		edges[0][2]*(edges[1][0]*edges[2][1]-edges[1][1]*edges[2][0])
+	if math.Abs(volume) < 1e-6 {
+		return false, false
+	}
+	return volume > 0, true
+}
+
+// doubleBondCis returns whether substituents x of a and y of b are on the same side of the double bond a=b, from
+// their coordinates. The result is not found if either substituent is in line with the bond
+func doubleBondCis(a [3]float64, b [3]float64, x [3]float64, y [3]float64) (bool, bool) {
+	var bond, fromA, fromB [3]float64
+	for k := range bond {
+		bond[k] = b[k] - a[k]
+		fromA[k] = x[k] - a[k]
+		fromB[k] = y[k] - b[k]
+	}
+	dot := func(u [3]float64, v [3]float64) float64 {
+		return u[0]*v[0] + u[1]*v[1] + u[2]*v[2]
+	}
+	length := dot(bond, bond)
+	if length == 0 {
+		return false, false
+	}
+
+	// the parts of the substituent bonds at right angles to the double bond
+	ta, tb := dot(fromA, bond)/length, dot(fromB, bond)/length
+	for k := range bond {
+		fromA[k] -= ta * bond[k]
+		fromB[k] -= tb * bond[k]
+	}
+	side := dot(fromA, fromB)
+	if math.Abs(side) < 1e-6 {
+		return false, false
+	}
+	return side > 0, true
+}
+
+// ringSize returns the number of bonds in the smallest ring with the bond key, or maxStereoRing if there isn't one
+// smaller than that
+func ringSize(neighbours [][]int, key [2]int) int {
+	distance := map[int]int{key[0]: 0}
+	queue := []int{key[0]}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		if distance[v]+1 >= maxStereoRing {
+			break
+		}
+		for _, u := range neighbours[v] {
+			if _, seen := distance[u]; seen || (v == key[0] && u == key[1]) {
+				continue
+			}
+			if u == key[1] {
+				return distance[v] + 2
+			}
+			distance[u] = distance[v] + 1
+			queue = append(queue, u)
+		}
+	}
+	return maxStereoRing
+}
+
+// stripHStereo renumbers stereochemistry perceived with all the atoms of a mol block for the graph without hydrogen
+// atoms (see stripHAtoms). Hydrogen neighbours of stereo centres become implicit, and hydrogen substituents of double
+// bonds are left out
+func stripHStereo(stereo *Stereo, atoms []MolAtom) *Stereo {
+	newIndex := make(map[int]int)
+	for i, atom := range atoms {
+		if atom.Element != "H" {
+			newIndex[i] = len(newIndex)
+		}
+	}
+
+	stripped := Stereo{Centres: make(map[int]StereoCentre), DoubleBonds: make(map[[2]int]StereoBond)}
+	for v, centre := range stereo.Centres {
+		newCentre := StereoCentre{Clockwise: centre.Clockwise}
+		for i, u := range centre.Neighbours {
+			newCentre.Neighbours[i] = -1
+			if w, ok := newIndex[u]; ok {
+				newCentre.Neighbours[i] = w
+			}
+		}
+		stripped.Centres[newIndex[v]] = newCentre
+	}
+	for key, bond := range stereo.DoubleBonds {
+		newBond := StereoBond{Cis: bond.Cis}
+		for end, substituents := range bond.Substituents {
+			for i, u := range substituents {
+				if w, ok := newIndex[u]; ok {
+					newBond.Substituents[end] = append(newBond.Substituents[end], w)
+				} else if i == 0 {
+					// the configuration is now from the other substituent
+					newBond.Cis = !newBond.Cis
+				}
+			}
+		}
+		if len(newBond.Substituents[0]) > 0 && len(newBond.Substituents[1]) > 0 {
+			stripped.DoubleBonds[[2]int{newIndex[key[0]], newIndex[key[1]]}] = newBond
+		}
+	}
+	return &stripped
+}
+
+// merge returns the stereochemistry of a graph made by adding a graph with stereochemistry right, with vertices
+// relabelled by vertexMap, to one with stereochemistry stereo, e.g. by recombineGraphsFrom. The elements of right at its
+// vertices are relabelled and added to those of stereo, and stereo and right are unchanged
+func (stereo *Stereo) merge(right *Stereo, vertices []int, vertexMap map[int]int) *Stereo {
+	if right == nil {
+		return stereo
+	}
+	relabel := func(v int) int {
+		if u, ok := vertexMap[v]; ok {
+			return u
+		}
+		return v
+	}
+	inRight := make(map[int]bool)
+	for _, v := range vertices {
+		inRight[v] = true
+	}
+
+	merged := Stereo{Centres: make(map[int]StereoCentre), DoubleBonds: make(map[[2]int]StereoBond)}
+	if stereo != nil {
+		for v, centre := range stereo.Centres {
+			merged.Centres[v] = centre
+		}
+		for key, bond := range stereo.DoubleBonds {
+			merged.DoubleBonds[key] = bond
+		}
+	}
+	for v, centre := range right.Centres {
+		if !inRight[v] {
+			continue
+		}
+		newCentre := StereoCentre{Clockwise: centre.Clockwise}
+		for i, u := range centre.Neighbours {
+			newCentre.Neighbours[i] = u
+			if u != -1 {
+				newCentre.Neighbours[i] = relabel(u)
+			}
+		}
+		merged.Centres[relabel(v)] = newCentre
+	}
+	for key, bond := range right.DoubleBonds {
+		if !inRight[key[0]] || !inRight[key[1]] {
+			continue
+		}
+		newKey := [2]int{relabel(key[0]), relabel(key[1])}
+		newBond := StereoBond{Cis: bond.Cis}
+		for end, substituents := range bond.Substituents {
+			for _, u := range substituents {
+				newBond.Substituents[end] = append(newBond.Substituents[end], relabel(u))
+			}
+		}
+		if newKey[0] > newKey[1] {
+			newKey = [2]int{newKey[1], newKey[0]}
+			newBond.Substituents = [2][]int{newBond.Substituents[1], newBond.Substituents[0]}
+		}
+		merged.DoubleBonds[newKey] = newBond
+	}
+	return &merged
+}
+
+// stereoElements returns the stereo centres and double bonds of g.Stereo that count in g, i.e. whose edges to the
+// neighbours of the centre, or the double bond and a substituent at each end, are all in g. Double bonds are given
+// with the configuration of the first substituent of each end that is in g
+func stereoElements(g *Graph) (map[int]StereoCentre, map[[2]int]StereoBond) {
+	centres := make(map[int]StereoCentre)
+	bonds := make(map[[2]int]StereoBond)
+	if g.Stereo == nil {
+		return centres, bonds
+	}
+	edges := make(map[[2]int]bool)
+	for _, e := range g.Edges {
+		edges[e] = true
+		edges[[2]int{e[1], e[0]}] = true
+	}
+
+	for v, centre := range g.Stereo.Centres {
+		inGraph := true
+		for _, u := range centre.Neighbours {
+			inGraph = inGraph && (u == -1 || edges[[2]int{v, u}])
+		}
+		if inGraph {
+			centres[v] = centre
+		}
+	}
+	for key, bond := range g.Stereo.DoubleBonds {
+		if !edges[key] {
+			continue
+		}
+		found := StereoBond{Cis: bond.Cis}
+		for end, substituents := range bond.Substituents {
+			for i, u := range substituents {
+				if edges[[2]int{key[end], u}] {
+					found.Substituents[end] = []int{u}
+					if i == 1 {
+						found.Cis = !found.Cis
+					}
+					break
+				}
+			}
+		}
+		if len(found.Substituents[0]) > 0 && len(found.Substituents[1]) > 0 {
+			bonds[key] = found
+		}
+	}
+	return centres, bonds
+}
+
+// stereoIsomorphic returns true if there is an isomorphism of left to right that maps each stereo centre and double
+// bond of left that counts (see stereoElements) to one of right with the same configuration. left and right must
+// already be isomorphic without stereochemistry
+func stereoIsomorphic(left *Graph, right *Graph) bool {
+	leftCentres, leftBonds := stereoElements(left)
+	rightCentres, rightBonds := stereoElements(right)
+	if len(leftCentres) != len(rightCentres) || len(leftBonds) != len(rightBonds) {
+		return false
+	}
+	if len(leftCentres) == 0 && len(leftBonds) == 0 {
+		return true
+	}
+
+	return graphIsomorphisms(left, right, func(mapping map[int]int) bool {
+		for v, centre := range leftCentres {
+			rightCentre, ok := rightCentres[mapping[v]]
+			if !ok || !sameCentre(centre, rightCentre, mapping) {
+				return false
+			}
+		}
+		for key, bond := range leftBonds {
+			rightKey := [2]int{mapping[key[0]], mapping[key[1]]}
+			mapped := [2]int{mapping[bond.Substituents[0][0]], mapping[bond.Substituents[1][0]]}
+			if rightKey[0] > rightKey[1] {
+				rightKey = [2]int{rightKey[1], rightKey[0]}
+				mapped = [2]int{mapped[1], mapped[0]}
+			}
+			if _, ok := rightBonds[rightKey]; !ok {
+				return false
+			}
+
+			// the configuration of right from the substituents mapped to, which may not be those rightBonds has
+			rightBond := right.Stereo.DoubleBonds[rightKey]
+			cis := rightBond.Cis
+			for end, substituents := range rightBond.Substituents {
+				switch {
+				case len(substituents) > 0 && substituents[0] == mapped[end]:
+				case len(substituents) > 1 && substituents[1] == mapped[end]:
+					cis = !cis
+				default:
+					return false
+				}
+			}
+			if cis != bond.Cis {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// sameCentre returns true if stereo centre left, with its neighbours mapped by mapping, has the same configuration as
+// right. The neighbours are the same if one order is an even permutation of the other
+func sameCentre(left StereoCentre, right StereoCentre, mapping map[int]int) bool {
+	position := make(map[int]int)
+	for i, u := range right.Neighbours {
+		position[u] = i
+	}
+	var permutation [4]int
+	for i, u := range left.Neighbours {
+		if u != -1 {
+			u = mapping[u]
+		}
+		p, ok := position[u]
+		if !ok {
+			return false
+		}
+		permutation[i] = p
+	}
+	odd := false
+	for i := range permutation {
+		for j := i + 1; j < len(permutation); j++ {
+			if permutation[i] > permutation[j] {
+				odd = !odd
+			}
+		}
+	}
+	return (left.Clockwise != odd) == right.Clockwise
+}
+
+// graphIsomorphisms calls found with each isomorphism of left to right that keeps vertex and edge colours, as a map of
+// the vertices of left to those of right, until found returns true. It returns true if found returned true. Edge
+// directions are ignored
+func graphIsomorphisms(left *Graph, right *Graph, found func(map[int]int) bool) bool {
+	if len(left.Vertices) != len(right.Vertices) {
+		return false
+	}
+	leftAdjacency, rightAdjacency := colourAdjacency(left), colourAdjacency(right)
+	leftColours, rightColours := make(map[int]int), make(map[int]int)
+	if GraphIsVertexColoured(left) && GraphIsVertexColoured(right) {
+		leftColours, rightColours = VertexColourMap(left), VertexColourMap(right)
+	}
+
+	// match the vertices of left in breadth first order, so most have a neighbour matched already
+	var order []int
+	seen := make(map[int]bool)
+	for _, start := range left.Vertices {
+		if seen[start] {
+			continue
+		}
+		seen[start] = true
+		queue := []int{start}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			order = append(order, v)
+			neighbours := make([]int, 0, len(leftAdjacency[v]))
+			for u := range leftAdjacency[v] {
+				neighbours = append(neighbours, u)
+			}
+			sort.Ints(neighbours)
+			for _, u := range neighbours {
+				if !seen[u] {
+					seen[u] = true
+					queue = append(queue, u)
+				}
+			}
+		}
+	}
+
+	mapping := make(map[int]int)
+	used := make(map[int]bool)
+	var match func(k int) bool
+	match = func(k int) bool {
+		if k == len(order) {
+			return found(mapping)
+		}
+		v := order[k]
+		for _, w := range right.Vertices {
+			if used[w] || leftColours[v] != rightColours[w] || len(leftAdjacency[v]) != len(rightAdjacency[w]) {
+				continue
+			}
+			mapping[v] = w
+			if adjacencyMatches(leftAdjacency[v], rightAdjacency[w], mapping, used, v, w) {
+				used[w] = true
+				if match(k + 1) {
+					return true
+				}
+				used[w] = false
+			}
+			delete(mapping, v)
+		}
+		return false
+	}
+	return match(0)
+}
+
+// adjacencyMatches returns true if the edges from v to vertices already mapped, including v, are the same as those from
+// w to the vertices they are mapped to
+func adjacencyMatches(leftEdges map[int][]int, rightEdges map[int][]int, mapping map[int]int, used map[int]bool, v int,
+	w int) bool {
+	mappedLeft := 0
+	for u, colours := range leftEdges {
+		mappedU, ok := mapping[u]
+		if !ok {
+			continue
+		}
+		mappedLeft++
+		if !SliceEqual(colours, rightEdges[mappedU]) {
+			return false
+		}
+	}
+	mappedRight := 0
+	for u := range rightEdges {
+		if used[u] || u == w {
+			mappedRight++
+		}
+	}
+	return mappedLeft == mappedRight
+}
+
+// colourAdjacency returns the neighbours of each vertex of g, with the sorted colours of the edges to each neighbour
+func colourAdjacency(g *Graph) map[int]map[int][]int {
+	adjacency := make(map[int]map[int][]int)
+	for _, v := range g.Vertices {
+		adjacency[v] = make(map[int][]int)
+	}
+	edgeColoured := GraphIsEdgeColoured(g)
+	for i, e := range g.Edges {
+		colour := 0
+		if edgeColoured {
+			colour = g.EdgeColours[i]
+		}
+		adjacency[e[0]][e[1]] = append(adjacency[e[0]][e[1]], colour)
+		if e[0] != e[1] {
+			adjacency[e[1]][e[0]] = append(adjacency[e[1]][e[0]], colour)
+		}
+	}
+	for _, neighbours := range adjacency {
+		for u := range neighbours {
+			sort.Ints(neighbours[u])
+		}
+	}
+	return adjacency
+}
@@ -0,0 +1,95 @@
+package assembly
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPerceiveStereo(t *testing.T) {
+	graphs := ParseSDFileColouring("testdata/stereo_test.sdf", true, Colouring{Stereo: true})
+	clockwise := map[int]StereoCentre{0: {[4]int{1, 2, 3, -1}, true}}
+	anticlockwise := map[int]StereoCentre{0: {[4]int{1, 2, 3, -1}, false}}
+	none := map[int]StereoCentre{}
+	noBonds := map[[2]int]StereoBond{}
+	tests := []struct {
+		name    string
+		centres map[int]StereoCentre
+		bonds   map[[2]int]StereoBond
+	}{
+		{"F wedge", clockwise, noBonds},
+		{"F hash", anticlockwise, noBonds},
+		{"F wedge rotated", clockwise, noBonds},
+		{"parity 1", clockwise, noBonds},
+		{"parity 2", anticlockwise, noBonds},
+		{"3D", clockwise, noBonds},
+		{"explicit H", clockwise, noBonds},
+		{"no stereo", none, noBonds},
+		{"cis-2-butene", none, map[[2]int]StereoBond{{1, 2}: {[2][]int{{0}, {3}}, true}}},
+		{"trans-2-butene", none, map[[2]int]StereoBond{{1, 2}: {[2][]int{{0}, {3}}, false}}},
+		{"2-butene either", none, noBonds},
+	}
+
+	if len(graphs) != len(tests) {
+		t.Fatalf("expected %v molecules, got %v", len(tests), len(graphs))
+	}
+	for i, tt := range tests {
+		if !reflect.DeepEqual(graphs[i].Stereo.Centres, tt.centres) || !reflect.DeepEqual(graphs[i].Stereo.DoubleBonds, tt.bonds) {
+			t.Errorf("perceiveStereo %v expected %v %v, got %v %v", tt.name, tt.centres, tt.bonds,
+				graphs[i].Stereo.Centres, graphs[i].Stereo.DoubleBonds)
+		}
+	}
+}
+
+func TestGraphsIsomorphicStereo(t *testing.T) {
+	graphs := ParseSDFileColouring("testdata/stereo_test.sdf", true, Colouring{Stereo: true})
+	plain := ParseSDFile("testdata/stereo_test.sdf", true)
+
+	// the molecules in each class have the same configuration
+	classes := []int{0, 1, 0, 0, 1, 0, 0, 2, 3, 4, 5}
+	for i := range graphs {
+		for j := range graphs {
+			if GraphsIsomorphic(&graphs[i], &graphs[j]) != (classes[i] == classes[j]) {
+				t.Errorf("GraphsIsomorphic molecules %v and %v with stereo should be %v", i, j, classes[i] == classes[j])
+			}
+			if i < 8 && j < 8 && !GraphsIsomorphic(&plain[i], &plain[j]) {
+				t.Errorf("GraphsIsomorphic molecules %v and %v without stereo should be true", i, j)
+			}
+		}
+	}
+}
+
+func TestStereoFragments(t *testing.T) {
+	// the halves of the molecule are the same with the wedges the same way round, and mirror images the other way round
+	tests := []struct {
+		fileName string
+		same     bool
+	}{
+		{"testdata/dichlorodifluorooctane_wedge.mol", true},
+		{"testdata/dichlorodifluorooctane_hash.mol", false},
+	}
+
+	for _, tt := range tests {
+		g, err := MolGraphFromFileColouring(tt.fileName, Colouring{Stereo: true})
+		check(err)
+		left, _ := BreakGraphOnEdges(&g, []int{0, 1, 2, 7, 8})
+		right, _ := BreakGraphOnEdges(&g, []int{4, 5, 6, 9, 10})
+		if GraphsIsomorphic(&left, &right) != tt.same {
+			t.Errorf("GraphsIsomorphic halves of %v should be %v", tt.fileName, tt.same)
+		}
+
+		// a fragment without all the neighbours of the stereo centre has no configuration
+		left, _ = BreakGraphOnEdges(&g, []int{0, 1, 7, 8})
+		right, _ = BreakGraphOnEdges(&g, []int{5, 6, 9, 10})
+		if !GraphsIsomorphic(&left, &right) {
+			t.Errorf("GraphsIsomorphic fragments of %v without whole stereo centres should be true", tt.fileName)
+		}
+
+		pathways := Assembly(g, 100, 100, "shortest")
+		if index := AssemblyIndex(&pathways[0], &g); index != 6 {
+			t.Errorf("Assembly %v with stereo expected index 6, got %v", tt.fileName, index)
+		}
+		if err := VerifyPathway(&g, &pathways[0]); err != nil {
+			t.Errorf("Assembly %v with stereo gave an invalid pathway: %v", tt.fileName, err)
+		}
+	}
+}
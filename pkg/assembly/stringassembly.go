@@ -0,0 +1,356 @@
+package assembly
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// This file contains assembly for strings (e.g. DNA, peptide sequences or text). Here a join concatenates two substrings,
+// so a string of n characters has a maximum assembly index of n - 1, and each repeated substring of length k saves k - 1 steps.
+// The search works directly on substrings rather than graphs, which is much faster, but the results are returned as
+// Pathway objects on the path graph from StringGraph, so they can be used with AssemblyIndex, AssemblyString etc. and
+// checked against Assembly(StringGraph(s), ...)
+
+// StringGraph returns the path graph encoding of a string. Vertex i is the boundary before character i, and edge i goes
+// from vertex i to vertex i + 1, coloured by character i. The graph is directed so that a substring only matches itself,
+// and not its reverse
+func StringGraph(s string) Graph {
+	return stringPathGraph([]rune(s), 0)
+}
+
+// StringFromGraph returns the string encoded by a path graph from StringGraph, or a fragment of one, by reading the edge
+// colours in edge order
+func StringFromGraph(g *Graph) string {
+	var sb strings.Builder
+	for _, c := range g.EdgeColours {
+		sb.WriteString(Colours.Name(c))
+	}
+	return sb.String()
+}
+
+// StringAssembly finds a shortest assembly pathway for a string. As with Assembly a slice of pathways is returned, which
+// currently contains the single best pathway. The original graph for the pathway is StringGraph(s)
+func StringAssembly(s string) []Pathway {
+	search := stringSearch{runes: []rune(s), visited: make(map[string]int)}
+	search.extend([]stringSegment{{0, len(search.runes)}}, 0, []stringMove{})
+
+	// rebuild the best pathway from its moves
+	var fragments []Graph
+	var duplicates []Duplicates
+	segments := []stringSegment{{0, len(search.runes)}}
+	for _, move := range search.bestMoves {
+		fragments = append(fragments, stringPathGraph(search.runes[move.left:move.left+move.k], move.left))
+		duplicates = append(duplicates, Duplicates{stringEdges(move.left, move.k), stringEdges(move.right, move.k)})
+		segments = applyStringMove(segments, move)
+	}
+
+	remnant, atomEquivalents := search.remnantGraph(segments)
+	return []Pathway{NewPathway(fragments, remnant, duplicates, atomEquivalents)}
+}
+
+// StringAssemblyIndex returns the assembly index of a string
+func StringAssemblyIndex(s string) int {
+	g := StringGraph(s)
+	return AssemblyIndex(&StringAssembly(s)[0], &g)
+}
+
+// stringSegment is the part of the original string from start up to (but not including) end
+type stringSegment struct {
+	start int
+	end   int
+}
+
+func (seg stringSegment) length() int {
+	return seg.end - seg.start
+}
+
+// stringMove is a duplicate of length k. The substring at position left is removed into the pathway, and the same
+// substring at position right is broken off from the rest of its segment. Positions are in the original string
+type stringMove struct {
+	left, right, k int
+}
+
+// stringSearch holds the state of the search for a string. The steps that can still be saved only depend on the multiset
+// of substrings in the remnant, not their positions, so visited records the most steps saved so far for each multiset,
+// and a multiset is not searched again unless it is reached with more steps saved
+type stringSearch struct {
+	runes          []rune
+	visited        map[string]int
+	bestStepsSaved int
+	bestMoves      []stringMove
+}
+
+// extend is the string version of ExtendPathway. It updates the best moves found so far, then tries each duplicate
+// in the segments, longest first, bounding the search with maxStringStepsSaved. Orderings of moves aren't pruned, as
+// visited merges segments with the same substrings at different positions, whose valid moves differ
+func (search *stringSearch) extend(segments []stringSegment, stepsSaved int, moves []stringMove) {
+	key := search.key(segments)
+	if seen, ok := search.visited[key]; ok && seen >= stepsSaved {
+		return
+	}
+	search.visited[key] = stepsSaved
+
+	if stepsSaved > search.bestStepsSaved || search.bestMoves == nil {
+		search.bestStepsSaved = stepsSaved
+		search.bestMoves = append([]stringMove{}, moves...)
+	}
+
+	if stepsSaved+search.maxStringStepsSaved(segments) <= search.bestStepsSaved {
+		return
+	}
+
+	candidates := search.duplicateMoves(segments)
+	sort.SliceStable(candidates, func(x, y int) bool {
+		return candidates[x].k > candidates[y].k
+	})
+	for _, move := range candidates {
+		search.extend(applyStringMove(segments, move), stepsSaved+move.k-1, append(moves, move))
+	}
+}
+
+// duplicateMoves returns all the pairs of matching substrings, of at least 2 characters, in the segments. As with
+// CheckSubgraphMatches each pair is only returned once, with the match after the substring, and substrings are extended
+// one character at a time until they no longer have a match
+func (search *stringSearch) duplicateMoves(segments []stringSegment) []stringMove {
+	sort.Slice(segments, func(x, y int) bool {
+		return segments[x].start < segments[y].start
+	})
+
+	var moves []stringMove
+	for i, seg := range segments {
+		for left := seg.start; left < seg.end; left++ {
+			for k := 2; left+k <= seg.end; k++ {
+				sub := search.runes[left : left+k]
+				found := false
+				for j := i; j < len(segments); j++ {
+					right := segments[j].start
+					if j == i {
+						right = left + k
+					}
+					for ; right+k <= segments[j].end; right++ {
+						if runesEqual(sub, search.runes[right:right+k]) {
+							found = true
+							moves = append(moves, stringMove{left, right, k})
+						}
+					}
+				}
+
+				// if there are no matches for this substring there will be none for longer ones containing it
+				if !found {
+					break
+				}
+			}
+		}
+	}
+	return moves
+}
+
+// key returns a key for the multiset of substrings in the segments. Segments shorter than 2 characters cannot contain a
+// duplicate, so they are left out
+func (search *stringSearch) key(segments []stringSegment) string {
+	var substrings []string
+	for _, seg := range segments {
+		if seg.length() >= 2 {
+			substrings = append(substrings, string(search.runes[seg.start:seg.end]))
+		}
+	}
+	sort.Strings(substrings)
+	return strings.Join(substrings, "\x00")
+}
+
+// applyStringMove returns the segments after a move. The removed substring is not in the output, and the matching
+// substring is a segment of its own
+func applyStringMove(segments []stringSegment, move stringMove) []stringSegment {
+	var out []stringSegment
+	for _, seg := range segments {
+		if move.left >= seg.start && move.left < seg.end {
+			out = append(out, stringSegment{seg.start, move.left})
+			seg = stringSegment{move.left + move.k, seg.end}
+		}
+		if move.right >= seg.start && move.right < seg.end {
+			out = append(out, stringSegment{seg.start, move.right}, stringSegment{move.right, move.right + move.k})
+			seg = stringSegment{move.right + move.k, seg.end}
+		}
+		out = append(out, seg)
+	}
+	return out
+}
+
+// maxStringStepsSaved bounds the steps that could still be saved within the segments. Building segments with n1, n2, ...
+// characters takes (n1 - 1) + (n2 - 1) + ... steps without duplicates, and at least log2 of the longest, as duplicates
+// can be shared between segments, so at most the difference can be saved. Also, every character in a future duplicate is in a substring
+// that is repeated somewhere in the segments, and a duplicate of k characters saves k - 1 steps, so a character whose
+// longest repeated substring has r characters can contribute at most 1 - 1/r steps. Finally see kmerStepsSaved
+func (search *stringSearch) maxStringStepsSaved(segments []stringSegment) int {
+	bound, longestSegment := 0, 0
+	for _, seg := range segments {
+		if n := seg.length(); n > 0 {
+			bound += n - 1
+			if n > longestSegment {
+				longestSegment = n
+			}
+		}
+	}
+	if longestSegment > 0 {
+		bound -= int(math.Floor(math.Log2(float64(longestSegment))))
+	}
+
+	longest := search.longestRepeats(segments)
+	repeatBound := 0.0
+	for _, r := range longest {
+		if r >= 2 {
+			repeatBound += 1 - 1/float64(r)
+		}
+	}
+	if int(repeatBound) < bound {
+		bound = int(repeatBound)
+	}
+
+	if kmerBound := search.kmerStepsSaved(segments); kmerBound < bound {
+		bound = kmerBound
+	}
+	return bound
+}
+
+// kmerStepsSaved bounds the steps saved using the distinct substrings of length k (k-mers). Joining the segments with
+// distinct separator characters gives a single string with the same possible duplicates. Each join adds at most k - 1
+// new k-mers to a string, so a string with D distinct k-mers has an assembly index of at least D / (k - 1), and the
+// steps saved are at most the number of characters - 1 minus this
+func (search *stringSearch) kmerStepsSaved(segments []stringSegment) int {
+	var joined []rune
+	for n, seg := range segments {
+		if seg.length() == 0 {
+			continue
+		}
+		if len(joined) > 0 {
+			joined = append(joined, rune(-1-n))
+		}
+		joined = append(joined, search.runes[seg.start:seg.end]...)
+	}
+	if len(joined) == 0 {
+		return 0
+	}
+
+	minIndex := 0
+	for k := 2; k <= 4; k++ {
+		kmers := make(map[[4]rune]bool)
+		for i := 0; i+k <= len(joined); i++ {
+			var kmer [4]rune
+			copy(kmer[:], joined[i:i+k])
+			kmers[kmer] = true
+		}
+		if index := (len(kmers) + k - 2) / (k - 1); index > minIndex {
+			minIndex = index
+		}
+	}
+	return len(joined) - 1 - minIndex
+}
+
+// longestRepeats returns, for each character position in the original string, the length of the longest substring
+// containing it that appears again, without overlap, somewhere in the segments. Positions not in a segment are 0
+func (search *stringSearch) longestRepeats(segments []stringSegment) []int {
+	longest := make([]int, len(search.runes))
+	for i, seg1 := range segments {
+		for _, seg2 := range segments[i:] {
+			for p := seg1.start; p < seg1.end; p++ {
+				q := seg2.start
+				if seg1 == seg2 {
+					q = p + 1
+				}
+				for ; q < seg2.end; q++ {
+					// length of the common substring starting at p and q, limited so that they do not overlap
+					limit := seg1.end - p
+					if seg2.end-q < limit {
+						limit = seg2.end - q
+					}
+					if seg1 == seg2 && q-p < limit {
+						limit = q - p
+					}
+					k := 0
+					for k < limit && search.runes[p+k] == search.runes[q+k] {
+						k++
+					}
+					for m := 0; m < k; m++ {
+						if k > longest[p+m] {
+							longest[p+m] = k
+						}
+						if k > longest[q+m] {
+							longest[q+m] = k
+						}
+					}
+				}
+			}
+		}
+	}
+	return longest
+}
+
+// remnantGraph returns the graph of the remnant segments, in string order, each as a separate directed path. As in
+// Assembly, vertices keep their labels from StringGraph, except where a vertex is shared by two segments. The second
+// copy is relabelled, and recorded in the atom equivalents returned
+func (search *stringSearch) remnantGraph(segments []stringSegment) (Graph, [][]int) {
+	sort.Slice(segments, func(x, y int) bool {
+		return segments[x].start < segments[y].start
+	})
+
+	remnant := NewColourGraphFromIDs([]int{}, [][2]int{}, []int{}, []int{})
+	remnant.Directed = true
+	var atomEquivalents [][]int
+	used := make(map[int]bool)
+	nextVertex := len(search.runes) + 1
+	for _, seg := range segments {
+		if seg.length() == 0 {
+			continue
+		}
+		path := stringPathGraph(search.runes[seg.start:seg.end], seg.start)
+		if used[seg.start] {
+			atomEquivalents = append(atomEquivalents, []int{seg.start, nextVertex})
+			path.Vertices[0] = nextVertex
+			path.Edges[0][0] = nextVertex
+			nextVertex++
+		}
+		used[seg.end] = true
+		remnant.Vertices = append(remnant.Vertices, path.Vertices...)
+		remnant.Edges = append(remnant.Edges, path.Edges...)
+		remnant.EdgeColours = append(remnant.EdgeColours, path.EdgeColours...)
+	}
+	return remnant, atomEquivalents
+}
+
+// stringPathGraph returns the directed path graph of runes, with vertices labelled from firstVertex
+func stringPathGraph(runes []rune, firstVertex int) Graph {
+	vertices := []int{firstVertex}
+	edges := [][2]int{}
+	edgeColours := []int{}
+	for i, r := range runes {
+		vertices = append(vertices, firstVertex+i+1)
+		edges = append(edges, [2]int{firstVertex + i, firstVertex + i + 1})
+		edgeColours = append(edgeColours, Colours.Intern(string(r)))
+	}
+	g := NewColourGraphFromIDs(vertices, edges, []int{}, edgeColours)
+	g.Directed = true
+	return g
+}
+
+// stringEdges returns the edges of StringGraph covering the substring of length k starting at position start
+func stringEdges(start int, k int) [][2]int {
+	edges := make([][2]int, k)
+	for i := range edges {
+		edges[i] = [2]int{start + i, start + i + 1}
+	}
+	return edges
+}
+
+// runesEqual returns true if two rune slices are the same
+func runesEqual(r1 []rune, r2 []rune) bool {
+	if len(r1) != len(r2) {
+		return false
+	}
+	for i := range r1 {
+		if r1[i] != r2[i] {
+			return false
+		}
+	}
+	return true
+}
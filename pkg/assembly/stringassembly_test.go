@@ -0,0 +1,91 @@
+package assembly
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestStringAssemblyIndex(t *testing.T) {
+	tests := []struct {
+		s             string
+		assemblyIndex int
+	}{
+		{"a", 0},
+		{"ab", 1},
+		{"abab", 2},
+		{"abcd", 3},
+		{"aaaaaaaa", 3},
+		{"abcabc", 3},
+		{"abcba", 4},
+		{"bananaban", 5},
+		{"ATGATGATGATG", 4},
+		{"αβαβ", 2},
+		{"aababab", 4},
+	}
+
+	for _, tt := range tests {
+		assemblyIndex := StringAssemblyIndex(tt.s)
+		if assemblyIndex != tt.assemblyIndex {
+			t.Errorf("StringAssemblyIndex error, string %v, expected %v, got %v", tt.s, tt.assemblyIndex, assemblyIndex)
+		}
+	}
+}
+
+// TestStringAssemblyGraph checks StringAssembly against Assembly on the path graph encoding of the same string
+func TestStringAssemblyGraph(t *testing.T) {
+	tests := []string{"abab", "abcba", "abcabc", "aabaab", "bananaban", "ATGCATGC"}
+
+	for _, s := range tests {
+		g := StringGraph(s)
+		stringPathway := StringAssembly(s)[0]
+		graphPathway := Assembly(g, 100, 100, "shortest")[0]
+
+		stringIndex := AssemblyIndex(&stringPathway, &g)
+		graphIndex := AssemblyIndex(&graphPathway, &g)
+		if stringIndex != graphIndex {
+			t.Errorf("StringAssembly and Assembly disagree on %v, string %v, graph %v\n%v\n%v",
+				s, stringIndex, graphIndex, PathwayString(&stringPathway), PathwayString(&graphPathway))
+		}
+
+		// the duplicates should be the same substrings, and be within the original string
+		for i, d := range stringPathway.duplicates {
+			fragment := StringFromGraph(&stringPathway.pathway[i])
+			left := s[d.left[0][0]:d.left[len(d.left)-1][1]]
+			right := s[d.right[0][0]:d.right[len(d.right)-1][1]]
+			if left != fragment || right != fragment {
+				t.Errorf("StringAssembly duplicate error on %v, fragment %v, duplicates %v %v", s, fragment, left, right)
+			}
+		}
+	}
+}
+
+// TestStringAssemblyIndexProperty checks StringAssemblyIndex against Assembly on the path graph encoding for every
+// string of a and b up to 8 characters, and for random strings of a, b and c up to 11 characters
+func TestStringAssemblyIndexProperty(t *testing.T) {
+	var tests []string
+	for n := 2; n <= 8; n++ {
+		for bits := 0; bits < 1<<n; bits++ {
+			runes := make([]rune, n)
+			for i := range runes {
+				runes[i] = 'a' + rune(bits>>i&1)
+			}
+			tests = append(tests, string(runes))
+		}
+	}
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		runes := make([]rune, 2+random.Intn(10))
+		for j := range runes {
+			runes[j] = 'a' + rune(random.Intn(3))
+		}
+		tests = append(tests, string(runes))
+	}
+
+	for _, s := range tests {
+		g := StringGraph(s)
+		graphPathway := Assembly(g, 100, 100, "shortest")[0]
+		if stringIndex, graphIndex := StringAssemblyIndex(s), AssemblyIndex(&graphPathway, &g); stringIndex != graphIndex {
+			t.Errorf("StringAssemblyIndex and Assembly disagree on %v, string %v, graph %v", s, stringIndex, graphIndex)
+		}
+	}
+}
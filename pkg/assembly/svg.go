@@ -0,0 +1,261 @@
+package assembly
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Code for drawing a graph as an SVG image from its Depiction, with the duplicated fragments of a pathway highlighted in
+// the same colours as DOTString and GraphMLString. Each fragment is a translucent band behind the atoms and bonds of both
+// of its occurrences, and there is a key to the fragments below the drawing
+
+const (
+	svgBondLength = 40.0 // the length of the median bond, in pixels
+	svgMargin     = 30.0
+	svgFontSize   = 14.0
+	svgLabelGap   = 8.0 // how far bonds stop short of the centre of a one letter label
+	svgBondGap    = 6.0 // the distance between the lines of a multiple bond
+	svgWedgeWidth = 3.5 // half the width of the wide end of a wedge or hash bond
+	svgKeyRow     = 20.0
+)
+
+// elementColours are the colours of the labels of common elements, others are black
+var elementColours = map[string]string{"N": "#2040d0", "O": "#e00000", "S": "#b08800", "P": "#e07000", "F": "#109010",
+	"Cl": "#109010", "Br": "#a02020", "I": "#800080"}
+
+// SVGString returns an SVG image of g drawn as given by depiction. If highlight is not nil, the vertices and edges of
+// each duplicated fragment occurrence are highlighted in the colour of the fragment, and there is a key to the colours
+func SVGString(g *Graph, depiction *Depiction, highlight *Highlight) string {
+	// the drawing is scaled so the median bond has length svgBondLength, with y upwards as in mol files
+	lengths := make([]float64, len(g.Edges))
+	for i, e := range g.Edges {
+		lengths[i] = distance(depiction.Positions[e[0]], depiction.Positions[e[1]])
+	}
+	scale := svgBondLength / median(lengths)
+	minX, maxX, minY, maxY := math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)
+	for _, v := range g.Vertices {
+		p := depiction.Positions[v]
+		minX, maxX = math.Min(minX, p[0]), math.Max(maxX, p[0])
+		minY, maxY = math.Min(minY, p[1]), math.Max(maxY, p[1])
+	}
+	if len(g.Vertices) == 0 {
+		minX, maxX, minY, maxY = 0, 0, 0, 0
+	}
+	points := make(map[int][2]float64, len(g.Vertices))
+	for _, v := range g.Vertices {
+		p := depiction.Positions[v]
+		points[v] = [2]float64{svgMargin + (p[0]-minX)*scale, svgMargin + (maxY-p[1])*scale}
+	}
+
+	width := (maxX-minX)*scale + 2*svgMargin
+	height := (maxY-minY)*scale + 2*svgMargin
+	keyTop := height
+	if highlight != nil && len(highlight.Occurrences) > 0 {
+		height += float64(len(highlight.Occurrences))*svgKeyRow + svgMargin/2
+		width = math.Max(width, 160)
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" `+
+		`font-family="sans-serif">`+"\n", width, height, width, height)
+	fmt.Fprintf(&out, `  <rect width="%.0f" height="%.0f" fill="white"/>`+"\n", width, height)
+
+	// each fragment is drawn as a whole with its opacity, so its two occurrences don't darken where they overlap
+	if highlight != nil {
+		for i, pair := range highlight.Occurrences {
+			fmt.Fprintf(&out, `  <g opacity="0.4" stroke="%v" fill="%v" stroke-width="12" stroke-linecap="round">`+"\n",
+				highlightColour(i), highlightColour(i))
+			fmt.Fprintf(&out, "    <title>fragment %v</title>\n", i)
+			for _, occurrence := range pair {
+				for _, e := range occurrence.Edges {
+					out.WriteString("    " + svgLine(points[g.Edges[e][0]], points[g.Edges[e][1]], "") + "\n")
+				}
+				for _, v := range occurrence.Vertices {
+					fmt.Fprintf(&out, `    <circle cx="%.1f" cy="%.1f" r="9" stroke="none"/>`+"\n", points[v][0], points[v][1])
+				}
+			}
+			out.WriteString("  </g>\n")
+		}
+	}
+
+	neighbours := make(map[int][]int)
+	for _, e := range g.Edges {
+		neighbours[e[0]] = append(neighbours[e[0]], e[1])
+		neighbours[e[1]] = append(neighbours[e[1]], e[0])
+	}
+	out.WriteString(`  <g stroke="black" stroke-width="1.5" stroke-linecap="round">` + "\n")
+	for i, e := range g.Edges {
+		bondType, bondStereo := 1, 0
+		if i < len(depiction.BondTypes) && i < len(depiction.BondStereo) {
+			bondType, bondStereo = depiction.BondTypes[i], depiction.BondStereo[i]
+		}
+		a, b := points[e[0]], points[e[1]]
+		gapA, gapB := labelGap(depiction.Labels[e[0]]), labelGap(depiction.Labels[e[1]])
+		if d := distance(a, b); gapA+gapB < 0.8*d {
+			a, b = along(a, b, gapA/d), along(b, a, gapB/d)
+		}
+		// the side of the bond its other bonds are on, for the inner line of a double bond in a ring or chain
+		side := 0.0
+		for j, v := range e {
+			for _, u := range neighbours[v] {
+				if u != e[1-j] {
+					side += cross(points[e[0]], points[e[1]], points[u])
+				}
+			}
+		}
+		for _, line := range svgBond(a, b, bondType, bondStereo, side) {
+			out.WriteString("    " + line + "\n")
+		}
+	}
+	out.WriteString("  </g>\n")
+
+	for _, v := range g.Vertices {
+		label := depiction.Labels[v]
+		if label.Text == "" {
+			continue
+		}
+		colour, ok := elementColours[label.Text]
+		if !ok {
+			colour = "black"
+		}
+		fmt.Fprintf(&out, `  <text x="%.1f" y="%.1f" dy="%.1f" font-size="%v" text-anchor="middle" fill="%v">%v</text>`+"\n",
+			points[v][0], points[v][1], svgFontSize*0.35, svgFontSize, colour, svgLabel(label))
+	}
+
+	if highlight != nil {
+		unit := "bonds"
+		if depiction.BondTypes == nil {
+			unit = "edges"
+		}
+		for i, pair := range highlight.Occurrences {
+			y := keyTop + float64(i)*svgKeyRow
+			fmt.Fprintf(&out, `  <rect x="%.0f" y="%.0f" width="14" height="14" fill="%v"/>`+"\n", svgMargin/2, y,
+				highlightColour(i))
+			fmt.Fprintf(&out, `  <text x="%.0f" y="%.0f" font-size="12">fragment %v, %v %v</text>`+"\n", svgMargin/2+20,
+				y+11, i, len(pair[0].Edges), unit)
+		}
+	}
+	out.WriteString("</svg>\n")
+	return out.String()
+}
+
+// svgBond returns the SVG elements of a bond from a to b: a line for a single bond, a wedge or a hash for a single bond
+// with stereo flag 1 or 6, two lines for a double bond, and three for a triple bond. Aromatic bonds (type 4) are drawn
+// as double bonds with a dashed second line. If side is not zero, the second line of a double bond is drawn inside the
+// bond on that side (see cross), otherwise the two lines are either side of the centre
+func svgBond(a [2]float64, b [2]float64, bondType int, bondStereo int, side float64) []string {
+	d := distance(a, b)
+	if d == 0 {
+		return nil
+	}
+	normal := [2]float64{-(b[1] - a[1]) / d, (b[0] - a[0]) / d}
+	offset := func(p [2]float64, by float64) [2]float64 {
+		return [2]float64{p[0] + normal[0]*by, p[1] + normal[1]*by}
+	}
+
+	switch {
+	case bondType == 2 || bondType == 4:
+		dash := ""
+		if bondType == 4 {
+			dash = "4,3"
+		}
+		if math.Abs(side) < 1e-9 {
+			return []string{svgLine(offset(a, svgBondGap/2), offset(b, svgBondGap/2), ""),
+				svgLine(offset(a, -svgBondGap/2), offset(b, -svgBondGap/2), dash)}
+		}
+		gap := math.Copysign(svgBondGap, side)
+		return []string{svgLine(a, b, ""), svgLine(offset(along(a, b, 0.15), gap), offset(along(b, a, 0.15), gap), dash)}
+	case bondType == 3:
+		return []string{svgLine(a, b, ""), svgLine(offset(a, svgBondGap), offset(b, svgBondGap), ""),
+			svgLine(offset(a, -svgBondGap), offset(b, -svgBondGap), "")}
+	case bondType == 1 && bondStereo == 1:
+		p, q := offset(b, svgWedgeWidth), offset(b, -svgWedgeWidth)
+		return []string{fmt.Sprintf(`<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="black" stroke-width="1"/>`,
+			a[0], a[1], p[0], p[1], q[0], q[1])}
+	case bondType == 1 && bondStereo == 6:
+		const hashes = 7
+		lines := make([]string, hashes)
+		for k := 1; k <= hashes; k++ {
+			t := float64(k) / hashes
+			c := along(a, b, t)
+			lines[k-1] = svgLine(offset(c, svgWedgeWidth*t), offset(c, -svgWedgeWidth*t), "")
+		}
+		return lines
+	}
+	return []string{svgLine(a, b, "")}
+}
+
+// svgLine returns an SVG line from a to b, dashed with the dash array if it isn't empty
+func svgLine(a [2]float64, b [2]float64, dash string) string {
+	if dash != "" {
+		dash = fmt.Sprintf(` stroke-dasharray="%v"`, dash)
+	}
+	return fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f"%v/>`, a[0], a[1], b[0], b[1], dash)
+}
+
+// svgLabel returns the text of a label as SVG tspans: the isotope as a superscript, the text, the hydrogens with their
+// number as a subscript, and the charge as a superscript
+func svgLabel(label DepictionLabel) string {
+	var out strings.Builder
+	shift := 0.0
+	span := func(text string, to float64) {
+		out.WriteString("<tspan")
+		if to != shift {
+			fmt.Fprintf(&out, ` dy="%v"`, to-shift)
+			shift = to
+		}
+		if to != 0 {
+			out.WriteString(` font-size="70%"`)
+		}
+		out.WriteString(">" + xmlEscape(text) + "</tspan>")
+	}
+
+	if label.Isotope != 0 {
+		span(fmt.Sprint(label.Isotope), -5)
+	}
+	span(label.Text, 0)
+	if label.Hydrogens > 0 {
+		span("H", 0)
+	}
+	if label.Hydrogens > 1 {
+		span(fmt.Sprint(label.Hydrogens), 4)
+	}
+	if label.Charge != 0 {
+		charge := ""
+		if label.Charge > 1 || label.Charge < -1 {
+			charge = fmt.Sprint(int(math.Abs(float64(label.Charge))))
+		}
+		if label.Charge > 0 {
+			charge += "+"
+		} else {
+			charge += "−"
+		}
+		span(charge, -5)
+	}
+	return out.String()
+}
+
+// labelGap returns how far bonds stop short of the centre of a label, which is further for longer labels, e.g. the
+// colours of graphs. Labels are taken to be 0.6 em wide per character
+func labelGap(label DepictionLabel) float64 {
+	if label.Text == "" {
+		return 0
+	}
+	characters := len(label.Text)
+	if label.Hydrogens > 0 {
+		characters++
+	}
+	return math.Max(svgLabelGap, 0.3*svgFontSize*float64(characters))
+}
+
+// along returns the point a fraction t of the way from a to b
+func along(a [2]float64, b [2]float64, t float64) [2]float64 {
+	return [2]float64{a[0] + (b[0]-a[0])*t, a[1] + (b[1]-a[1])*t}
+}
+
+// cross returns the cross product of b - a and p - a, which is positive if p is on the side of the line from a to b
+// that svgBond offsets lines to with a positive distance, and negative if it is on the other side
+func cross(a [2]float64, b [2]float64, p [2]float64) float64 {
+	return (b[0]-a[0])*(p[1]-a[1]) - (b[1]-a[1])*(p[0]-a[0])
+}
@@ -0,0 +1,47 @@
+graph [
+  label "Square Graph (coloured)"
+  node [
+    id 1
+    label "1"
+    colour "Red"
+  ]
+  node [
+    id 2
+    label "2"
+    colour "Blue"
+    graphics [
+      x 1.0
+      y 0.0
+    ]
+  ]
+  node [
+    id 3
+    label "3"
+    colour "Red"
+  ]
+  node [
+    id 4
+    label "4"
+    colour "Blue"
+  ]
+  edge [
+    source 1
+    target 2
+    colour "A"
+  ]
+  edge [
+    source 2
+    target 3
+    colour "B"
+  ]
+  edge [
+    source 3
+    target 4
+    colour "B"
+  ]
+  edge [
+    source 4
+    target 1
+    colour "A"
+  ]
+]
@@ -0,0 +1,125 @@
+package assembly
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Code in this file checks that Graph objects are well formed before they are used, so bad input is rejected
+// when it is read rather than partway through a long assembly calculation
+
+// ValidationIssue describes a single problem with a graph. Vertex and Edge are the positions in Graph.Vertices and
+// Graph.Edges that the issue relates to, or -1 if the issue does not relate to a particular vertex or edge
+type ValidationIssue struct {
+	Kind    string
+	Vertex  int
+	Edge    int
+	Message string
+}
+
+// Kinds of ValidationIssue
+const (
+	IssueDuplicateVertex    = "duplicate vertex"
+	IssueMissingVertex      = "missing vertex"
+	IssueSelfLoop           = "self-loop"
+	IssueDuplicateEdge      = "duplicate edge"
+	IssueVertexColourLength = "vertex colour length"
+	IssueEdgeColourLength   = "edge colour length"
+)
+
+// String returns the issue with its location
+func (issue ValidationIssue) String() string {
+	location := ""
+	if issue.Vertex != -1 {
+		location += fmt.Sprintf(" vertex %v", issue.Vertex)
+	}
+	if issue.Edge != -1 {
+		location += fmt.Sprintf(" edge %v", issue.Edge)
+	}
+	return fmt.Sprintf("%v%v: %v", issue.Kind, location, issue.Message)
+}
+
+// ValidationError is returned when a graph has one or more validation issues
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+func (e ValidationError) Error() string {
+	issueStrings := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		issueStrings[i] = issue.String()
+	}
+	return fmt.Sprintf("invalid graph, %v issue(s):\n%v", len(e.Issues), strings.Join(issueStrings, "\n"))
+}
+
+// Validate checks the graph and returns all the problems found. It checks that vertices are unique, that edges only
+// reference vertices in Vertices, that there are no self-loops or duplicate edges (unless g.Multigraph is set), and that
+// the colour slices are either empty or the same length as the vertices / edges they colour. A nil result means the graph is valid
+func (g *Graph) Validate() []ValidationIssue {
+	var issues []ValidationIssue
+
+	// vertices must be unique
+	vertexPositions := make(map[int]int)
+	for i, v := range g.Vertices {
+		if first, ok := vertexPositions[v]; ok {
+			issues = append(issues, ValidationIssue{IssueDuplicateVertex, i, -1,
+				fmt.Sprintf("vertex label %v already used at position %v", v, first)})
+		} else {
+			vertexPositions[v] = i
+		}
+	}
+
+	// edges must join two different vertices in the vertex list, and only appear once
+	edgePositions := make(map[[2]int]int)
+	for i, e := range g.Edges {
+		for _, v := range e {
+			if _, ok := vertexPositions[v]; !ok {
+				issues = append(issues, ValidationIssue{IssueMissingVertex, -1, i,
+					fmt.Sprintf("edge %v references vertex %v, which is not in the vertex list", e, v)})
+			}
+		}
+
+		// parallel edges and self-loops are allowed in multigraphs
+		if g.Multigraph {
+			continue
+		}
+
+		if e[0] == e[1] {
+			issues = append(issues, ValidationIssue{IssueSelfLoop, -1, i,
+				fmt.Sprintf("edge %v joins vertex %v to itself", e, e[0])})
+			continue
+		}
+
+		key := e
+		if !g.Directed {
+			key = ListPairSort([][2]int{e})[0]
+		}
+		if first, ok := edgePositions[key]; ok {
+			issues = append(issues, ValidationIssue{IssueDuplicateEdge, -1, i,
+				fmt.Sprintf("edge %v duplicates edge %v", e, first)})
+		} else {
+			edgePositions[key] = i
+		}
+	}
+
+	// colours are either not specified, or specified for every vertex / edge
+	if len(g.VertexColours) != 0 && len(g.VertexColours) != len(g.Vertices) {
+		issues = append(issues, ValidationIssue{IssueVertexColourLength, -1, -1,
+			fmt.Sprintf("%v vertex colours for %v vertices", len(g.VertexColours), len(g.Vertices))})
+	}
+	if len(g.EdgeColours) != 0 && len(g.EdgeColours) != len(g.Edges) {
+		issues = append(issues, ValidationIssue{IssueEdgeColourLength, -1, -1,
+			fmt.Sprintf("%v edge colours for %v edges", len(g.EdgeColours), len(g.Edges))})
+	}
+
+	return issues
+}
+
+// ValidateGraph returns a ValidationError listing all the issues with a graph, or nil if the graph is valid
+func ValidateGraph(g *Graph) error {
+	issues := g.Validate()
+	if len(issues) == 0 {
+		return nil
+	}
+	return ValidationError{issues}
+}
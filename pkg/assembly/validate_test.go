@@ -0,0 +1,90 @@
+package assembly
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		g     Graph
+		kinds []string
+	}{
+		{
+			NewColourGraphFromIDs([]int{1, 2, 3}, [][2]int{{1, 2}, {2, 3}}, []int{}, []int{}),
+			nil,
+		},
+		{
+			NewColourGraphFromIDs([]int{1, 2, 2}, [][2]int{{1, 2}}, []int{}, []int{}),
+			[]string{IssueDuplicateVertex},
+		},
+		{
+			NewColourGraphFromIDs([]int{1, 2, 3}, [][2]int{{1, 2}, {2, 2}, {3, 4}, {2, 1}}, []int{}, []int{}),
+			[]string{IssueSelfLoop, IssueMissingVertex, IssueDuplicateEdge},
+		},
+		{
+			Graph{Vertices: []int{1, 2, 3}, Edges: [][2]int{{1, 2}, {2, 2}, {2, 1}}, Multigraph: true},
+			nil,
+		},
+		{
+			// opposite directions are different edges in a directed graph
+			Graph{Vertices: []int{1, 2}, Edges: [][2]int{{1, 2}, {2, 1}}, Directed: true},
+			nil,
+		},
+		{
+			Graph{Vertices: []int{1, 2}, Edges: [][2]int{{1, 2}, {1, 2}}, Directed: true},
+			[]string{IssueDuplicateEdge},
+		},
+		{
+			NewColourGraphFromIDs([]int{1, 2, 3}, [][2]int{{1, 2}, {2, 3}}, []int{0, 1}, []int{0}),
+			[]string{IssueVertexColourLength, IssueEdgeColourLength},
+		},
+	}
+
+	for i, tt := range tests {
+		issues := tt.g.Validate()
+		var kinds []string
+		for _, issue := range issues {
+			kinds = append(kinds, issue.Kind)
+		}
+		if !reflect.DeepEqual(kinds, tt.kinds) {
+			t.Errorf("Validate error on test %v, graph %v\nExpected %v\nGot %v", i, GraphPrint(&tt.g), tt.kinds, issues)
+		}
+		if (ValidateGraph(&tt.g) == nil) != (len(tt.kinds) == 0) {
+			t.Errorf("ValidateGraph error on test %v, issues %v but error %v", i, issues, ValidateGraph(&tt.g))
+		}
+	}
+}
+
+func TestValidateIssueLocation(t *testing.T) {
+	g := NewColourGraphFromIDs([]int{1, 2, 3}, [][2]int{{1, 2}, {2, 3}, {3, 5}}, []int{}, []int{})
+	issues := g.Validate()
+	expected := []ValidationIssue{{IssueMissingVertex, -1, 2, "edge [3 5] references vertex 5, which is not in the vertex list"}}
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("Validate location error, expected %v got %v", expected, issues)
+	}
+}
+
+func TestNewGraphFromStringValidation(t *testing.T) {
+	_, _, err := NewGraphFromString("bad graph\n1 2 3\n1 2 2 5\n!\n!")
+	if _, ok := err.(ValidationError); !ok {
+		t.Errorf("NewGraphFromString expected ValidationError, got %v", err)
+	}
+}
+
+func TestNewGraphFromStringBadNumbers(t *testing.T) {
+	// bad numbers are returned as errors, not fatal, so callers can reject the graph
+	for _, graphString := range []string{"bad vertex\n1 x 3\n1 2 2 3", "bad edge\n1 2 3\n1 2 2 y"} {
+		if _, _, err := NewGraphFromString(graphString); err == nil {
+			t.Errorf("NewGraphFromString %q expected an error", graphString)
+		}
+	}
+}
+
+func TestNewColourGraphInvalid(t *testing.T) {
+	// the constructors don't validate, so an invalid graph is reported by ValidateGraph rather than being fatal
+	g := NewColourGraph([]int{1, 2}, [][2]int{{1, 3}}, []string{}, []string{})
+	if _, ok := ValidateGraph(&g).(ValidationError); !ok {
+		t.Errorf("ValidateGraph expected ValidationError for %v", GraphPrint(&g))
+	}
+}
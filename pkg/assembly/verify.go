@@ -0,0 +1,474 @@
+package assembly
+
+import (
+	"GoAssembly/pkg/helpers"
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Code in this file checks that a pathway is sensible for an original graph, independently of how it was found. This
+// allows results from other tools, or from older versions of this one, to be checked. Pathways are read from the output
+// of AssemblyString (e.g. the -verbose output of the executable)
+
+// VerificationIssue describes a single problem with a pathway. Duplicate is the position in the pathway of the duplicate
+// that the issue relates to, or -1 if the issue does not relate to a particular duplicate
+type VerificationIssue struct {
+	Kind      string
+	Duplicate int
+	Message   string
+}
+
+// Kinds of VerificationIssue
+const (
+	IssueDuplicateCount          = "duplicate count"
+	IssueDuplicateNotInOriginal  = "duplicate not in original"
+	IssueDuplicatesNotIsomorphic = "duplicates not isomorphic"
+	IssueDuplicatesOverlap       = "duplicates overlap"
+	IssueFragmentMismatch        = "fragment mismatch"
+	IssueRemnant                 = "remnant"
+	IssueAtomEquivalents         = "atom equivalents"
+	IssueIndexNotAchievable      = "index not achievable"
+	IssueClaimedIndex            = "claimed index"
+)
+
+// String returns the issue with the duplicate it relates to
+func (issue VerificationIssue) String() string {
+	location := ""
+	if issue.Duplicate != -1 {
+		location = fmt.Sprintf(" duplicate %v", issue.Duplicate)
+	}
+	return fmt.Sprintf("%v%v: %v", issue.Kind, location, issue.Message)
+}
+
+// VerificationError is returned when a pathway has one or more verification issues
+type VerificationError struct {
+	Issues []VerificationIssue
+}
+
+func (e VerificationError) Error() string {
+	issueStrings := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		issueStrings[i] = issue.String()
+	}
+	return fmt.Sprintf("invalid pathway, %v issue(s):\n%v", len(e.Issues), strings.Join(issueStrings, "\n"))
+}
+
+// VerifyPathway checks an edge based pathway against the original graph. It checks that there is a Duplicates for each
+// graph in the pathway, that the left and right of each are in the original graph, are isomorphic to each other and to
+// the pathway graph, and don't share edges, that the lefts and the remnant split up the edges of the original graph,
+// that atomEquivalents is consistent, and that the AssemblyIndex of the pathway can be achieved by replaying it as an
+// assembly space (see PathwayAssemblySpace). Labels reused by older versions are resolved first, with each standing
+// for the original vertex it had at that step (see resolvePathwayLabels). It returns a VerificationError listing all
+// the issues found, or nil
+func VerifyPathway(original *Graph, pathway *Pathway) error {
+	return verificationError(pathwayIssues(original, pathway))
+}
+
+// VerifyPathwayIndex is VerifyPathway, also checking that the AssemblyIndex of the pathway is claimedIndex
+func VerifyPathwayIndex(original *Graph, pathway *Pathway, claimedIndex int) error {
+	issues := pathwayIssues(original, pathway)
+	if index := AssemblyIndex(pathway, original); index != claimedIndex {
+		issues = append(issues, VerificationIssue{IssueClaimedIndex, -1,
+			fmt.Sprintf("assembly index %v was claimed, but the pathway gives %v", claimedIndex, index)})
+	}
+	return verificationError(issues)
+}
+
+func verificationError(issues []VerificationIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	return VerificationError{issues}
+}
+
+// pathwayIssues returns all the issues found by VerifyPathway
+func pathwayIssues(original *Graph, pathway *Pathway) []VerificationIssue {
+	issues := atomEquivalentIssues(original, pathway)
+	if resolved, ok := resolvePathwayLabels(original, pathway); ok {
+		pathway = &resolved
+	}
+
+	if len(pathway.pathway) != len(pathway.duplicates) {
+		issues = append(issues, VerificationIssue{IssueDuplicateCount, -1,
+			fmt.Sprintf("pathway has %v graphs but %v duplicates", len(pathway.pathway), len(pathway.duplicates))})
+	}
+
+	// the lefts and the remnant share one finder, as each original edge should be in exactly one of them
+	partFinder := newOriginalEdgeFinder(original)
+	for i, d := range pathway.duplicates {
+		leftFinder := newOriginalEdgeFinder(original)
+		left, leftErr := leftFinder.find(pathway, d.left)
+		if leftErr != nil {
+			issues = append(issues, VerificationIssue{IssueDuplicateNotInOriginal, i, fmt.Sprintf("left: %v", leftErr)})
+		}
+		rightFinder := newOriginalEdgeFinder(original)
+		right, rightErr := rightFinder.find(pathway, d.right)
+		if rightErr != nil {
+			issues = append(issues, VerificationIssue{IssueDuplicateNotInOriginal, i, fmt.Sprintf("right: %v", rightErr)})
+		}
+		if leftErr != nil || rightErr != nil {
+			continue
+		}
+
+		if _, err := partFinder.find(pathway, d.left); err != nil {
+			issues = append(issues, VerificationIssue{IssueDuplicatesOverlap, i,
+				fmt.Sprintf("left uses original edges already used by an earlier duplicate: %v", err)})
+		}
+		if _, err := leftFinder.find(pathway, d.right); err != nil {
+			issues = append(issues, VerificationIssue{IssueDuplicatesOverlap, i, "left and right share original edges"})
+		}
+
+		leftGraph, _ := BreakGraphOnEdges(original, left)
+		rightGraph, _ := BreakGraphOnEdges(original, right)
+		if !GraphsIsomorphic(&leftGraph, &rightGraph) {
+			issues = append(issues, VerificationIssue{IssueDuplicatesNotIsomorphic, i,
+				fmt.Sprintf("left %v is not the same as right %v", d.left, d.right)})
+		}
+		if i < len(pathway.pathway) {
+			fragment := CopyGraph(&pathway.pathway[i])
+			fragment.copyKind(original)
+			if err := ValidateGraph(&fragment); err != nil {
+				issues = append(issues, VerificationIssue{IssueFragmentMismatch, i, fmt.Sprintf("pathway graph: %v", err)})
+			} else if GraphIsVertexColoured(&fragment) != GraphIsVertexColoured(&leftGraph) ||
+				GraphIsEdgeColoured(&fragment) != GraphIsEdgeColoured(&leftGraph) ||
+				!GraphsIsomorphic(&fragment, &leftGraph) {
+				issues = append(issues, VerificationIssue{IssueFragmentMismatch, i,
+					fmt.Sprintf("pathway graph %v is not the same as left %v", fragment.Edges, d.left)})
+			}
+		}
+	}
+
+	if _, err := partFinder.find(pathway, pathway.remnant.Edges); err != nil {
+		issues = append(issues, VerificationIssue{IssueRemnant, -1, err.Error()})
+	} else if unused := partFinder.unused(); len(unused) != 0 {
+		issues = append(issues, VerificationIssue{IssueRemnant, -1,
+			fmt.Sprintf("original edges %v are not in the remnant or any duplicate", unused)})
+	}
+
+	// only replay the pathway if it is otherwise sensible, as the assembly space can't be found otherwise
+	if len(issues) == 0 {
+		space, err := PathwayAssemblySpace(pathway, original)
+		if err == nil {
+			err = ValidateAssemblySpace(&space, pathway, original)
+		}
+		if err != nil {
+			issues = append(issues, VerificationIssue{IssueIndexNotAchievable, -1, err.Error()})
+		}
+	}
+
+	return issues
+}
+
+// atomEquivalentIssues checks that each class of atomEquivalents starts with a vertex of the original graph, followed
+// by the labels it was relabelled to. A label may also be a vertex of the original graph, or be in more than one class,
+// as older versions reused labels (see resolvePathwayLabels)
+func atomEquivalentIssues(original *Graph, pathway *Pathway) []VerificationIssue {
+	var issues []VerificationIssue
+	originalVertices := make(map[int]bool)
+	for _, v := range original.Vertices {
+		originalVertices[v] = true
+	}
+
+	for i, equivalents := range pathway.atomEquivalents {
+		if len(equivalents) < 2 {
+			issues = append(issues, VerificationIssue{IssueAtomEquivalents, -1,
+				fmt.Sprintf("class %v %v has fewer than two labels", i, equivalents)})
+		}
+		if len(equivalents) > 0 && !originalVertices[equivalents[0]] {
+			issues = append(issues, VerificationIssue{IssueAtomEquivalents, -1,
+				fmt.Sprintf("class %v %v starts with %v, which is not in the original graph", i, equivalents,
+					equivalents[0])})
+		}
+	}
+	return issues
+}
+
+// resolvePathwayLabels returns a copy of pathway with the vertices of its duplicates and remnant replaced by the vertices
+// of the original graph they came from, and no atomEquivalents. Older versions reused the labels of vertices that were
+// no longer in the remnant, so a label can be both a vertex of the original graph and a relabelled vertex, or be in
+// more than one class of atomEquivalents, and stand for different original vertices at different steps. Labels are
+// unique in the remnant at each step, so each duplicate, and the final remnant, has one meaning for each label. The
+// meanings of ambiguous labels are tried in turn, backtracking, until the lefts and rights are in the original graph
+// and isomorphic, and the lefts and the remnant split up the original edges. It returns false if there are no such
+// meanings, and the pathway should be checked as it is
+func resolvePathwayLabels(original *Graph, pathway *Pathway) (Pathway, bool) {
+	originalVertices := make(map[int]bool)
+	for _, v := range original.Vertices {
+		originalVertices[v] = true
+	}
+	// the original vertices each label could stand for, with the one originalVertex gives first
+	meanings := make(map[int][]int)
+	for _, equivalents := range pathway.atomEquivalents {
+		for _, v := range equivalents {
+			if !helpers.Contains(meanings[v], equivalents[0]) {
+				meanings[v] = append(meanings[v], equivalents[0])
+			}
+		}
+	}
+	for v := range meanings {
+		if originalVertices[v] && !helpers.Contains(meanings[v], v) {
+			meanings[v] = append(meanings[v], v)
+		}
+	}
+
+	resolved := Pathway{pathway: pathway.pathway, duplicates: make([]Duplicates, len(pathway.duplicates))}
+	var resolve func(i int, partFinder originalEdgeFinder) bool
+	resolve = func(i int, partFinder originalEdgeFinder) bool {
+		if i == len(pathway.duplicates) {
+			for _, originalOf := range labelChoices(meanings, pathway.remnant.Edges) {
+				finder := partFinder.copy()
+				if _, err := finder.findWith(pathway.remnant.Edges, originalOf); err == nil && len(finder.unused()) == 0 {
+					resolved.remnant = relabelGraph(&pathway.remnant, originalOf)
+					return true
+				}
+			}
+			return false
+		}
+
+		d := pathway.duplicates[i]
+		choices := labelChoices(meanings, append(append([][2]int{}, d.left...), d.right...))
+		for _, originalOf := range choices {
+			stepFinder := newOriginalEdgeFinder(original)
+			left, err := stepFinder.findWith(d.left, originalOf)
+			if err != nil {
+				continue
+			}
+			right, err := stepFinder.findWith(d.right, originalOf)
+			if err != nil {
+				continue
+			}
+			nextFinder := partFinder.copy()
+			if _, err := nextFinder.findWith(d.left, originalOf); err != nil {
+				continue
+			}
+			if len(choices) > 1 {
+				leftGraph, _ := BreakGraphOnEdges(original, left)
+				rightGraph, _ := BreakGraphOnEdges(original, right)
+				if !GraphsIsomorphic(&leftGraph, &rightGraph) {
+					continue
+				}
+			}
+			resolved.duplicates[i] = Duplicates{relabelEdges(d.left, originalOf), relabelEdges(d.right, originalOf)}
+			if resolve(i+1, nextFinder) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !resolve(0, newOriginalEdgeFinder(original)) {
+		return Pathway{}, false
+	}
+	return resolved, true
+}
+
+// labelChoices returns a function mapping labels to original vertices for each combination of the meanings of the
+// labels of edges with more than one meaning. Labels with one meaning, or none, map to it or to themselves
+func labelChoices(meanings map[int][]int, edges [][2]int) []func(int) int {
+	var ambiguous []int
+	for _, e := range edges {
+		for _, v := range e {
+			if len(meanings[v]) > 1 && !helpers.Contains(ambiguous, v) {
+				ambiguous = append(ambiguous, v)
+			}
+		}
+	}
+
+	choices := []map[int]int{{}}
+	for _, v := range ambiguous {
+		var next []map[int]int
+		for _, choice := range choices {
+			for _, meaning := range meanings[v] {
+				extended := map[int]int{v: meaning}
+				for u, m := range choice {
+					extended[u] = m
+				}
+				next = append(next, extended)
+			}
+		}
+		choices = next
+	}
+
+	originalOfs := make([]func(int) int, len(choices))
+	for i, choice := range choices {
+		choice := choice
+		originalOfs[i] = func(v int) int {
+			if meaning, ok := choice[v]; ok {
+				return meaning
+			}
+			if len(meanings[v]) > 0 {
+				return meanings[v][0]
+			}
+			return v
+		}
+	}
+	return originalOfs
+}
+
+// relabelEdges returns edges with their vertices mapped by originalOf
+func relabelEdges(edges [][2]int, originalOf func(int) int) [][2]int {
+	relabelled := make([][2]int, len(edges))
+	for i, e := range edges {
+		relabelled[i] = [2]int{originalOf(e[0]), originalOf(e[1])}
+	}
+	return relabelled
+}
+
+// relabelGraph returns g with its vertices mapped by originalOf. Vertices that map to the same vertex are merged
+func relabelGraph(g *Graph, originalOf func(int) int) Graph {
+	var vertices, vColours []int
+	for i, v := range g.Vertices {
+		if v = originalOf(v); helpers.Contains(vertices, v) {
+			continue
+		}
+		vertices = append(vertices, v)
+		if GraphIsVertexColoured(g) {
+			vColours = append(vColours, g.VertexColours[i])
+		}
+	}
+	relabelled := NewColourGraphFromIDs(vertices, relabelEdges(g.Edges, originalOf), vColours,
+		append([]int{}, g.EdgeColours...))
+	relabelled.copyKind(g)
+	return relabelled
+}
+
+var edgePattern = regexp.MustCompile(`\[(-?\d+) (-?\d+)\]`)
+
+// ParsePathwayString reads the first pathway from the output of AssemblyString, e.g. the -verbose output of the
+// executable. It also returns the assembly index claimed in the output, or -1 if there isn't one
+func ParsePathwayString(pathwayString string) (Pathway, int, error) {
+	pathway := NewStartingPathway(NewColourGraphFromIDs([]int{}, [][2]int{}, []int{}, []int{}))
+	claimedIndex := -1
+
+	section := ""
+	pathwayFound := false
+	remnantFound := false
+	var fields map[string]string
+	scanner := bufio.NewScanner(strings.NewReader(pathwayString))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "Pathway Graphs":
+			// all_shortest gives several pathways, only the first is read
+			if pathwayFound {
+				return pathway, claimedIndex, nil
+			}
+			pathwayFound = true
+			section = "graphs"
+		case line == "Remnant Graph":
+			section = "remnant"
+			fields = make(map[string]string)
+		case line == "Duplicated Edges":
+			section = "duplicates"
+		case line == "Atom Equivalents":
+			section = "equivalents"
+		case strings.HasPrefix(line, "Assembly Index:"):
+			index, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "Assembly Index:")))
+			if err != nil {
+				return pathway, claimedIndex, fmt.Errorf("bad assembly index line %q: %v", line, err)
+			}
+			claimedIndex = index
+		case line == "======" && section == "graphs":
+			if fields == nil {
+				fields = make(map[string]string)
+				continue
+			}
+			g, err := graphFromFields(fields)
+			if err != nil {
+				return pathway, claimedIndex, err
+			}
+			pathway.pathway = append(pathway.pathway, g)
+			fields = nil
+		case line == "----------" && section == "remnant":
+			g, err := graphFromFields(fields)
+			if err != nil {
+				return pathway, claimedIndex, err
+			}
+			pathway.remnant = g
+			remnantFound = true
+			section = ""
+		case line == "+++++++++++++++" || line == "###############":
+			section = ""
+		case section == "duplicates" && strings.HasPrefix(line, "{"):
+			d, err := parseDuplicates(line)
+			if err != nil {
+				return pathway, claimedIndex, err
+			}
+			pathway.duplicates = append(pathway.duplicates, d)
+		case section == "equivalents" && strings.HasPrefix(line, "["):
+			equivalents, err := parseIntList(line)
+			if err != nil {
+				return pathway, claimedIndex, err
+			}
+			pathway.atomEquivalents = append(pathway.atomEquivalents, equivalents)
+		case (section == "graphs" || section == "remnant") && fields != nil:
+			if parts := strings.SplitN(line, " ", 2); len(parts) == 2 {
+				fields[parts[0]] = parts[1]
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return pathway, claimedIndex, err
+	}
+	if !pathwayFound || !remnantFound {
+		return pathway, claimedIndex, fmt.Errorf("no pathway found")
+	}
+	return pathway, claimedIndex, nil
+}
+
+// graphFromFields makes a graph from the lines of GraphPrint, keyed by their first word
+func graphFromFields(fields map[string]string) (Graph, error) {
+	vertices, err := parseIntList(fields["Vertices"])
+	if err != nil {
+		return Graph{}, err
+	}
+	edges := parseEdgeList(fields["Edges"])
+	vColours := Colours.InternSlice(parseNameList(fields["VertexColours"]))
+	eColours := Colours.InternSlice(parseNameList(fields["EdgeColours"]))
+	return NewColourGraphFromIDs(vertices, edges, vColours, eColours), nil
+}
+
+// parseDuplicates parses a Duplicates as printed by fmt, e.g. {[[1 4] [4 7]] [[5 2] [7 5]]}
+func parseDuplicates(line string) (Duplicates, error) {
+	inner := strings.TrimSuffix(strings.TrimPrefix(line, "{"), "}")
+	split := strings.Index(inner, "]]") + 2
+	if strings.HasPrefix(inner, "[]") {
+		split = 2
+	}
+	if split < 2 {
+		return Duplicates{}, fmt.Errorf("bad duplicates line %q", line)
+	}
+	return Duplicates{parseEdgeList(inner[:split]), parseEdgeList(inner[split:])}, nil
+}
+
+// parseEdgeList parses an edge list as printed by fmt, e.g. [[1 4] [4 7]]
+func parseEdgeList(s string) [][2]int {
+	edges := [][2]int{}
+	for _, match := range edgePattern.FindAllStringSubmatch(s, -1) {
+		v1, _ := strconv.Atoi(match[1])
+		v2, _ := strconv.Atoi(match[2])
+		edges = append(edges, [2]int{v1, v2})
+	}
+	return edges
+}
+
+// parseIntList parses an int slice as printed by fmt, e.g. [2 13]
+func parseIntList(s string) ([]int, error) {
+	ints := []int{}
+	for _, field := range parseNameList(s) {
+		i, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("bad integer list %q: %v", s, err)
+		}
+		ints = append(ints, i)
+	}
+	return ints, nil
+}
+
+// parseNameList parses a string slice as printed by fmt, e.g. [C C O]
+func parseNameList(s string) []string {
+	return strings.Fields(strings.Trim(strings.TrimSpace(s), "[]"))
+}
@@ -0,0 +1,123 @@
+package assembly
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+func TestVerifyPathwayAssembly(t *testing.T) {
+	tests := []Graph{
+		NewGraphOnlyFromFile("testdata/graphs/square.txt"),
+		MolColourGraph("testdata/aspirin.mol"),
+		StringGraph("ATGATGATGATG"),
+	}
+
+	for _, g := range tests {
+		pathway := Assembly(g, 100, 100, "shortest")[0]
+		if err := VerifyPathwayIndex(&g, &pathway, AssemblyIndex(&pathway, &g)); err != nil {
+			t.Errorf("VerifyPathway error on an assembly pathway: %v\n%v", err, PathwayString(&pathway))
+		}
+	}
+}
+
+func TestParsePathwayString(t *testing.T) {
+	molGraph := MolColourGraph("testdata/aspirin.mol")
+	pathways := Assembly(molGraph, 100, 100, "shortest")
+	outString := AssemblyString(pathways, &molGraph) + "Assembly Index: 8\n"
+
+	pathway, claimedIndex, err := ParsePathwayString(outString)
+	if err != nil {
+		t.Fatalf("ParsePathwayString error: %v", err)
+	}
+	if claimedIndex != 8 {
+		t.Errorf("ParsePathwayString claimed index should be 8, got %v", claimedIndex)
+	}
+	if PathwayString(&pathway) != PathwayString(&pathways[0]) {
+		t.Errorf("ParsePathwayString got\n%v\nexpected\n%v", PathwayString(&pathway), PathwayString(&pathways[0]))
+	}
+	if err := VerifyPathwayIndex(&molGraph, &pathway, claimedIndex); err != nil {
+		t.Errorf("VerifyPathwayIndex error on a parsed pathway: %v", err)
+	}
+
+	if _, _, err := ParsePathwayString("Assembly Index: 8\n"); err == nil {
+		t.Errorf("ParsePathwayString should give an error if there is no pathway")
+	}
+}
+
+func TestVerifyPathwayReusedLabels(t *testing.T) {
+	// pathways from an older version, which reused labels: in 100360 the copy of 8 is relabelled to 14 once 14 has been
+	// broken off, and in 655273 labels 9 and 10 are each in two classes of atom equivalents
+	tests := []string{"100360", "655273"}
+
+	for _, name := range tests {
+		g := MolColourGraph("testdata/test_mols/" + name + ".mol")
+		pathwayString, err := ioutil.ReadFile("testdata/pathways/" + name + "_reused_labels.txt")
+		if err != nil {
+			t.Fatalf("ReadFile error: %v", err)
+		}
+		pathway, claimedIndex, err := ParsePathwayString(string(pathwayString))
+		if err != nil {
+			t.Errorf("ParsePathwayString %v error: %v", name, err)
+			continue
+		}
+		if err := VerifyPathwayIndex(&g, &pathway, claimedIndex); err != nil {
+			t.Errorf("VerifyPathwayIndex %v should be valid, got %v", name, err)
+		}
+	}
+}
+
+func TestVerifyPathway(t *testing.T) {
+	// a path of four edges, with the first two duplicated by the last two
+	original := NewColourGraph([]int{1, 2, 3, 4, 5}, [][2]int{{1, 2}, {2, 3}, {3, 4}, {4, 5}}, []string{}, []string{})
+	fragment := NewColourGraph([]int{1, 2, 3}, [][2]int{{1, 2}, {2, 3}}, []string{}, []string{})
+	edge := NewColourGraph([]int{1, 2}, [][2]int{{1, 2}}, []string{}, []string{})
+	remnant := NewColourGraph([]int{3, 4, 5}, [][2]int{{3, 4}, {4, 5}}, []string{}, []string{})
+	left := [][2]int{{1, 2}, {2, 3}}
+	right := [][2]int{{3, 4}, {4, 5}}
+
+	tests := []struct {
+		name    string
+		pathway Pathway
+		kind    string
+	}{
+		{"valid", NewPathway([]Graph{fragment}, remnant, []Duplicates{{left, right}}, nil), ""},
+		{"missing duplicates", NewPathway([]Graph{fragment}, remnant, nil, nil), IssueDuplicateCount},
+		{"overlap", NewPathway([]Graph{fragment}, remnant, []Duplicates{{left, [][2]int{{2, 3}, {3, 4}}}}, nil), IssueDuplicatesOverlap},
+		{"not in original", NewPathway([]Graph{fragment}, remnant, []Duplicates{{left, [][2]int{{3, 4}, {1, 5}}}}, nil), IssueDuplicateNotInOriginal},
+		{"not isomorphic", NewPathway([]Graph{fragment}, remnant, []Duplicates{{left, [][2]int{{3, 4}}}}, nil), IssueDuplicatesNotIsomorphic},
+		{"fragment mismatch", NewPathway([]Graph{edge}, remnant, []Duplicates{{left, right}}, nil), IssueFragmentMismatch},
+		{"remnant missing edge", NewPathway([]Graph{fragment}, edge, []Duplicates{{left, right}}, nil), IssueRemnant},
+		{"single equivalent", NewPathway([]Graph{fragment}, remnant, []Duplicates{{left, right}}, [][]int{{3}}), IssueAtomEquivalents},
+		{"equivalent not in original", NewPathway([]Graph{fragment}, remnant, []Duplicates{{left, right}}, [][]int{{6, 7}}), IssueAtomEquivalents},
+		{"equivalent reused in original", NewPathway([]Graph{fragment}, remnant, []Duplicates{{left, right}}, [][]int{{3, 4}}), ""},
+	}
+
+	for _, test := range tests {
+		err := VerifyPathway(&original, &test.pathway)
+		if test.kind == "" {
+			if err != nil {
+				t.Errorf("VerifyPathway %v should be valid, got %v", test.name, err)
+			}
+			continue
+		}
+
+		var verificationErr VerificationError
+		if !errors.As(err, &verificationErr) {
+			t.Errorf("VerifyPathway %v should give a VerificationError, got %v", test.name, err)
+			continue
+		}
+		found := false
+		for _, issue := range verificationErr.Issues {
+			found = found || issue.Kind == test.kind
+		}
+		if !found {
+			t.Errorf("VerifyPathway %v should give a %v issue, got %v", test.name, test.kind, err)
+		}
+	}
+
+	valid := tests[0].pathway
+	if err := VerifyPathwayIndex(&original, &valid, 3); err == nil {
+		t.Errorf("VerifyPathwayIndex should give an error for the wrong claimed index")
+	}
+}
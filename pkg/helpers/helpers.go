@@ -20,6 +20,16 @@ func Contains(l []int, i int) bool {
 	return false
 }
 
+// ContainsAll returns true if every item of sub is in slice l
+func ContainsAll(l []int, sub []int) bool {
+	for _, i := range sub {
+		if !Contains(l, i) {
+			return false
+		}
+	}
+	return true
+}
+
 // ContainsStr returns true if i is in slice l
 func ContainsStr(l []string, i string) bool {
 	for _, v := range l {
@@ -88,6 +98,14 @@ func SortInnerIntList(l [][]int) {
 	}
 }
 
+// SortedCopy returns a sorted copy of a slice of ints
+func SortedCopy(l []int) []int {
+	sorted := make([]int, len(l))
+	copy(sorted, l)
+	sort.Ints(sorted)
+	return sorted
+}
+
 // SortSliceOfSlices sorts a slice of int slices lexographically
 func SortSliceOfSlices(l [][]int) {
 
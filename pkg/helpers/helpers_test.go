@@ -24,6 +24,24 @@ func TestContains(t *testing.T) {
 	}
 }
 
+func TestContainsAll(t *testing.T) {
+	var tests = []struct {
+		list    []int
+		sub     []int
+		desired bool
+	}{
+		{[]int{1, 2, 3, 4, 5}, []int{4, 1}, true},
+		{[]int{1, 2, 3, 4, 5}, []int{1, 6}, false},
+		{[]int{1, 2}, []int{}, true},
+	}
+	for _, test := range tests {
+		output := ContainsAll(test.list, test.sub)
+		if output != test.desired {
+			t.Errorf("Expected ContainsAll(%v,%v) to be %v, got %v", test.list, test.sub, test.desired, output)
+		}
+	}
+}
+
 func TestMapUpdate(t *testing.T) {
 	var testMap = map[int][]int{
 		1: {},
@@ -390,4 +408,21 @@ func TestIntInSliceOfSlices(t *testing.T) {
 
 
 	}
-}
\ No newline at end of file
+}
+func TestSortedCopy(t *testing.T) {
+	var tests = []struct {
+		list    []int
+		desired []int
+	}{
+		{[]int{3, 1, 2}, []int{1, 2, 3}},
+		{[]int{}, []int{}},
+	}
+	for _, test := range tests {
+		input := make([]int, len(test.list))
+		copy(input, test.list)
+		output := SortedCopy(input)
+		if !reflect.DeepEqual(output, test.desired) || !reflect.DeepEqual(input, test.list) {
+			t.Errorf("Expected SortedCopy(%v) to be %v without changing the input, got %v, input now %v", test.list, test.desired, output, input)
+		}
+	}
+}